@@ -0,0 +1,343 @@
+package renderscreenshot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// CreditsUsedHeader is the HTTP header reporting the credits charged for a request.
+	CreditsUsedHeader = "X-Credits-Used"
+	// CreditsRemainingHeader is the HTTP header reporting the account's remaining credits.
+	CreditsRemainingHeader = "X-Credits-Remaining"
+)
+
+// BudgetPeriod identifies the window a budget limit applies to.
+type BudgetPeriod string
+
+// Supported budget periods. Days and months are calendar periods in UTC.
+const (
+	BudgetDaily   BudgetPeriod = "daily"
+	BudgetMonthly BudgetPeriod = "monthly"
+)
+
+// DefaultBudgetThresholds are the fractions of a limit at which
+// BudgetGuard reports alerts.
+var DefaultBudgetThresholds = []float64{0.5, 0.8, 1.0}
+
+// BudgetLimits sets credit ceilings. A zero value means unlimited.
+type BudgetLimits struct {
+	Daily   int
+	Monthly int
+}
+
+// BudgetAlert is passed to threshold callbacks when usage crosses a threshold.
+type BudgetAlert struct {
+	// Tenant is the tenant whose budget crossed the threshold, or "" for the client-wide budget.
+	Tenant    string
+	Period    BudgetPeriod
+	Threshold float64
+	Used      int
+	Limit     int
+}
+
+// BudgetUsage reports the credits consumed in the current periods.
+type BudgetUsage struct {
+	Daily   int
+	Monthly int
+}
+
+type budgetCounter struct {
+	day     string
+	month   string
+	daily   int
+	monthly int
+	// firedDaily and firedMonthly record the thresholds already reported
+	// in the current day and month.
+	firedDaily   map[float64]bool
+	firedMonthly map[float64]bool
+}
+
+// BudgetGuard enforces credit ceilings locally before captures are issued.
+// Attach it to a client with WithBudgetGuard. Usage is attributed to the
+// tenant carried by the request context (see ContextWithTenant) and to the
+// client as a whole; a request is refused with a CodeNoCredits error if it
+// would exceed either ceiling.
+type BudgetGuard struct {
+	mu           sync.Mutex
+	limits       BudgetLimits
+	tenantLimits map[string]BudgetLimits
+	counters     map[string]*budgetCounter
+	thresholds   []float64
+	callbacks    []func(BudgetAlert)
+	remaining    int
+	hasRemaining bool
+	now          func() time.Time
+}
+
+// NewBudgetGuard creates a BudgetGuard with client-wide limits.
+func NewBudgetGuard(limits BudgetLimits) *BudgetGuard {
+	return &BudgetGuard{
+		limits:       limits,
+		tenantLimits: map[string]BudgetLimits{},
+		counters:     map[string]*budgetCounter{},
+		thresholds:   DefaultBudgetThresholds,
+		now:          time.Now,
+	}
+}
+
+// SetTenantLimits sets the ceilings for a single tenant.
+func (g *BudgetGuard) SetTenantLimits(tenant string, limits BudgetLimits) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tenantLimits[tenant] = limits
+}
+
+// SetThresholds replaces the fractions of a limit at which alerts fire.
+func (g *BudgetGuard) SetThresholds(thresholds ...float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.thresholds = thresholds
+}
+
+// OnThreshold registers a callback invoked once per period each time usage
+// crosses one of the configured thresholds.
+func (g *BudgetGuard) OnThreshold(fn func(BudgetAlert)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.callbacks = append(g.callbacks, fn)
+}
+
+// Usage returns the credits consumed by tenant ("" for the client-wide total).
+func (g *BudgetGuard) Usage(tenant string) BudgetUsage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := g.counter(tenantScope(tenant))
+	return BudgetUsage{Daily: c.daily, Monthly: c.monthly}
+}
+
+// Sync polls the account's remaining credits with client.Usage so that
+// requests are refused locally once the account runs dry.
+func (g *BudgetGuard) Sync(ctx context.Context, client *Client) error {
+	usage, err := client.Usage(ctx)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remaining = usage.Remaining
+	g.hasRemaining = true
+	return nil
+}
+
+// budgetReservation holds credits reserved for an in-flight request.
+type budgetReservation struct {
+	tenant string
+	cost   int
+}
+
+// reserve checks every applicable ceiling and, if cost fits, reserves it.
+func (g *BudgetGuard) reserve(tenant string, cost int) (*budgetReservation, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.hasRemaining && cost > g.remaining {
+		return nil, &Error{
+			Message: fmt.Sprintf("Insufficient credits: %d remaining, %d required", g.remaining, cost),
+			Code:    CodeNoCredits,
+		}
+	}
+
+	scopes := []string{tenantScope("")}
+	if tenant != "" {
+		scopes = append(scopes, tenantScope(tenant))
+	}
+	for _, scope := range scopes {
+		limits := g.limitsFor(scope)
+		c := g.counter(scope)
+		if limits.Daily > 0 && c.daily+cost > limits.Daily {
+			return nil, budgetExceeded(scope, BudgetDaily, c.daily, limits.Daily)
+		}
+		if limits.Monthly > 0 && c.monthly+cost > limits.Monthly {
+			return nil, budgetExceeded(scope, BudgetMonthly, c.monthly, limits.Monthly)
+		}
+	}
+
+	for _, scope := range scopes {
+		c := g.counter(scope)
+		c.daily += cost
+		c.monthly += cost
+	}
+	if g.hasRemaining {
+		g.remaining -= cost
+	}
+	return &budgetReservation{tenant: tenant, cost: cost}, nil
+}
+
+// release returns reserved credits after a failed request.
+func (g *BudgetGuard) release(r *budgetReservation) {
+	g.adjust(r, -r.cost, false)
+}
+
+// settle replaces the reserved estimate with the credits actually charged
+// and fires any threshold callbacks.
+func (g *BudgetGuard) settle(r *budgetReservation, headers http.Header) {
+	actual := r.cost
+	if n, err := strconv.Atoi(headers.Get(CreditsUsedHeader)); err == nil && n >= 0 {
+		actual = n
+	}
+	g.adjust(r, actual-r.cost, true)
+
+	if n, err := strconv.Atoi(headers.Get(CreditsRemainingHeader)); err == nil {
+		g.mu.Lock()
+		g.remaining = n
+		g.hasRemaining = true
+		g.mu.Unlock()
+	}
+}
+
+func (g *BudgetGuard) adjust(r *budgetReservation, delta int, notify bool) {
+	g.mu.Lock()
+	scopes := []string{tenantScope("")}
+	if r.tenant != "" {
+		scopes = append(scopes, tenantScope(r.tenant))
+	}
+	var alerts []BudgetAlert
+	for _, scope := range scopes {
+		c := g.counter(scope)
+		c.daily += delta
+		c.monthly += delta
+		if notify {
+			alerts = append(alerts, g.crossedThresholds(scope, c)...)
+		}
+	}
+	if g.hasRemaining {
+		g.remaining -= delta
+	}
+	callbacks := g.callbacks
+	g.mu.Unlock()
+
+	for _, alert := range alerts {
+		for _, fn := range callbacks {
+			fn(alert)
+		}
+	}
+}
+
+func (g *BudgetGuard) crossedThresholds(scope string, c *budgetCounter) []BudgetAlert {
+	limits := g.limitsFor(scope)
+	var alerts []BudgetAlert
+	check := func(period BudgetPeriod, fired map[float64]bool, used, limit int) {
+		if limit <= 0 {
+			return
+		}
+		for _, th := range g.thresholds {
+			if fired[th] || float64(used) < th*float64(limit) {
+				continue
+			}
+			fired[th] = true
+			alerts = append(alerts, BudgetAlert{
+				Tenant:    scopeTenant(scope),
+				Period:    period,
+				Threshold: th,
+				Used:      used,
+				Limit:     limit,
+			})
+		}
+	}
+	check(BudgetDaily, c.firedDaily, c.daily, limits.Daily)
+	check(BudgetMonthly, c.firedMonthly, c.monthly, limits.Monthly)
+	return alerts
+}
+
+// counter returns the usage counter for scope, resetting it when a new
+// day or month has started. Must be called with g.mu held.
+func (g *BudgetGuard) counter(scope string) *budgetCounter {
+	now := g.now().UTC()
+	day, month := now.Format("2006-01-02"), now.Format("2006-01")
+
+	c, ok := g.counters[scope]
+	if !ok {
+		c = &budgetCounter{day: day, month: month, firedDaily: map[float64]bool{}, firedMonthly: map[float64]bool{}}
+		g.counters[scope] = c
+	}
+	if c.day != day {
+		c.day = day
+		c.daily = 0
+		c.firedDaily = map[float64]bool{}
+	}
+	if c.month != month {
+		c.month = month
+		c.monthly = 0
+		c.firedMonthly = map[float64]bool{}
+	}
+	return c
+}
+
+func (g *BudgetGuard) limitsFor(scope string) BudgetLimits {
+	if scope == tenantScope("") {
+		return g.limits
+	}
+	return g.tenantLimits[scopeTenant(scope)]
+}
+
+func tenantScope(tenant string) string {
+	return "tenant:" + tenant
+}
+
+func scopeTenant(scope string) string {
+	return scope[len("tenant:"):]
+}
+
+func budgetExceeded(scope string, period BudgetPeriod, used, limit int) *Error {
+	who := "client"
+	if t := scopeTenant(scope); t != "" {
+		who = "tenant " + t
+	}
+	return &Error{
+		Message: fmt.Sprintf("Budget exceeded: %s %s limit of %d credits reached (%d used)", who, period, limit, used),
+		Code:    CodeNoCredits,
+	}
+}
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a copy of ctx that attributes API usage to tenant
+// for budget enforcement.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx, if any.
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// withBudget runs call under the client's BudgetGuard, if any, reserving
+// cost credits beforehand and settling them from the response headers.
+func (c *Client) withBudget(ctx context.Context, cost int, call func() (http.Header, error)) error {
+	if c.budget == nil {
+		_, err := call()
+		return err
+	}
+
+	reservation, err := c.budget.reserve(TenantFromContext(ctx), cost)
+	if err != nil {
+		return err
+	}
+	headers, err := call()
+	if err != nil {
+		c.budget.release(reservation)
+		return err
+	}
+	c.budget.settle(reservation, headers)
+	return nil
+}
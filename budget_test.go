@@ -0,0 +1,177 @@
+package renderscreenshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newBudgetTestServer(t *testing.T, creditsUsed string) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if creditsUsed != "" {
+			w.Header().Set(CreditsUsedHeader, creditsUsed)
+		}
+		if r.URL.Path == "/v1/batch" {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "batch_1"})
+			return
+		}
+		_, _ = w.Write([]byte{0x89})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestBudgetGuardClientDailyLimit(t *testing.T) {
+	server, requests := newBudgetTestServer(t, "")
+	guard := NewBudgetGuard(BudgetLimits{Daily: 2})
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithBudgetGuard(guard))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Take(context.Background(), URL("https://example.com")); err != nil {
+			t.Fatalf("take %d: unexpected error: %v", i, err)
+		}
+	}
+
+	_, err := client.Take(context.Background(), URL("https://example.com"))
+	if !errors.Is(err, ErrNoCredits) {
+		t.Fatalf("expected ErrNoCredits, got %v", err)
+	}
+	if *requests != 2 {
+		t.Errorf("expected 2 requests to reach the server, got %d", *requests)
+	}
+	if got := guard.Usage("").Daily; got != 2 {
+		t.Errorf("daily usage = %d, want 2", got)
+	}
+}
+
+func TestBudgetGuardTenantLimits(t *testing.T) {
+	server, _ := newBudgetTestServer(t, "")
+	guard := NewBudgetGuard(BudgetLimits{})
+	guard.SetTenantLimits("acme", BudgetLimits{Monthly: 3})
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithBudgetGuard(guard))
+
+	acme := ContextWithTenant(context.Background(), "acme")
+	if _, err := client.Batch(acme, []string{"https://a.com", "https://b.com"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := client.Batch(acme, []string{"https://c.com", "https://d.com"}, nil)
+	if !errors.Is(err, ErrNoCredits) {
+		t.Fatalf("expected ErrNoCredits for acme, got %v", err)
+	}
+
+	other := ContextWithTenant(context.Background(), "globex")
+	if _, err := client.Batch(other, []string{"https://c.com", "https://d.com"}, nil); err != nil {
+		t.Fatalf("other tenant should be unaffected: %v", err)
+	}
+	if got := guard.Usage("acme").Monthly; got != 2 {
+		t.Errorf("acme monthly usage = %d, want 2", got)
+	}
+	if got := guard.Usage("").Monthly; got != 4 {
+		t.Errorf("client monthly usage = %d, want 4", got)
+	}
+}
+
+func TestBudgetGuardUsesCreditsHeader(t *testing.T) {
+	server, _ := newBudgetTestServer(t, "5")
+	guard := NewBudgetGuard(BudgetLimits{Daily: 100})
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithBudgetGuard(guard))
+
+	if _, err := client.Take(context.Background(), URL("https://example.com").Format(FormatPDF)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := guard.Usage("").Daily; got != 5 {
+		t.Errorf("daily usage = %d, want 5 from %s", got, CreditsUsedHeader)
+	}
+}
+
+func TestBudgetGuardReleasesOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+	}))
+	defer server.Close()
+
+	guard := NewBudgetGuard(BudgetLimits{Daily: 1})
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithBudgetGuard(guard))
+	_, err := client.Take(context.Background(), URL("https://example.com"))
+	if !IsValidation(err) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if got := guard.Usage("").Daily; got != 0 {
+		t.Errorf("daily usage = %d, want 0 after failed request", got)
+	}
+}
+
+func TestBudgetGuardThresholdCallbacks(t *testing.T) {
+	server, _ := newBudgetTestServer(t, "")
+	guard := NewBudgetGuard(BudgetLimits{Daily: 10})
+	var alerts []BudgetAlert
+	guard.OnThreshold(func(a BudgetAlert) { alerts = append(alerts, a) })
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithBudgetGuard(guard))
+
+	for i := 0; i < 10; i++ {
+		if _, err := client.Take(context.Background(), URL("https://example.com")); err != nil {
+			t.Fatalf("take %d: unexpected error: %v", i, err)
+		}
+	}
+
+	if len(alerts) != 3 {
+		t.Fatalf("expected 3 alerts, got %d: %+v", len(alerts), alerts)
+	}
+	wantThresholds := []float64{0.5, 0.8, 1.0}
+	wantUsed := []int{5, 8, 10}
+	for i, a := range alerts {
+		if a.Threshold != wantThresholds[i] || a.Used != wantUsed[i] || a.Limit != 10 || a.Period != BudgetDaily {
+			t.Errorf("alert %d = %+v", i, a)
+		}
+	}
+}
+
+func TestBudgetGuardResetsDaily(t *testing.T) {
+	guard := NewBudgetGuard(BudgetLimits{Daily: 1, Monthly: 5})
+	now := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+
+	r, err := guard.reserve("", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	guard.settle(r, nil)
+	if _, err := guard.reserve("", 1); !errors.Is(err, ErrNoCredits) {
+		t.Fatalf("expected daily limit, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := guard.reserve("", 1); err != nil {
+		t.Fatalf("expected new day to reset daily usage: %v", err)
+	}
+	if got := guard.Usage(""); got.Daily != 1 || got.Monthly != 2 {
+		t.Errorf("usage = %+v, want daily 1 monthly 2", got)
+	}
+}
+
+func TestBudgetGuardSync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"credits": 100.0, "used": 99.0, "remaining": 1.0})
+	}))
+	defer server.Close()
+
+	guard := NewBudgetGuard(BudgetLimits{})
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithBudgetGuard(guard))
+	if err := guard.Sync(context.Background(), client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := client.Batch(context.Background(), []string{"https://a.com", "https://b.com"}, nil)
+	if !errors.Is(err, ErrNoCredits) {
+		t.Fatalf("expected ErrNoCredits after sync, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package renderscreenshot
 
 import (
 	"context"
+	"strconv"
 	"time"
 )
 
@@ -16,8 +17,8 @@ func NewCacheManager(http *httpClient) *CacheManager {
 }
 
 // Get retrieves a cached screenshot by key. Returns nil if not found.
-func (cm *CacheManager) Get(_ context.Context, key string) ([]byte, error) {
-	resp, err := cm.http.getBinary("/v1/cache/"+key, nil, nil)
+func (cm *CacheManager) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := cm.http.getBinary(ctx, "/v1/cache/"+pathSegment(key), nil, nil)
 	if err != nil {
 		if IsNotFound(err) {
 			return nil, nil
@@ -27,9 +28,28 @@ func (cm *CacheManager) Get(_ context.Context, key string) ([]byte, error) {
 	return resp.Body, nil
 }
 
+// List returns a page of cache entries matching the given filters.
+func (cm *CacheManager) List(ctx context.Context, opts CacheListOptions) (*CacheList, error) {
+	params := map[string]string{}
+	if opts.URL != "" {
+		params["url"] = opts.URL
+	}
+	if opts.Limit > 0 {
+		params["limit"] = strconv.Itoa(opts.Limit)
+	}
+	if opts.Cursor != "" {
+		params["cursor"] = opts.Cursor
+	}
+	result, err := cm.http.get(ctx, "/v1/cache", tagParams(params, opts.Tags), nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseCacheList(result), nil
+}
+
 // Delete removes a single cached entry. Returns true if deleted, false if not found.
-func (cm *CacheManager) Delete(_ context.Context, key string) (bool, error) {
-	_, err := cm.http.delete("/v1/cache/"+key, nil, nil)
+func (cm *CacheManager) Delete(ctx context.Context, key string) (bool, error) {
+	_, err := cm.http.delete(ctx, "/v1/cache/"+pathSegment(key), nil, nil)
 	if err != nil {
 		if IsNotFound(err) {
 			return false, nil
@@ -40,8 +60,8 @@ func (cm *CacheManager) Delete(_ context.Context, key string) (bool, error) {
 }
 
 // Purge removes multiple cache entries by keys.
-func (cm *CacheManager) Purge(_ context.Context, keys []string) (*PurgeResult, error) {
-	result, err := cm.http.post("/v1/cache/purge", map[string]interface{}{"keys": keys}, nil)
+func (cm *CacheManager) Purge(ctx context.Context, keys []string) (*PurgeResult, error) {
+	result, err := cm.http.post(ctx, "/v1/cache/purge", map[string]interface{}{"keys": keys}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -49,8 +69,8 @@ func (cm *CacheManager) Purge(_ context.Context, keys []string) (*PurgeResult, e
 }
 
 // PurgeURL removes cache entries matching a URL pattern (glob syntax).
-func (cm *CacheManager) PurgeURL(_ context.Context, pattern string) (*PurgeResult, error) {
-	result, err := cm.http.post("/v1/cache/purge", map[string]interface{}{"url": pattern}, nil)
+func (cm *CacheManager) PurgeURL(ctx context.Context, pattern string) (*PurgeResult, error) {
+	result, err := cm.http.post(ctx, "/v1/cache/purge", map[string]interface{}{"url": pattern}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -58,9 +78,9 @@ func (cm *CacheManager) PurgeURL(_ context.Context, pattern string) (*PurgeResul
 }
 
 // PurgeBefore removes cache entries older than the given time.
-func (cm *CacheManager) PurgeBefore(_ context.Context, before time.Time) (*PurgeResult, error) {
+func (cm *CacheManager) PurgeBefore(ctx context.Context, before time.Time) (*PurgeResult, error) {
 	dateStr := before.UTC().Format(time.RFC3339)
-	result, err := cm.http.post("/v1/cache/purge", map[string]interface{}{"before": dateStr}, nil)
+	result, err := cm.http.post(ctx, "/v1/cache/purge", map[string]interface{}{"before": dateStr}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -68,14 +88,50 @@ func (cm *CacheManager) PurgeBefore(_ context.Context, before time.Time) (*Purge
 }
 
 // PurgePattern removes cache entries matching a storage path pattern.
-func (cm *CacheManager) PurgePattern(_ context.Context, pattern string) (*PurgeResult, error) {
-	result, err := cm.http.post("/v1/cache/purge", map[string]interface{}{"pattern": pattern}, nil)
+func (cm *CacheManager) PurgePattern(ctx context.Context, pattern string) (*PurgeResult, error) {
+	result, err := cm.http.post(ctx, "/v1/cache/purge", map[string]interface{}{"pattern": pattern}, nil)
 	if err != nil {
 		return nil, err
 	}
 	return parsePurgeResult(result), nil
 }
 
+func parseCacheList(m map[string]interface{}) *CacheList {
+	l := &CacheList{}
+	if v, ok := m["next_cursor"].(string); ok {
+		l.NextCursor = v
+	}
+	if entries, ok := m["entries"].([]interface{}); ok {
+		for _, item := range entries {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			e := CacheEntry{Tags: parseTags(entry["tags"])}
+			if v, ok := entry["key"].(string); ok {
+				e.Key = v
+			}
+			if v, ok := entry["url"].(string); ok {
+				e.URL = v
+			}
+			if v, ok := entry["format"].(string); ok {
+				e.Format = v
+			}
+			if v, ok := entry["size"].(float64); ok {
+				e.Size = int(v)
+			}
+			if v, ok := entry["created_at"].(string); ok {
+				e.CreatedAt = v
+			}
+			if v, ok := entry["expires_at"].(string); ok {
+				e.ExpiresAt = v
+			}
+			l.Entries = append(l.Entries, e)
+		}
+	}
+	return l
+}
+
 func parsePurgeResult(m map[string]interface{}) *PurgeResult {
 	r := &PurgeResult{}
 	if v, ok := m["purged"].(float64); ok {
@@ -208,3 +208,74 @@ func TestCachePurgePattern(t *testing.T) {
 		t.Errorf("Purged = %d, want 3", result.Purged)
 	}
 }
+
+func TestCacheKeyPathEscaped(t *testing.T) {
+	key := "tenant/a b&c=d"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/v1/cache/tenant%2Fa%20b&c=d" {
+			t.Errorf("escaped path = %q", r.URL.EscapedPath())
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true})
+	}))
+	defer server.Close()
+
+	cm := NewCacheManager(newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0))
+	deleted, err := cm.Delete(context.Background(), key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !deleted {
+		t.Error("expected deleted = true")
+	}
+}
+
+func TestCacheList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/cache" {
+			t.Errorf("path = %q, want /v1/cache", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("tags[tenant]") != "acme" || q.Get("limit") != "10" || q.Get("cursor") != "c1" {
+			t.Errorf("query = %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"entries": []interface{}{
+				map[string]interface{}{
+					"key":        "key1",
+					"url":        "https://example.com",
+					"format":     "png",
+					"size":       2048.0,
+					"created_at": "2026-03-01T00:00:00Z",
+					"tags":       map[string]interface{}{"tenant": "acme"},
+				},
+			},
+			"next_cursor": "c2",
+		})
+	}))
+	defer server.Close()
+
+	cm := NewCacheManager(newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0))
+	list, err := cm.List(context.Background(), CacheListOptions{
+		Tags:   map[string]string{"tenant": "acme"},
+		Limit:  10,
+		Cursor: "c1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.NextCursor != "c2" {
+		t.Errorf("NextCursor = %q, want c2", list.NextCursor)
+	}
+	if len(list.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(list.Entries))
+	}
+	e := list.Entries[0]
+	if e.Key != "key1" || e.Size != 2048 || e.Tags["tenant"] != "acme" {
+		t.Errorf("entry = %+v", e)
+	}
+}
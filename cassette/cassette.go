@@ -0,0 +1,294 @@
+// Package cassette records API traffic to a file and replays it offline, for
+// deterministic integration tests.
+//
+// Record once against the real API, commit the cassette, and replay it in CI:
+//
+//	rec, err := cassette.New("testdata/thumbnails.json", cassette.ModeRecordOnce)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	client, _ := rs.New(os.Getenv("RS_API_KEY"), rs.WithTransport(rec))
+//
+// Credentials are scrubbed before anything is written: Authorization,
+// cookie and API key headers, and passwords, tokens and cookie values in
+// request bodies (see Scrub).
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode selects whether a Recorder talks to the network.
+type Mode int
+
+const (
+	// ModeReplay serves requests from the cassette only.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request to the network and overwrites the cassette on Stop.
+	ModeRecord
+	// ModeRecordOnce replays if the cassette file exists and records otherwise.
+	ModeRecordOnce
+)
+
+// ErrUnmatched is wrapped by the error returned for requests that have no
+// recorded interaction in replay mode.
+var ErrUnmatched = errors.New("cassette: no recorded interaction matches request")
+
+// Cassette is the on-disk format.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request/response pair.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response. Non-UTF-8 bodies such as images are
+// stored base64-encoded in BodyBase64.
+type Response struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used to reach the network while
+// recording. Defaults to http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithScrubber adds a function applied to every interaction before it is
+// stored, after the built-in scrubbing. Use it to redact application data.
+func WithScrubber(fn func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, fn)
+	}
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	path      string
+	recording bool
+	transport http.RoundTripper
+	scrubbers []func(*Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a Recorder for the cassette at path. In ModeReplay the file
+// must exist; in ModeRecordOnce its existence selects replay.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{path: path, transport: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	switch mode {
+	case ModeRecord:
+		r.recording = true
+	case ModeRecordOnce:
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			r.recording = true
+		}
+	}
+	if r.recording {
+		r.cassette.Version = 1
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("cassette: parse %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Recording reports whether the Recorder is recording rather than replaying.
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := newRequest(req, body)
+
+	if r.recording {
+		return r.record(req, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: recorded,
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: resp.Header.Clone(),
+		},
+	}
+	if utf8.Valid(respBody) {
+		interaction.Response.Body = string(respBody)
+	} else {
+		interaction.Response.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+	Scrub(&interaction)
+	for _, fn := range r.scrubbers {
+		fn(&interaction)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay returns the first unused interaction matching the request.
+// Identical requests are answered in recorded order.
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	probe := Interaction{Request: recorded}
+	Scrub(&probe)
+	for _, fn := range r.scrubbers {
+		fn(&probe)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || !matches(in.Request, probe.Request) {
+			continue
+		}
+		r.used[i] = true
+		return in.Response.toHTTP(req)
+	}
+	return nil, fmt.Errorf("%w: %s %s (query %q, body %s) in %s",
+		ErrUnmatched, recorded.Method, recorded.Path, recorded.Query, truncate(probe.Request.Body, 200), r.path)
+}
+
+// Stop writes the cassette if recording. It is safe to call more than once.
+func (r *Recorder) Stop() error {
+	if !r.recording {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+func newRequest(req *http.Request, body []byte) Request {
+	return Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Header: req.Header.Clone(),
+		Body:   string(body),
+	}
+}
+
+// matches compares method, path, query parameters and canonical JSON body.
+// Headers are ignored, so per-call values such as idempotency keys do not
+// affect matching.
+func matches(recorded, req Request) bool {
+	return recorded.Method == req.Method &&
+		recorded.Path == req.Path &&
+		canonicalQuery(recorded.Query) == canonicalQuery(req.Query) &&
+		canonicalBody(recorded.Body) == canonicalBody(req.Body)
+}
+
+func (resp Response) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(resp.Body)
+	if resp.BodyBase64 != "" {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(resp.BodyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: decode response body: %w", err)
+		}
+	}
+	header := resp.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,201 @@
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/rstest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "take.json")
+	ctx := context.Background()
+	options := rs.URL("https://example.com").Width(320).Height(200)
+
+	srv := rstest.NewServer()
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !rec.Recording() {
+		t.Fatal("Recording() = false, want true")
+	}
+	recorded, err := srv.Client(rs.WithTransport(rec)).Take(ctx, options)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if _, err := srv.Client(rs.WithTransport(rec)).Usage(ctx); err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	srv.Close()
+
+	rec, err = New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	client, err := rs.New("rs_live_other", rs.WithBaseURL(srv.URL), rs.WithTransport(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := client.Take(ctx, options)
+	if err != nil {
+		t.Fatalf("replayed Take() error = %v", err)
+	}
+	if !bytes.Equal(replayed, recorded) {
+		t.Error("replayed image differs from recorded image")
+	}
+	if _, err := client.Usage(ctx); err != nil {
+		t.Errorf("replayed Usage() error = %v", err)
+	}
+}
+
+func TestReplayUnmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "take.json")
+	srv := rstest.NewServer()
+	defer srv.Close()
+
+	rec, _ := New(path, ModeRecord)
+	if _, err := srv.Client(rs.WithTransport(rec)).Take(context.Background(), rs.URL("https://example.com")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, _ = New(path, ModeReplay)
+	client := srv.Client(rs.WithTransport(rec), rs.WithMaxRetries(0))
+	ctx := context.Background()
+
+	_, err := client.Take(ctx, rs.URL("https://example.org"))
+	if !errors.Is(err, ErrUnmatched) {
+		t.Fatalf("Take(other URL) error = %v, want ErrUnmatched", err)
+	}
+	if !strings.Contains(err.Error(), "example.org") {
+		t.Errorf("error %q does not describe the request", err)
+	}
+
+	if _, err := client.Take(ctx, rs.URL("https://example.com")); err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	// Each interaction is used once.
+	if _, err := client.Take(ctx, rs.URL("https://example.com")); !errors.Is(err, ErrUnmatched) {
+		t.Errorf("second Take() error = %v, want ErrUnmatched", err)
+	}
+}
+
+func TestRecordScrubsCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	srv := rstest.NewServer()
+	defer srv.Close()
+
+	rec, _ := New(path, ModeRecord)
+	client := srv.Client(rs.WithTransport(rec))
+	options := rs.URL("https://example.com/admin").
+		AuthBasic("admin", "hunter2").
+		Cookies([]rs.Cookie{{Name: "session", Value: "cookie-secret"}}).
+		Headers(map[string]string{"X-Api-Key": "header-secret", "Accept-Language": "en"})
+	if _, err := client.Take(context.Background(), options); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{rstest.DefaultAPIKey, "hunter2", "cookie-secret", "header-secret"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	for _, kept := range []string{"admin", "session", "Accept-Language"} {
+		if !bytes.Contains(data, []byte(kept)) {
+			t.Errorf("cassette is missing %q", kept)
+		}
+	}
+
+	// Replay matches despite different credentials.
+	rec, _ = New(path, ModeReplay)
+	options.AuthBasic("admin", "other")
+	if _, err := srv.Client(rs.WithTransport(rec)).Take(context.Background(), options); err != nil {
+		t.Errorf("replayed Take() error = %v", err)
+	}
+}
+
+func TestScrubBearerToken(t *testing.T) {
+	in := Interaction{
+		Request: Request{
+			Header: http.Header{"Authorization": {"Bearer rs_live_abc"}},
+			Query:  "url=https%3A%2F%2Fexample.com&signature=abc123",
+			Body:   `{"requests":[{"url":"https://example.com","network":{"auth":{"type":"bearer","token":"tok"}}}]}`,
+		},
+		Response: Response{Header: http.Header{"Set-Cookie": {"a=b"}}},
+	}
+	Scrub(&in)
+
+	if got := in.Request.Header.Get("Authorization"); got != Redacted {
+		t.Errorf("Authorization = %q, want %q", got, Redacted)
+	}
+	if got := in.Response.Header.Get("Set-Cookie"); got != Redacted {
+		t.Errorf("Set-Cookie = %q, want %q", got, Redacted)
+	}
+	if strings.Contains(in.Request.Query, "abc123") {
+		t.Errorf("Query = %q, signature not scrubbed", in.Request.Query)
+	}
+	if strings.Contains(in.Request.Body, `"tok"`) || !strings.Contains(in.Request.Body, "bearer") {
+		t.Errorf("Body = %s", in.Request.Body)
+	}
+}
+
+func TestMatchCanonicalBody(t *testing.T) {
+	a := Request{Method: "POST", Path: "/v1/screenshot", Body: `{"url":"x","viewport":{"width":1,"height":2}}`}
+	b := Request{Method: "POST", Path: "/v1/screenshot", Body: `{"viewport":{"height":2,"width":1},"url":"x"}`}
+	if !matches(a, b) {
+		t.Error("matches() = false for reordered JSON")
+	}
+	b.Path = "/v1/batch"
+	if matches(a, b) {
+		t.Error("matches() = true for different paths")
+	}
+}
+
+func TestRecordOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "once.json")
+
+	rec, err := New(path, ModeRecordOnce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Recording() {
+		t.Error("Recording() = false without a cassette, want true")
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err = New(path, ModeRecordOnce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Recording() {
+		t.Error("Recording() = true with a cassette, want false")
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay); err == nil {
+		t.Error("New() error = nil, want error for missing cassette")
+	}
+}
@@ -0,0 +1,162 @@
+package cassette
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces scrubbed values.
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are redacted in recorded requests and responses, and in
+// the network.headers option of capture requests.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// sensitiveFields are JSON body fields whose values are redacted wherever
+// they appear, which covers AuthBasic passwords and AuthBearer tokens.
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"secret":        true,
+	"api_key":       true,
+	"authorization": true,
+}
+
+// sensitiveParams are query parameters whose values are redacted.
+var sensitiveParams = []string{"api_key", "signature", "key_id"}
+
+// Scrub redacts credentials from an interaction in place: sensitive
+// headers, sensitive query parameters, and in JSON request bodies any
+// password, token, secret or api_key field, every cookie value and
+// sensitive entries of header maps.
+func Scrub(in *Interaction) {
+	scrubHeader(in.Request.Header)
+	scrubHeader(in.Response.Header)
+	in.Request.Query = scrubQuery(in.Request.Query)
+	in.Request.Body = scrubBody(in.Request.Body)
+}
+
+func scrubHeader(h http.Header) {
+	for _, name := range sensitiveHeaders {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			h.Set(name, Redacted)
+		}
+	}
+}
+
+func scrubQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	changed := false
+	for _, name := range sensitiveParams {
+		if _, ok := values[name]; ok {
+			values.Set(name, Redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+	return values.Encode()
+}
+
+func scrubBody(body string) string {
+	var v interface{}
+	if body == "" || json.Unmarshal([]byte(body), &v) != nil {
+		return body
+	}
+	data, err := json.Marshal(scrubValue(v))
+	if err != nil {
+		return body
+	}
+	return string(data)
+}
+
+func scrubValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			lower := strings.ToLower(k)
+			switch {
+			case sensitiveFields[lower]:
+				val[k] = Redacted
+			case lower == "cookies":
+				val[k] = scrubCookies(child)
+			case lower == "headers":
+				val[k] = scrubHeaderMap(child)
+			default:
+				val[k] = scrubValue(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = scrubValue(child)
+		}
+	}
+	return v
+}
+
+func scrubCookies(v interface{}) interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		return Redacted
+	}
+	for _, item := range list {
+		if cookie, ok := item.(map[string]interface{}); ok {
+			if _, ok := cookie["value"]; ok {
+				cookie["value"] = Redacted
+			}
+		}
+	}
+	return list
+}
+
+func scrubHeaderMap(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for k := range m {
+		for _, name := range sensitiveHeaders {
+			if strings.EqualFold(k, name) {
+				m[k] = Redacted
+			}
+		}
+	}
+	return m
+}
+
+// canonicalBody re-encodes JSON with sorted keys so that field order does
+// not affect matching. Other bodies are returned unchanged.
+func canonicalBody(body string) string {
+	var v interface{}
+	if body == "" || json.Unmarshal([]byte(body), &v) != nil {
+		return body
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return string(data)
+}
+
+func canonicalQuery(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	return values.Encode()
+}
@@ -434,3 +434,74 @@ func TestClientCache(t *testing.T) {
 		t.Error("Cache() should return the same instance")
 	}
 }
+
+func TestClientTagsEchoed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/v1/batch" {
+			requests := body["requests"].([]interface{})
+			first := requests[0].(map[string]interface{})
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": "batch_1",
+				"results": []interface{}{
+					map[string]interface{}{"url": "https://site1.com", "status": "completed", "tags": first["tags"]},
+				},
+			})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "req_1", "tags": body["tags"]})
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL))
+	tags := map[string]string{"tenant": "acme"}
+
+	resp, err := client.TakeJSON(context.Background(), URL("https://example.com").Tags(tags))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Tags["tenant"] != "acme" {
+		t.Errorf("ScreenshotResponse.Tags = %v", resp.Tags)
+	}
+
+	batch, err := client.BatchAdvanced(context.Background(), []BatchRequest{
+		{URL: "https://site1.com", Options: URL("").Tags(tags)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batch.Results) != 1 || batch.Results[0].Tags["tenant"] != "acme" {
+		t.Errorf("BatchResult.Tags = %+v", batch.Results)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"credits":10}`))
+	}))
+	defer server.Close()
+
+	var seen string
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		seen = r.URL.Path
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithTransport(rt), WithProxy("http://proxy.invalid:1"))
+	usage, err := client.Usage(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen != "/v1/usage" || usage.Credits != 10 {
+		t.Errorf("seen = %q, credits = %d", seen, usage.Credits)
+	}
+}
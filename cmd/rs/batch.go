@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/manifest"
+)
+
+// batchOutput is the batch response plus the files written by --download.
+type batchOutput struct {
+	*rs.BatchResponse
+	Downloads []download `json:"downloads,omitempty"`
+}
+
+type download struct {
+	URL   string `json:"url"`
+	File  string `json:"file,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func runBatch(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("batch", "[flags] [url...]")
+	file := fs.String("file", "", "read URLs from a file, one per line (- for stdin)")
+	manifestPath := fs.String("manifest", "", "read URLs and per-row overrides from a CSV or JSON-lines manifest (- for stdin)")
+	manifestFormat := fs.String("manifest-format", "", "manifest format: csv or jsonl (default from the file extension)")
+	resultsPath := fs.String("results", "", "write the --manifest results manifest to a file (default stdout as JSON lines)")
+	id := fs.String("id", "", "resume an existing batch instead of submitting one")
+	wait := fs.Bool("wait", false, "poll until the batch finishes")
+	poll := fs.Duration("poll", 2*time.Second, "polling interval for --wait")
+	dir := fs.String("download", "", "download completed captures into a directory (implies --wait)")
+	tf := newTakeFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	if *manifestPath != "" {
+		if len(positional) > 0 || *file != "" || *id != "" {
+			return usageErrorf("--manifest cannot be combined with URLs, --file or --id")
+		}
+		return c.runManifest(ctx, client, tf, manifestRun{
+			path:        *manifestPath,
+			format:      *manifestFormat,
+			resultsPath: *resultsPath,
+			outputDir:   *dir,
+			poll:        *poll,
+		})
+	}
+
+	var resp *rs.BatchResponse
+	if *id != "" {
+		if len(positional) > 0 || *file != "" {
+			return usageErrorf("--id cannot be combined with URLs")
+		}
+		resp, err = client.GetBatch(ctx, *id)
+	} else {
+		urls := positional
+		if *file != "" {
+			fromFile, err := c.readLines(*file)
+			if err != nil {
+				return err
+			}
+			urls = append(urls, fromFile...)
+		}
+		if len(urls) == 0 {
+			return usageErrorf("no URLs given")
+		}
+		var options *rs.TakeOptions
+		if len(tf.setters) > 0 {
+			options = rs.URL("")
+			tf.apply(options)
+		}
+		resp, err = client.Batch(ctx, urls, options)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *wait || *dir != "" {
+		resp, err = c.waitBatch(ctx, client, resp, *poll)
+		if err != nil {
+			return err
+		}
+	}
+
+	out := batchOutput{BatchResponse: resp}
+	if *dir != "" {
+		out.Downloads = c.downloadResults(ctx, client, resp.Results, *dir)
+	}
+	if err := c.print(out); err != nil {
+		return err
+	}
+	if n := countFailed(out.Downloads); n > 0 {
+		return fmt.Errorf("%d of %d downloads failed", n, len(out.Downloads))
+	}
+	return nil
+}
+
+// batchDone reports whether b has finished. A batch whose items have not
+// been counted yet has a zero Total and is still running.
+func batchDone(b *rs.BatchResponse) bool {
+	return b.Status == "completed" || b.Status == "failed" || b.Total > 0 && b.Completed+b.Failed >= b.Total
+}
+
+// waitBatch polls until the batch finishes, reporting progress on stderr.
+func (c *cli) waitBatch(ctx context.Context, client rs.Batcher, b *rs.BatchResponse, interval time.Duration) (*rs.BatchResponse, error) {
+	for !batchDone(b) {
+		fmt.Fprintf(c.stderr, "batch %s: %d/%d completed, %d failed\n", b.ID, b.Completed, b.Total, b.Failed)
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		next, err := client.GetBatch(ctx, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		b = next
+	}
+	return b, nil
+}
+
+// downloadResults saves each completed result as NNN-<slug>.<ext> in dir.
+func (c *cli) downloadResults(ctx context.Context, client *rs.Client, results []rs.BatchResult, dir string) []download {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return []download{{Error: err.Error()}}
+	}
+	var downloads []download
+	for i, r := range results {
+		if r.Status != "completed" || r.ImageURL == "" {
+			continue
+		}
+		d := download{URL: r.URL}
+		data, contentType, err := fetchImage(ctx, client, r.ImageURL)
+		if err == nil {
+			d.File = filepath.Join(dir, manifest.FileName(i, r.URL, contentType))
+			err = os.WriteFile(d.File, data, 0o644)
+		}
+		if err != nil {
+			d.File = ""
+			d.Error = err.Error()
+		}
+		downloads = append(downloads, d)
+	}
+	return downloads
+}
+
+// fetchImage downloads a result. Cache URLs on the API host are fetched
+// through the authenticated cache API; other URLs (CDN, storage) are
+// fetched anonymously so the API key never leaves the API host.
+func fetchImage(ctx context.Context, client *rs.Client, imageURL string) ([]byte, string, error) {
+	for _, ep := range client.Endpoints() {
+		prefix := strings.TrimRight(ep.BaseURL, "/") + "/v1/cache/"
+		if strings.HasPrefix(imageURL, prefix) {
+			key, err := url.PathUnescape(strings.TrimPrefix(imageURL, prefix))
+			if err != nil {
+				return nil, "", err
+			}
+			data, err := client.Cache().Get(ctx, key)
+			if err == nil && data == nil {
+				err = fmt.Errorf("cache entry %s not found", key)
+			}
+			return data, http.DetectContentType(data), err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("GET %s: %s", imageURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.Header.Get("Content-Type"), err
+}
+
+func countFailed(downloads []download) int {
+	n := 0
+	for _, d := range downloads {
+		if d.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// readLines reads non-empty, non-comment lines from path or stdin ("-").
+func (c *cli) readLines(path string) ([]string, error) {
+	var r io.Reader = c.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
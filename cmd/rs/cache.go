@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+func runCache(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("expected a subcommand: get, list, delete or purge")
+	}
+	switch args[0] {
+	case "get":
+		return runCacheGet(ctx, c, args[1:])
+	case "list":
+		return runCacheList(ctx, c, args[1:])
+	case "delete":
+		return runCacheDelete(ctx, c, args[1:])
+	case "purge":
+		return runCachePurge(ctx, c, args[1:])
+	}
+	return usageErrorf("unknown cache subcommand %q", args[0])
+}
+
+func runCacheGet(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("cache get", "[flags] <key>")
+	out := fs.String("o", "", "write the capture to a file instead of stdout")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("expected one cache key")
+	}
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	data, err := client.Cache().Get(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("cache entry %s not found", positional[0])
+	}
+	if err := c.writeOutput(*out, data); err != nil {
+		return err
+	}
+	if *out == "" || *out == "-" {
+		return nil
+	}
+	return c.print(map[string]interface{}{"file": *out, "bytes": len(data)})
+}
+
+func runCacheList(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("cache list", "[flags]")
+	var opts rs.CacheListOptions
+	fs.StringVar(&opts.URL, "url", "", "only entries whose URL matches a glob pattern")
+	fs.IntVar(&opts.Limit, "limit", 0, "maximum entries per page")
+	fs.StringVar(&opts.Cursor, "cursor", "", "continue from a previous next_cursor")
+	fs.Func("tag", "only entries with tag key=value (repeatable)", tagFlag(&opts.Tags))
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	list, err := client.Cache().List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return c.print(list)
+}
+
+func runCacheDelete(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("cache delete", "<key>")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("expected one cache key")
+	}
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	deleted, err := client.Cache().Delete(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return c.print(map[string]interface{}{"key": positional[0], "deleted": deleted})
+}
+
+func runCachePurge(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("cache purge", "[--url pattern | --before time | --pattern pattern | key...]")
+	urlPattern := fs.String("url", "", "purge entries whose URL matches a glob pattern")
+	before := fs.String("before", "", "purge entries created before an RFC 3339 time, or a duration ago (e.g. 72h)")
+	pattern := fs.String("pattern", "", "purge entries whose storage path matches a pattern")
+	keys, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	modes := 0
+	for _, set := range []bool{len(keys) > 0, *urlPattern != "", *before != "", *pattern != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return usageErrorf("give exactly one of keys, --url, --before or --pattern")
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	cache := client.Cache()
+	var result *rs.PurgeResult
+	switch {
+	case len(keys) > 0:
+		result, err = cache.Purge(ctx, keys)
+	case *urlPattern != "":
+		result, err = cache.PurgeURL(ctx, *urlPattern)
+	case *pattern != "":
+		result, err = cache.PurgePattern(ctx, *pattern)
+	default:
+		t, perr := parseTime(*before, time.Now())
+		if perr != nil {
+			return usageErrorf("invalid --before: %v", perr)
+		}
+		result, err = cache.PurgeBefore(ctx, t)
+	}
+	if err != nil {
+		return err
+	}
+	return c.print(result)
+}
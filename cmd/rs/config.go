@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// config holds credentials and defaults. Environment variables override
+// the config file.
+type config struct {
+	APIKey         string   `json:"api_key"`
+	BaseURL        string   `json:"base_url,omitempty"`
+	SigningKey     string   `json:"signing_key,omitempty"`
+	PublicKeyID    string   `json:"public_key_id,omitempty"`
+	WebhookSecret  string   `json:"webhook_secret,omitempty"`
+	WebhookSecrets []string `json:"webhook_secrets,omitempty"`
+}
+
+// Environment variables read by loadConfig.
+const (
+	envConfig        = "RS_CONFIG"
+	envAPIKey        = "RS_API_KEY"
+	envBaseURL       = "RS_BASE_URL"
+	envSigningKey    = "RS_SIGNING_KEY"
+	envPublicKeyID   = "RS_PUBLIC_KEY_ID"
+	envWebhookSecret = "RS_WEBHOOK_SECRET"
+)
+
+// loadConfig reads the config file at path, $RS_CONFIG or the default
+// location, then applies environment overrides. A missing file is only an
+// error when its path was given explicitly.
+func loadConfig(path string, getenv func(string) string) (config, error) {
+	var cfg config
+
+	explicit := path != ""
+	if !explicit {
+		path = getenv(envConfig)
+		explicit = path != ""
+	}
+	if !explicit {
+		path = defaultConfigPath()
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return cfg, fmt.Errorf("config %s: %w", path, err)
+			}
+		case explicit || !errors.Is(err, os.ErrNotExist):
+			return cfg, fmt.Errorf("config: %w", err)
+		}
+	}
+
+	for env, field := range map[string]*string{
+		envAPIKey:        &cfg.APIKey,
+		envBaseURL:       &cfg.BaseURL,
+		envSigningKey:    &cfg.SigningKey,
+		envPublicKeyID:   &cfg.PublicKeyID,
+		envWebhookSecret: &cfg.WebhookSecret,
+	} {
+		if v := getenv(env); v != "" {
+			*field = v
+		}
+	}
+	return cfg, nil
+}
+
+// webhookSecrets returns the configured webhook secrets, newest first.
+func (cfg config) webhookSecrets() []string {
+	var secrets []string
+	if cfg.WebhookSecret != "" {
+		secrets = append(secrets, cfg.WebhookSecret)
+	}
+	return append(secrets, cfg.WebhookSecrets...)
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "rs", "config.json")
+}
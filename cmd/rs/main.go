@@ -0,0 +1,262 @@
+// Command rs is a command-line client for the RenderScreenshot API.
+//
+// Usage:
+//
+//	rs [global flags] <command> [flags] [args]
+//
+// Commands:
+//
+//	take        capture a screenshot or PDF
+//	batch       submit a batch, wait for it and download the results
+//	cache       get, delete or purge cached captures
+//	presets     list presets
+//	devices     list devices
+//	usage       show credit usage
+//	sign-url    generate a signed screenshot URL
+//	webhook     verify, receive and replay webhooks
+//
+// The API key is read from --api-key, the RS_API_KEY environment variable
+// or the config file (~/.config/rs/config.json by default, see --config).
+// Results are printed as JSON for scripting.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// Exit codes.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// defaultRetries is the --retries default. The SDK itself does not retry
+// unless asked to.
+const defaultRetries = 3
+
+// usageError is an error caused by invalid command-line arguments. An empty
+// usageError means the flag package has already reported the problem.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// cli holds the process environment so that commands can be tested.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	config  config
+	timeout time.Duration
+	retries int
+	pretty  bool
+	// retryDelay overrides the SDK's base retry delay in seconds if set.
+	retryDelay float64
+}
+
+// command is a subcommand. run receives the arguments after the command name.
+type command struct {
+	summary string
+	run     func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"take":     {"capture a screenshot or PDF", runTake},
+		"batch":    {"submit a batch, wait for it and download the results", runBatch},
+		"cache":    {"get, delete or purge cached captures", runCache},
+		"presets":  {"list presets, or show one", runPresets},
+		"devices":  {"list devices", runDevices},
+		"usage":    {"show credit usage or usage history", runUsage},
+		"sign-url": {"generate a signed screenshot URL", runSignURL},
+		"webhook":  {"verify, receive and replay webhooks", runWebhook},
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := (&cli{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		getenv: os.Getenv,
+	}).main(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// main parses global flags, dispatches to a command and returns the exit code.
+func (c *cli) main(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("rs", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	configPath := fs.String("config", "", "config file (default $RS_CONFIG or ~/.config/rs/config.json)")
+	apiKey := fs.String("api-key", "", "API key (default $RS_API_KEY)")
+	baseURL := fs.String("base-url", "", "API base URL (default $RS_BASE_URL)")
+	fs.DurationVar(&c.timeout, "timeout", 0, "request timeout (default 30s)")
+	c.retries = defaultRetries
+	fs.Func("retries", "maximum retries for failed requests (default 3)", func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid retry count %q", s)
+		}
+		c.retries = n
+		return nil
+	})
+	fs.BoolVar(&c.pretty, "pretty", false, "indent JSON output")
+	fs.Usage = func() { c.usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		c.usage(fs)
+		return exitUsage
+	}
+
+	cfg, err := loadConfig(*configPath, c.getenv)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "rs: %v\n", err)
+		return exitError
+	}
+	if *apiKey != "" {
+		cfg.APIKey = *apiKey
+	}
+	if *baseURL != "" {
+		cfg.BaseURL = *baseURL
+	}
+	c.config = cfg
+
+	name := fs.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(c.stderr, "rs: unknown command %q\n", name)
+		c.usage(fs)
+		return exitUsage
+	}
+	if err := cmd.run(ctx, c, fs.Args()[1:]); err != nil {
+		return c.fail(name, err)
+	}
+	return exitOK
+}
+
+func (c *cli) usage(fs *flag.FlagSet) {
+	fmt.Fprintln(c.stderr, "Usage: rs [global flags] <command> [flags] [args]")
+	fmt.Fprintln(c.stderr, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(c.stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(c.stderr, "\nGlobal flags:")
+	fs.PrintDefaults()
+	fmt.Fprintln(c.stderr, "\nRun 'rs <command> -h' for command flags.")
+}
+
+// fail reports err and returns the matching exit code. API errors are
+// printed as JSON so scripts can inspect the error code.
+func (c *cli) fail(name string, err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		if usageErr != "" {
+			fmt.Fprintf(c.stderr, "rs %s: %v\n", name, usageErr)
+		}
+		return exitUsage
+	}
+	var apiErr *rs.Error
+	if errors.As(err, &apiErr) {
+		_ = writeJSON(c.stderr, errorJSON(apiErr), c.pretty)
+		return exitError
+	}
+	fmt.Fprintf(c.stderr, "rs %s: %v\n", name, err)
+	return exitError
+}
+
+// client builds an API client from the loaded configuration.
+func (c *cli) client() (*rs.Client, error) {
+	if c.config.APIKey == "" {
+		return nil, errors.New("no API key: set RS_API_KEY, pass --api-key or add api_key to the config file")
+	}
+	var opts []rs.Option
+	if c.config.BaseURL != "" {
+		opts = append(opts, rs.WithBaseURL(c.config.BaseURL))
+	}
+	if c.config.SigningKey != "" {
+		opts = append(opts, rs.WithSigningKey(c.config.SigningKey))
+	}
+	if c.config.PublicKeyID != "" {
+		opts = append(opts, rs.WithPublicKeyID(c.config.PublicKeyID))
+	}
+	if c.timeout > 0 {
+		opts = append(opts, rs.WithTimeout(c.timeout))
+	}
+	if c.retryDelay > 0 {
+		opts = append(opts, rs.WithRetryDelay(c.retryDelay))
+	}
+	opts = append(opts, rs.WithMaxRetries(c.retries))
+	return rs.New(c.config.APIKey, opts...)
+}
+
+// print writes v to stdout as JSON.
+func (c *cli) print(v interface{}) error {
+	return writeJSON(c.stdout, v, c.pretty)
+}
+
+// newFlagSet returns a flag set for a subcommand that reports errors
+// instead of exiting.
+func (c *cli) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("rs "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: rs %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, allowing flags after positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError("")
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,595 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/manifest"
+	"github.com/Render-Screenshot/rs-go/rstest"
+)
+
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// run executes the CLI against srv with credentials from a config file.
+func run(t *testing.T, srv *rstest.Server, stdin string, args ...string) result {
+	t.Helper()
+	cfg := config{
+		APIKey:      rstest.DefaultAPIKey,
+		SigningKey:  rstest.DefaultSigningKey,
+		PublicKeyID: rstest.DefaultPublicKeyID,
+	}
+	if srv != nil {
+		cfg.BaseURL = srv.URL
+	}
+	env := map[string]string{envConfig: writeConfig(t, cfg)}
+	return runEnv(t, env, stdin, args...)
+}
+
+func runEnv(t *testing.T, env map[string]string, stdin string, args ...string) result {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	c := &cli{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(k string) string { return env[k] },
+	}
+	code := c.main(context.Background(), append([]string{"--retries", "0"}, args...))
+	return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func writeConfig(t *testing.T, cfg config) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	data, _ := json.Marshal(cfg)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func decode(t *testing.T, s string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", s, err)
+	}
+}
+
+// lastBody returns the JSON body of the last request to path.
+func lastBody(t *testing.T, srv *rstest.Server, path string) map[string]interface{} {
+	t.Helper()
+	reqs := srv.Requests()
+	for i := len(reqs) - 1; i >= 0; i-- {
+		if reqs[i].Path == path {
+			var body map[string]interface{}
+			decode(t, string(reqs[i].Body), &body)
+			return body
+		}
+	}
+	t.Fatalf("no request to %s", path)
+	return nil
+}
+
+func TestTakeToFile(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+	out := filepath.Join(t.TempDir(), "shot.png")
+
+	r := run(t, srv, "", "take", "https://example.com", "--width", "320", "--height", "200", "-o", out)
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Error("output is not a PNG")
+	}
+	var summary struct {
+		File  string `json:"file"`
+		Bytes int    `json:"bytes"`
+	}
+	decode(t, r.stdout, &summary)
+	if summary.File != out || summary.Bytes != len(data) {
+		t.Errorf("summary = %+v, want file %s with %d bytes", summary, out, len(data))
+	}
+}
+
+func TestTakeStdout(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+
+	r := run(t, srv, "", "take", "--format", "jpeg", "https://example.com")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	if !strings.HasPrefix(r.stdout, "\xff\xd8") {
+		t.Error("stdout is not a JPEG")
+	}
+}
+
+func TestTakeJSON(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+
+	r := run(t, srv, "", "take", "--json", "--preset", "og_card", "--tag", "tenant=acme", "https://example.com")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	var resp rs.ScreenshotResponse
+	decode(t, r.stdout, &resp)
+	if resp.Image.Width != 1200 || resp.Image.URL == "" {
+		t.Errorf("image = %+v, want a 1200px wide image with a URL", resp.Image)
+	}
+	if resp.Tags["tenant"] != "acme" {
+		t.Errorf("Tags = %v, want tenant=acme", resp.Tags)
+	}
+}
+
+func TestTakeFlags(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+	script := filepath.Join(t.TempDir(), "script.js")
+	if err := os.WriteFile(script, []byte("document.body.remove()"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := run(t, srv, "", "take", "--json",
+		"--full-page", "--mobile=false", "--dark-mode",
+		"--wait-for", "networkidle", "--delay", "500",
+		"--block-url", "*.ads.com/*", "--block-url", "*.tracker.com/*",
+		"--hide", ".banner",
+		"--inject-script", "@"+script,
+		"--geolocation", "48.85,2.35,10",
+		"--header", "Accept-Language: fr", "--header", "X-Test: 1",
+		"--cookie", "session=abc;domain=example.com", "--cookie", "theme=dark",
+		"--auth-basic", "admin:hunter2",
+		"--cache-ttl", "60",
+		"https://example.com")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+
+	body := lastBody(t, srv, "/v1/screenshot")
+	want := rs.URL("https://example.com").
+		FullPage().Mobile(false).DarkMode().
+		WaitFor(rs.WaitNetworkIdle).Delay(500).
+		BlockURLs([]string{"*.ads.com/*", "*.tracker.com/*"}).
+		Hide([]string{".banner"}).
+		InjectScript("document.body.remove()").
+		SetGeolocation(48.85, 2.35, 10).
+		Headers(map[string]string{"Accept-Language": "fr", "X-Test": "1"}).
+		Cookies([]rs.Cookie{{Name: "session", Value: "abc", Domain: "example.com"}, {Name: "theme", Value: "dark"}}).
+		AuthBasic("admin", "hunter2").
+		CacheTTL(60).
+		ToParams()
+	// Round-trip through JSON so both sides are maps with sorted keys.
+	var wantBody map[string]interface{}
+	wantJSON, _ := json.Marshal(want)
+	decode(t, string(wantJSON), &wantBody)
+	wantJSON, _ = json.Marshal(wantBody)
+	gotJSON, _ := json.Marshal(body)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("request body =\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+}
+
+func TestTakeUsageErrors(t *testing.T) {
+	tests := [][]string{
+		{"take"},
+		{"take", "https://a.example", "https://b.example"},
+		{"take", "--width", "wide", "https://example.com"},
+		{"take", "--geolocation", "north", "https://example.com"},
+		{"nope"},
+	}
+	for _, args := range tests {
+		if r := run(t, nil, "", args...); r.code != exitUsage {
+			t.Errorf("rs %v exit code = %d, want %d", args, r.code, exitUsage)
+		}
+	}
+}
+
+func TestAPIErrorJSON(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+	srv.FailURL("https://broken.example.com", "Navigation timeout")
+
+	r := run(t, srv, "", "take", "https://broken.example.com")
+	if r.code != exitError {
+		t.Fatalf("exit code = %d, want %d", r.code, exitError)
+	}
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	decode(t, r.stderr, &envelope)
+	if envelope.Error.Code != string(rs.CodeRenderFailed) {
+		t.Errorf("error code = %q, want %q", envelope.Error.Code, rs.CodeRenderFailed)
+	}
+}
+
+func TestMissingAPIKey(t *testing.T) {
+	env := map[string]string{envConfig: writeConfig(t, config{})}
+	r := runEnv(t, env, "", "usage")
+	if r.code != exitError || !strings.Contains(r.stderr, "RS_API_KEY") {
+		t.Errorf("exit code = %d, stderr = %q, want a hint about RS_API_KEY", r.code, r.stderr)
+	}
+}
+
+func TestConfigEnvOverridesFile(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+	env := map[string]string{
+		envConfig: writeConfig(t, config{APIKey: "wrong", BaseURL: srv.URL}),
+		envAPIKey: rstest.DefaultAPIKey,
+	}
+	if r := runEnv(t, env, "", "usage"); r.code != exitOK {
+		t.Errorf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+}
+
+func TestDefaultRetries(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	c := &cli{
+		stdout:     &stdout,
+		stderr:     &stderr,
+		getenv:     func(string) string { return "" },
+		retryDelay: 0.001,
+	}
+	code := c.main(context.Background(), []string{"--api-key", rstest.DefaultAPIKey, "--base-url", server.URL, "take", "https://example.com"})
+	if code != exitError {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr.String())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != defaultRetries+1 {
+		t.Errorf("requests = %d, want %d", requests, defaultRetries+1)
+	}
+}
+
+func TestMissingExplicitConfig(t *testing.T) {
+	r := runEnv(t, map[string]string{}, "", "--config", filepath.Join(t.TempDir(), "missing.json"), "usage")
+	if r.code != exitError {
+		t.Errorf("exit code = %d, want %d", r.code, exitError)
+	}
+}
+
+func TestBatchDownload(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+	srv.FailURL("https://broken.example.com", "Navigation timeout")
+	dir := filepath.Join(t.TempDir(), "out")
+	list := filepath.Join(t.TempDir(), "urls.txt")
+	if err := os.WriteFile(list, []byte("# pages\nhttps://b.example.com/about\n\nhttps://broken.example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := run(t, srv, "", "batch", "--width", "100", "--height", "100", "--poll", "1ms",
+		"--file", list, "--download", dir, "https://a.example.com")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+
+	var out batchOutput
+	decode(t, r.stdout, &out)
+	if out.Status != "completed" || out.Completed != 2 || out.Failed != 1 {
+		t.Errorf("batch = %s %d/%d failed %d, want completed 2/3 failed 1", out.Status, out.Completed, out.Total, out.Failed)
+	}
+	wantFiles := []string{"001-a-example-com.png", "002-b-example-com-about.png"}
+	if len(out.Downloads) != len(wantFiles) {
+		t.Fatalf("downloads = %+v, want %d", out.Downloads, len(wantFiles))
+	}
+	for i, name := range wantFiles {
+		if got := filepath.Base(out.Downloads[i].File); got != name {
+			t.Errorf("download %d = %q, want %q", i, got, name)
+		}
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	body := lastBody(t, srv, "/v1/batch")
+	if opts, _ := body["options"].(map[string]interface{}); opts == nil {
+		t.Errorf("batch body = %v, want options", body)
+	}
+}
+
+func TestBatchDone(t *testing.T) {
+	for _, tt := range []struct {
+		batch rs.BatchResponse
+		want  bool
+	}{
+		{rs.BatchResponse{Status: "processing"}, false},
+		{rs.BatchResponse{Status: "processing", Total: 2, Completed: 1}, false},
+		{rs.BatchResponse{Status: "processing", Total: 2, Completed: 1, Failed: 1}, true},
+		{rs.BatchResponse{Status: "completed"}, true},
+		{rs.BatchResponse{Status: "failed"}, true},
+	} {
+		if got := batchDone(&tt.batch); got != tt.want {
+			t.Errorf("batchDone(%+v) = %v, want %v", tt.batch, got, tt.want)
+		}
+	}
+}
+
+func TestBatchResume(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+
+	r := run(t, srv, "", "batch", "https://a.example.com", "https://b.example.com")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	var submitted batchOutput
+	decode(t, r.stdout, &submitted)
+
+	r = run(t, srv, "", "batch", "--id", submitted.ID, "--wait", "--poll", "1ms")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	var done batchOutput
+	decode(t, r.stdout, &done)
+	if done.ID != submitted.ID || done.Completed != 2 {
+		t.Errorf("batch = %+v, want %s with 2 completed", done.BatchResponse, submitted.ID)
+	}
+	if !strings.Contains(r.stderr, "completed") {
+		t.Errorf("stderr = %q, want progress", r.stderr)
+	}
+}
+
+func TestBatchManifest(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+	srv.FailURL("https://broken.example.com", "Navigation timeout")
+	dir := t.TempDir()
+	rows := filepath.Join(dir, "rows.jsonl")
+	if err := os.WriteFile(rows, []byte(
+		`{"url":"https://example.com","format":"jpeg","output":"home.jpg"}`+"\n"+
+			`{"url":"https://broken.example.com","output":"broken.png"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results := filepath.Join(dir, "results.csv")
+
+	r := run(t, srv, "", "batch", "--manifest", rows, "--results", results,
+		"--download", filepath.Join(dir, "out"), "--width", "64", "--height", "64", "--poll", "1ms")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	var summary struct {
+		Rows   int            `json:"rows"`
+		Status map[string]int `json:"status"`
+	}
+	decode(t, r.stdout, &summary)
+	if summary.Rows != 2 || summary.Status["completed"] != 1 || summary.Status["failed"] != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+	if !strings.Contains(r.stderr, "1 downloaded") {
+		t.Errorf("stderr = %q, want progress with downloads", r.stderr)
+	}
+
+	data, err := os.ReadFile(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "line,url,status") ||
+		!strings.Contains(lines[1], filepath.Join(dir, "out", "home.jpg")) || !strings.Contains(lines[2], "Navigation timeout") {
+		t.Errorf("results manifest =\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "home.jpg")); err != nil {
+		t.Error(err)
+	}
+
+	// Without --results, results stream to stdout as JSON lines.
+	r = run(t, srv, "url\nhttps://example.com/a\nhttps://example.com/b\n",
+		"batch", "--manifest", "-", "--manifest-format", "csv", "--poll", "1ms")
+	if r.code != exitOK {
+		t.Fatalf("stdin manifest exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	lines = strings.Split(strings.TrimSpace(r.stdout), "\n")
+	var first manifest.Result
+	decode(t, lines[0], &first)
+	if len(lines) != 2 || first.Line != 2 || first.Status != "completed" {
+		t.Errorf("stdout = %s", r.stdout)
+	}
+
+	if r := run(t, srv, "", "batch", "--manifest", "-"); r.code != exitUsage {
+		t.Errorf("stdin manifest without format exit code = %d, want %d", r.code, exitUsage)
+	}
+}
+
+func TestCacheCommands(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+	for _, u := range []string{"https://example.com/a", "https://example.com/b", "https://other.com"} {
+		if r := run(t, srv, "", "take", "-o", filepath.Join(t.TempDir(), "x.png"), u); r.code != exitOK {
+			t.Fatalf("take exit code = %d, stderr = %s", r.code, r.stderr)
+		}
+	}
+
+	r := run(t, srv, "", "cache", "list", "--url", "https://example.com/*")
+	var list rs.CacheList
+	decode(t, r.stdout, &list)
+	if len(list.Entries) != 2 {
+		t.Fatalf("cache list = %d entries, want 2", len(list.Entries))
+	}
+	key := list.Entries[0].Key
+
+	r = run(t, srv, "", "cache", "get", key)
+	if r.code != exitOK || !strings.HasPrefix(r.stdout, "\x89PNG") {
+		t.Errorf("cache get exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+
+	r = run(t, srv, "", "cache", "delete", key)
+	var deleted struct {
+		Deleted bool `json:"deleted"`
+	}
+	decode(t, r.stdout, &deleted)
+	if !deleted.Deleted {
+		t.Error("cache delete reported deleted = false")
+	}
+	if r := run(t, srv, "", "cache", "get", key); r.code != exitError {
+		t.Errorf("cache get of deleted key exit code = %d, want %d", r.code, exitError)
+	}
+
+	r = run(t, srv, "", "cache", "purge", "--url", "https://other.com*")
+	var purged rs.PurgeResult
+	decode(t, r.stdout, &purged)
+	if purged.Purged != 1 {
+		t.Errorf("purged = %d, want 1", purged.Purged)
+	}
+
+	if r := run(t, srv, "", "cache", "purge", "--url", "x", "--pattern", "y"); r.code != exitUsage {
+		t.Errorf("purge with two modes exit code = %d, want %d", r.code, exitUsage)
+	}
+}
+
+func TestMetadataCommands(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+
+	var presets []rs.PresetInfo
+	decode(t, run(t, srv, "", "presets").stdout, &presets)
+	if len(presets) == 0 {
+		t.Error("presets returned no presets")
+	}
+
+	var preset rs.PresetInfo
+	decode(t, run(t, srv, "", "presets", "og_card").stdout, &preset)
+	if preset.Width != 1200 {
+		t.Errorf("og_card width = %d, want 1200", preset.Width)
+	}
+
+	var devices []rs.DeviceInfo
+	decode(t, run(t, srv, "", "devices").stdout, &devices)
+	if len(devices) == 0 {
+		t.Error("devices returned no devices")
+	}
+
+	var usage rs.UsageInfo
+	decode(t, run(t, srv, "", "usage").stdout, &usage)
+	if usage.Credits != rstest.DefaultCredits {
+		t.Errorf("credits = %d, want %d", usage.Credits, rstest.DefaultCredits)
+	}
+
+	r := run(t, srv, "", "--pretty", "usage", "--history", "--from", "168h", "--granularity", "day")
+	if r.code != exitOK {
+		t.Fatalf("usage --history exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	if !strings.Contains(r.stdout, "\n  ") {
+		t.Error("--pretty output is not indented")
+	}
+}
+
+func TestSignURL(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+
+	r := run(t, srv, "", "sign-url", "--width", "64", "--height", "64", "--expires", "1h", "https://example.com")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	var signed struct {
+		URL       string `json:"url"`
+		ExpiresAt string `json:"expires_at"`
+	}
+	decode(t, r.stdout, &signed)
+	if !strings.HasPrefix(signed.URL, srv.URL+"/v1/screenshot?") {
+		t.Fatalf("url = %q", signed.URL)
+	}
+
+	resp, err := http.Get(signed.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET signed URL status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestWebhookVerify(t *testing.T) {
+	payload := `{"event":"screenshot.completed","id":"evt_1","timestamp":1,"data":{}}`
+	ts := time.Now().Unix()
+	sig := rs.SignWebhook(payload, "whsec_new", ts)
+	env := map[string]string{envConfig: writeConfig(t, config{WebhookSecrets: []string{"whsec_old"}})}
+
+	r := runEnv(t, env, payload, "webhook", "verify",
+		"--secret", "whsec_old", "--secret", "whsec_new",
+		"--signature", sig, "--timestamp", fmt.Sprint(ts))
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+	var ok map[string]interface{}
+	decode(t, r.stdout, &ok)
+	if ok["valid"] != true || ok["secret_index"] != float64(1) || ok["event"] != "screenshot.completed" {
+		t.Errorf("result = %v", ok)
+	}
+
+	// Secrets fall back to the config file.
+	r = runEnv(t, env, payload, "webhook", "verify", "--signature", sig, "--timestamp", fmt.Sprint(ts))
+	if r.code != exitError {
+		t.Fatalf("exit code = %d, want %d", r.code, exitError)
+	}
+	var bad map[string]interface{}
+	decode(t, r.stdout, &bad)
+	if bad["valid"] != false {
+		t.Errorf("result = %v, want valid false", bad)
+	}
+
+	old := time.Now().Add(-time.Hour).Unix()
+	oldSig := rs.SignWebhook(payload, "whsec_old", old)
+	r = runEnv(t, env, payload, "webhook", "verify", "--signature", oldSig, "--timestamp", fmt.Sprint(old))
+	if r.code != exitError || !strings.Contains(r.stdout, "tolerance") {
+		t.Errorf("expired: exit code = %d, stdout = %s", r.code, r.stdout)
+	}
+	r = runEnv(t, env, payload, "webhook", "verify", "--tolerance", "0", "--signature", oldSig, "--timestamp", fmt.Sprint(old))
+	if r.code != exitOK {
+		t.Errorf("--tolerance 0: exit code = %d, stdout = %s", r.code, r.stdout)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"2024-03-01T08:00:00Z": time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+		"2024-03-01":           time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"48h":                  time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC),
+	}
+	for in, want := range tests {
+		got, err := parseTime(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseTime(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseTime("yesterday", now); err == nil {
+		t.Error("parseTime(\"yesterday\") error = nil, want error")
+	}
+}
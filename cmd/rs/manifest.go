@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/manifest"
+)
+
+// manifestRun holds the flags of 'rs batch --manifest'.
+type manifestRun struct {
+	path        string
+	format      string
+	resultsPath string
+	outputDir   string
+	poll        time.Duration
+}
+
+// runManifest runs a manifest batch. Take flags act as defaults that
+// manifest columns override. Results go to --results, or to stdout as
+// JSON lines; a summary is printed when they go to a file.
+func (c *cli) runManifest(ctx context.Context, client *rs.Client, tf *takeFlags, m manifestRun) error {
+	format := manifest.Format(m.format)
+	if format == "" {
+		if m.path == "-" {
+			return usageErrorf("--manifest-format is required when reading a manifest from stdin")
+		}
+		var err error
+		if format, err = manifest.FormatFromPath(m.path); err != nil {
+			return usageErrorf("%v", err)
+		}
+	}
+	resultsFormat := manifest.JSONL
+	if m.resultsPath != "" && m.resultsPath != "-" {
+		var err error
+		if resultsFormat, err = manifest.FormatFromPath(m.resultsPath); err != nil {
+			return usageErrorf("%v", err)
+		}
+	}
+
+	var in io.Reader = c.stdin
+	if m.path != "-" {
+		f, err := os.Open(m.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	rows, err := manifest.Read(in, format)
+	if err != nil {
+		return err
+	}
+
+	runner := &manifest.Runner{
+		Client:       client,
+		Defaults:     tf.apply,
+		OutputDir:    m.outputDir,
+		PollInterval: m.poll,
+		Fetch: func(ctx context.Context, imageURL string) ([]byte, error) {
+			data, _, err := fetchImage(ctx, client, imageURL)
+			return data, err
+		},
+		OnProgress: func(p manifest.Progress) {
+			fmt.Fprintf(c.stderr, "batch %s: %d/%d completed, %d failed, %d downloaded\n",
+				p.BatchID, p.Completed, p.Total, p.Failed, p.Downloaded)
+		},
+	}
+	results, err := runner.Run(ctx, rows)
+	if err != nil {
+		return err
+	}
+
+	if m.resultsPath == "" || m.resultsPath == "-" {
+		if err := manifest.WriteResults(c.stdout, resultsFormat, results); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Create(m.resultsPath)
+		if err != nil {
+			return err
+		}
+		if err := manifest.WriteResults(f, resultsFormat, results); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if err := c.print(map[string]interface{}{
+			"results": m.resultsPath,
+			"rows":    len(results),
+			"status":  manifest.Summary(results),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if n := manifest.Summary(results)[manifest.StatusDownloadFailed]; n > 0 {
+		return fmt.Errorf("%d of %d downloads failed", n, len(results))
+	}
+	return nil
+}
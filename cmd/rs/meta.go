@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+func runPresets(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("presets", "[id]")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageErrorf("expected at most one preset ID")
+	}
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	if len(positional) == 1 {
+		preset, err := client.Preset(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+		return c.print(preset)
+	}
+	presets, err := client.Presets(ctx)
+	if err != nil {
+		return err
+	}
+	return c.print(presets)
+}
+
+func runDevices(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("devices", "")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected arguments %q", positional)
+	}
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	devices, err := client.Devices(ctx)
+	if err != nil {
+		return err
+	}
+	return c.print(devices)
+}
+
+func runUsage(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("usage", "[flags]")
+	var tags map[string]string
+	fs.Func("tag", "only usage with tag key=value (repeatable)", tagFlag(&tags))
+	history := fs.Bool("history", false, "print usage history instead of the current period")
+	from := fs.String("from", "720h", "history start: RFC 3339 time, date, or a duration ago")
+	to := fs.String("to", "", "history end: RFC 3339 time, date, or a duration ago (default now)")
+	granularity := fs.String("granularity", string(rs.GranularityDay), "history bucket size: day, week or month")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected arguments %q", positional)
+	}
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	if !*history {
+		var usage *rs.UsageInfo
+		if len(tags) > 0 {
+			usage, err = client.UsageForTags(ctx, tags)
+		} else {
+			usage, err = client.Usage(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		return c.print(usage)
+	}
+
+	now := time.Now()
+	start, err := parseTime(*from, now)
+	if err != nil {
+		return usageErrorf("invalid --from: %v", err)
+	}
+	end := now
+	if *to != "" {
+		if end, err = parseTime(*to, now); err != nil {
+			return usageErrorf("invalid --to: %v", err)
+		}
+	}
+	var h *rs.UsageHistory
+	if len(tags) > 0 {
+		h, err = client.UsageHistoryForTags(ctx, start, end, rs.UsageGranularity(*granularity), tags)
+	} else {
+		h, err = client.UsageHistory(ctx, start, end, rs.UsageGranularity(*granularity))
+	}
+	if err != nil {
+		return err
+	}
+	return c.print(h)
+}
+
+// tagFlag returns a flag.Func handler collecting key=value pairs into *tags.
+func tagFlag(tags *map[string]string) func(string) error {
+	return func(s string) error {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("expected key=value, got %q", s)
+		}
+		if *tags == nil {
+			*tags = map[string]string{}
+		}
+		(*tags)[k] = v
+		return nil
+	}
+}
+
+// parseTime accepts an RFC 3339 time, a YYYY-MM-DD date (UTC) or a Go
+// duration meaning that long before now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time, date or duration", s)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+func writeJSON(w io.Writer, v interface{}, pretty bool) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// errorJSON mirrors the API error envelope for an *rs.Error.
+func errorJSON(err *rs.Error) map[string]interface{} {
+	e := map[string]interface{}{
+		"code":    err.Code,
+		"message": err.Message,
+	}
+	if err.HTTPStatus != 0 {
+		e["status"] = err.HTTPStatus
+	}
+	if err.RequestID != "" {
+		e["request_id"] = err.RequestID
+	}
+	if err.RetryAfter > 0 {
+		e["retry_after"] = err.RetryAfter
+	}
+	if err.DocumentationURL != "" {
+		e["documentation_url"] = err.DocumentationURL
+	}
+	if len(err.Details) > 0 {
+		details := make([]map[string]interface{}, 0, len(err.Details))
+		for _, d := range err.Details {
+			detail := map[string]interface{}{
+				"path":       d.Path,
+				"constraint": d.Constraint,
+				"message":    d.Message,
+			}
+			if d.Received != nil {
+				detail["received"] = d.Received
+			}
+			details = append(details, detail)
+		}
+		e["details"] = details
+	}
+	if err.Cause != nil {
+		e["cause"] = err.Cause.Error()
+	}
+	return map[string]interface{}{"error": e}
+}
+
+// writeOutput writes data to path, or to stdout when path is "" or "-".
+func (c *cli) writeOutput(path string, data []byte) error {
+	if path == "" || path == "-" {
+		_, err := c.stdout.Write(data)
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+func runSignURL(_ context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("sign-url", "[flags] <url>")
+	expires := fs.String("expires", "24h", "expiry as a duration from now or an RFC 3339 time")
+	signingKey := fs.String("signing-key", "", "signing key (default $RS_SIGNING_KEY or config)")
+	keyID := fs.String("key-id", "", "public key ID (default $RS_PUBLIC_KEY_ID or config)")
+	tf := newTakeFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 && tf.html == "" {
+		return usageErrorf("expected one URL or --html")
+	}
+
+	now := time.Now()
+	expiresAt, err := time.Parse(time.RFC3339, *expires)
+	if err != nil {
+		d, derr := time.ParseDuration(*expires)
+		if derr != nil || d <= 0 {
+			return usageErrorf("invalid --expires %q", *expires)
+		}
+		expiresAt = now.Add(d)
+	}
+
+	var pageURL string
+	if len(positional) == 1 {
+		pageURL = positional[0]
+	}
+	options, err := tf.options(pageURL)
+	if err != nil {
+		return err
+	}
+
+	if *signingKey == "" {
+		*signingKey = c.config.SigningKey
+	}
+	if *keyID == "" {
+		*keyID = c.config.PublicKeyID
+	}
+	if *signingKey == "" || *keyID == "" {
+		return errors.New("signing requires a signing key and public key ID: set RS_SIGNING_KEY and RS_PUBLIC_KEY_ID or pass --signing-key and --key-id")
+	}
+
+	// Signing is local, so the API key is only needed to construct a client.
+	apiKey := c.config.APIKey
+	if apiKey == "" {
+		apiKey = *keyID
+	}
+	var opts []rs.Option
+	if c.config.BaseURL != "" {
+		opts = append(opts, rs.WithBaseURL(c.config.BaseURL))
+	}
+	client, err := rs.New(apiKey, opts...)
+	if err != nil {
+		return err
+	}
+	signed, err := client.GenerateURL(options, expiresAt, *signingKey, *keyID)
+	if err != nil {
+		return err
+	}
+	return c.print(map[string]interface{}{
+		"url":        signed,
+		"expires_at": expiresAt.UTC().Format(time.RFC3339),
+	})
+}
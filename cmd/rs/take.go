@@ -0,0 +1,51 @@
+package main
+
+import "context"
+
+func runTake(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("take", "[flags] <url>")
+	out := fs.String("o", "", "write the capture to a file instead of stdout")
+	asJSON := fs.Bool("json", false, "print the JSON response (with image URL) instead of the capture")
+	tf := newTakeFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var pageURL string
+	switch {
+	case tf.html != "" && len(positional) == 0:
+	case tf.html == "" && len(positional) == 1:
+		pageURL = positional[0]
+	default:
+		return usageErrorf("expected one URL or --html")
+	}
+	options, err := tf.options(pageURL)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		resp, err := client.TakeJSON(ctx, options)
+		if err != nil {
+			return err
+		}
+		return c.print(resp)
+	}
+
+	data, err := client.Take(ctx, options)
+	if err != nil {
+		return err
+	}
+	if err := c.writeOutput(*out, data); err != nil {
+		return err
+	}
+	if *out == "" || *out == "-" {
+		return nil
+	}
+	return c.print(map[string]interface{}{"file": *out, "bytes": len(data)})
+}
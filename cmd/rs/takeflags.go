@@ -0,0 +1,309 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// takeFlags registers a flag for every TakeOptions setter. Setters are
+// applied in command-line order once the URL or HTML source is known, so
+// unset flags leave the API defaults in place.
+type takeFlags struct {
+	html    string
+	setters []func(*rs.TakeOptions)
+}
+
+func newTakeFlags(fs *flag.FlagSet) *takeFlags {
+	f := &takeFlags{}
+
+	fs.StringVar(&f.html, "html", "", "render this HTML instead of a URL (@file reads a file)")
+
+	f.str(fs, "preset", "preset ID, e.g. og_card", func(o *rs.TakeOptions, v string) { o.Preset(v) })
+	f.str(fs, "device", "device ID, e.g. iphone_14_pro", func(o *rs.TakeOptions, v string) { o.Device(v) })
+	f.int(fs, "width", "viewport width in pixels", func(o *rs.TakeOptions, v int) { o.Width(v) })
+	f.int(fs, "height", "viewport height in pixels", func(o *rs.TakeOptions, v int) { o.Height(v) })
+	f.float(fs, "scale", "device scale factor", func(o *rs.TakeOptions, v float64) { o.Scale(v) })
+	f.bool(fs, "mobile", "emulate a mobile device", func(o *rs.TakeOptions, v bool) { o.Mobile(v) })
+
+	f.bool(fs, "full-page", "capture the full scrollable page", func(o *rs.TakeOptions, v bool) { o.FullPage(v) })
+	f.str(fs, "element", "capture the element matching a CSS selector", func(o *rs.TakeOptions, v string) { o.Element(v) })
+	f.str(fs, "format", "output format: png, jpeg, webp or pdf", func(o *rs.TakeOptions, v string) { o.Format(rs.ImageFormat(v)) })
+	f.int(fs, "quality", "JPEG/WebP quality (1-100)", func(o *rs.TakeOptions, v int) { o.Quality(v) })
+
+	f.str(fs, "wait-for", "wait condition: load, domcontentloaded or networkidle", func(o *rs.TakeOptions, v string) { o.WaitFor(rs.WaitCondition(v)) })
+	f.int(fs, "delay", "extra delay before capture in milliseconds", func(o *rs.TakeOptions, v int) { o.Delay(v) })
+	f.str(fs, "wait-for-selector", "wait for a CSS selector to appear", func(o *rs.TakeOptions, v string) { o.WaitForSelector(v) })
+	f.int(fs, "wait-for-timeout", "wait timeout in milliseconds", func(o *rs.TakeOptions, v int) { o.WaitForTimeout(v) })
+
+	f.bool(fs, "block-ads", "block ads", func(o *rs.TakeOptions, v bool) { o.BlockAds(v) })
+	f.bool(fs, "block-trackers", "block trackers", func(o *rs.TakeOptions, v bool) { o.BlockTrackers(v) })
+	f.bool(fs, "block-cookie-banners", "block cookie banners", func(o *rs.TakeOptions, v bool) { o.BlockCookieBanners(v) })
+	f.bool(fs, "block-chat-widgets", "block chat widgets", func(o *rs.TakeOptions, v bool) { o.BlockChatWidgets(v) })
+	f.list(fs, "block-url", "block requests matching a URL pattern (repeatable)", func(o *rs.TakeOptions, v []string) { o.BlockURLs(v) })
+	f.list(fs, "block-resource", "block a resource type, e.g. font (repeatable)", func(o *rs.TakeOptions, v []string) { o.BlockResources(v) })
+
+	f.text(fs, "inject-script", "JavaScript to run before capture (@file reads a file)", func(o *rs.TakeOptions, v string) { o.InjectScript(v) })
+	f.text(fs, "inject-style", "CSS to inject before capture (@file reads a file)", func(o *rs.TakeOptions, v string) { o.InjectStyle(v) })
+	f.str(fs, "click", "click the element matching a CSS selector", func(o *rs.TakeOptions, v string) { o.Click(v) })
+	f.list(fs, "hide", "hide elements matching a CSS selector (repeatable)", func(o *rs.TakeOptions, v []string) { o.Hide(v) })
+	f.list(fs, "remove", "remove elements matching a CSS selector (repeatable)", func(o *rs.TakeOptions, v []string) { o.Remove(v) })
+
+	f.bool(fs, "dark-mode", "emulate prefers-color-scheme: dark", func(o *rs.TakeOptions, v bool) { o.DarkMode(v) })
+	f.bool(fs, "reduced-motion", "emulate prefers-reduced-motion", func(o *rs.TakeOptions, v bool) { o.ReducedMotion(v) })
+	f.str(fs, "media-type", "CSS media type: screen or print", func(o *rs.TakeOptions, v string) { o.SetMediaType(rs.MediaType(v)) })
+	f.str(fs, "user-agent", "browser user agent", func(o *rs.TakeOptions, v string) { o.UserAgent(v) })
+	f.str(fs, "timezone", "IANA timezone, e.g. Europe/Paris", func(o *rs.TakeOptions, v string) { o.Timezone(v) })
+	f.str(fs, "locale", "browser locale, e.g. fr-FR", func(o *rs.TakeOptions, v string) { o.Locale(v) })
+	f.parsed(fs, "geolocation", "geolocation as lat,lon[,accuracy]", parseGeolocation)
+
+	f.kv(fs, "header", ":", "HTTP header as 'Name: value' (repeatable)", func(o *rs.TakeOptions, v map[string]string) { o.Headers(v) })
+	f.parsed(fs, "cookie", "cookie as name=value[;domain=...;path=...] (repeatable)", cookieParser())
+	f.parsed(fs, "auth-basic", "HTTP basic auth as user:password", parseBasicAuth)
+	f.str(fs, "auth-bearer", "bearer token sent to the page", func(o *rs.TakeOptions, v string) { o.AuthBearer(v) })
+	f.bool(fs, "bypass-csp", "bypass the page's Content-Security-Policy", func(o *rs.TakeOptions, v bool) { o.BypassCSP(v) })
+
+	f.int(fs, "cache-ttl", "cache TTL in seconds", func(o *rs.TakeOptions, v int) { o.CacheTTL(v) })
+	f.bool(fs, "cache-refresh", "bypass and refresh the cache", func(o *rs.TakeOptions, v bool) { o.CacheRefresh(v) })
+
+	f.str(fs, "pdf-paper-size", "PDF paper size: a3, a4, a5, legal, letter or ledger", func(o *rs.TakeOptions, v string) { o.PDFPaperSize(rs.PaperSize(v)) })
+	f.str(fs, "pdf-width", "custom PDF width, e.g. 210mm", func(o *rs.TakeOptions, v string) { o.PDFWidth(v) })
+	f.str(fs, "pdf-height", "custom PDF height, e.g. 297mm", func(o *rs.TakeOptions, v string) { o.PDFHeight(v) })
+	f.bool(fs, "pdf-landscape", "landscape PDF orientation", func(o *rs.TakeOptions, v bool) { o.PDFLandscape(v) })
+	f.parsed(fs, "pdf-margin", "PDF margin: one value, or top,right,bottom,left", parsePDFMargin)
+	f.float(fs, "pdf-scale", "PDF rendering scale (0.1-2.0)", func(o *rs.TakeOptions, v float64) { o.PDFScale(v) })
+	f.bool(fs, "pdf-print-background", "print background graphics", func(o *rs.TakeOptions, v bool) { o.PDFPrintBackground(v) })
+	f.str(fs, "pdf-page-ranges", "PDF page ranges, e.g. 1-3", func(o *rs.TakeOptions, v string) { o.PDFPageRanges(v) })
+	f.str(fs, "pdf-header", "PDF header HTML template", func(o *rs.TakeOptions, v string) { o.PDFHeader(v) })
+	f.str(fs, "pdf-footer", "PDF footer HTML template", func(o *rs.TakeOptions, v string) { o.PDFFooter(v) })
+	f.bool(fs, "pdf-fit-one-page", "fit the PDF on one page", func(o *rs.TakeOptions, v bool) { o.PDFFitOnePage(v) })
+	f.bool(fs, "pdf-prefer-css-page-size", "prefer the CSS @page size", func(o *rs.TakeOptions, v bool) { o.PDFPreferCSSPageSize(v) })
+
+	f.bool(fs, "storage", "store the capture in your configured bucket", func(o *rs.TakeOptions, v bool) { o.StorageEnabled(v) })
+	f.str(fs, "storage-path", "storage path template", func(o *rs.TakeOptions, v string) { o.StoragePath(v) })
+	f.str(fs, "storage-acl", "storage ACL: public-read or private", func(o *rs.TakeOptions, v string) { o.StorageACL(rs.StorageACL(v)) })
+
+	f.kv(fs, "tag", "=", "attribution tag as key=value (repeatable)", func(o *rs.TakeOptions, v map[string]string) { o.Tags(v) })
+
+	return f
+}
+
+// options returns TakeOptions for pageURL, or for the --html source if it
+// was given.
+func (f *takeFlags) options(pageURL string) (*rs.TakeOptions, error) {
+	var o *rs.TakeOptions
+	if f.html != "" {
+		html, err := readArg(f.html)
+		if err != nil {
+			return nil, err
+		}
+		o = rs.HTML(html)
+	} else {
+		o = rs.URL(pageURL)
+	}
+	f.apply(o)
+	return o, nil
+}
+
+// apply runs the setters for every flag given on the command line.
+func (f *takeFlags) apply(o *rs.TakeOptions) {
+	for _, set := range f.setters {
+		set(o)
+	}
+}
+
+func (f *takeFlags) str(fs *flag.FlagSet, name, usage string, set func(*rs.TakeOptions, string)) {
+	fs.Func(name, usage, func(s string) error {
+		f.setters = append(f.setters, func(o *rs.TakeOptions) { set(o, s) })
+		return nil
+	})
+}
+
+// text is like str but reads the value from a file when it starts with @.
+func (f *takeFlags) text(fs *flag.FlagSet, name, usage string, set func(*rs.TakeOptions, string)) {
+	fs.Func(name, usage, func(s string) error {
+		v, err := readArg(s)
+		if err != nil {
+			return err
+		}
+		f.setters = append(f.setters, func(o *rs.TakeOptions) { set(o, v) })
+		return nil
+	})
+}
+
+func (f *takeFlags) int(fs *flag.FlagSet, name, usage string, set func(*rs.TakeOptions, int)) {
+	fs.Func(name, usage, func(s string) error {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		f.setters = append(f.setters, func(o *rs.TakeOptions) { set(o, v) })
+		return nil
+	})
+}
+
+func (f *takeFlags) float(fs *flag.FlagSet, name, usage string, set func(*rs.TakeOptions, float64)) {
+	fs.Func(name, usage, func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		f.setters = append(f.setters, func(o *rs.TakeOptions) { set(o, v) })
+		return nil
+	})
+}
+
+func (f *takeFlags) bool(fs *flag.FlagSet, name, usage string, set func(*rs.TakeOptions, bool)) {
+	fs.BoolFunc(name, usage, func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		f.setters = append(f.setters, func(o *rs.TakeOptions) { set(o, v) })
+		return nil
+	})
+}
+
+// list collects every occurrence of a repeatable flag into one setter call.
+func (f *takeFlags) list(fs *flag.FlagSet, name, usage string, set func(*rs.TakeOptions, []string)) {
+	var values []string
+	fs.Func(name, usage, func(s string) error {
+		if values == nil {
+			f.setters = append(f.setters, func(o *rs.TakeOptions) { set(o, values) })
+		}
+		values = append(values, s)
+		return nil
+	})
+}
+
+// kv collects repeatable key<sep>value flags into one map.
+func (f *takeFlags) kv(fs *flag.FlagSet, name, sep, usage string, set func(*rs.TakeOptions, map[string]string)) {
+	var values map[string]string
+	fs.Func(name, usage, func(s string) error {
+		k, v, ok := strings.Cut(s, sep)
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return fmt.Errorf("expected key%svalue, got %q", sep, s)
+		}
+		if values == nil {
+			values = map[string]string{}
+			f.setters = append(f.setters, func(o *rs.TakeOptions) { set(o, values) })
+		}
+		values[k] = strings.TrimSpace(v)
+		return nil
+	})
+}
+
+// parsed registers a flag whose value is parsed into a setter.
+func (f *takeFlags) parsed(fs *flag.FlagSet, name, usage string, parse func(string) (func(*rs.TakeOptions), error)) {
+	fs.Func(name, usage, func(s string) error {
+		set, err := parse(s)
+		if err != nil {
+			return err
+		}
+		f.setters = append(f.setters, set)
+		return nil
+	})
+}
+
+func parseGeolocation(s string) (func(*rs.TakeOptions), error) {
+	parts := strings.Split(s, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("expected lat,lon[,accuracy], got %q", s)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("invalid coordinates %q", s)
+	}
+	var accuracy []int
+	if len(parts) == 3 {
+		a, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid accuracy %q", parts[2])
+		}
+		accuracy = append(accuracy, a)
+	}
+	return func(o *rs.TakeOptions) { o.SetGeolocation(lat, lon, accuracy...) }, nil
+}
+
+func parseBasicAuth(s string) (func(*rs.TakeOptions), error) {
+	user, pass, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("expected user:password")
+	}
+	return func(o *rs.TakeOptions) { o.AuthBasic(user, pass) }, nil
+}
+
+func parsePDFMargin(s string) (func(*rs.TakeOptions), error) {
+	parts := strings.Split(s, ",")
+	switch len(parts) {
+	case 1:
+		return func(o *rs.TakeOptions) { o.PDFMarginUniform(s) }, nil
+	case 4:
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return func(o *rs.TakeOptions) { o.PDFMarginSides(parts[0], parts[1], parts[2], parts[3]) }, nil
+	}
+	return nil, fmt.Errorf("expected one margin or top,right,bottom,left, got %q", s)
+}
+
+// cookieParser collects repeated --cookie flags into one Cookies call.
+func cookieParser() func(string) (func(*rs.TakeOptions), error) {
+	var cookies []rs.Cookie
+	return func(s string) (func(*rs.TakeOptions), error) {
+		cookie, err := parseCookie(s)
+		if err != nil {
+			return nil, err
+		}
+		cookies = append(cookies, cookie)
+		if len(cookies) > 1 {
+			// The setter registered for the first cookie sees the whole list.
+			return func(*rs.TakeOptions) {}, nil
+		}
+		return func(o *rs.TakeOptions) { o.Cookies(cookies) }, nil
+	}
+}
+
+func parseCookie(s string) (rs.Cookie, error) {
+	attrs := strings.Split(s, ";")
+	name, value, ok := strings.Cut(strings.TrimSpace(attrs[0]), "=")
+	if !ok || name == "" {
+		return rs.Cookie{}, fmt.Errorf("expected name=value, got %q", s)
+	}
+	cookie := rs.Cookie{Name: name, Value: value}
+	for _, attr := range attrs[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(attr), "=")
+		switch strings.ToLower(k) {
+		case "domain":
+			cookie.Domain = v
+		case "path":
+			cookie.Path = v
+		case "secure":
+			cookie.Secure = true
+		case "httponly":
+			cookie.HTTPOnly = true
+		case "":
+		default:
+			return rs.Cookie{}, fmt.Errorf("unknown cookie attribute %q", k)
+		}
+	}
+	return cookie, nil
+}
+
+// readArg returns s, or the contents of the named file when s starts with @.
+func readArg(s string) (string, error) {
+	if !strings.HasPrefix(s, "@") {
+		return s, nil
+	}
+	data, err := os.ReadFile(s[1:])
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
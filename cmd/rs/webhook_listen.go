@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// storedDelivery is a received webhook persisted for replay.
+type storedDelivery struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	ReceivedAt time.Time `json:"received_at"`
+	Verified   bool      `json:"verified"`
+	Signature  string    `json:"signature,omitempty"`
+	Timestamp  string    `json:"timestamp,omitempty"`
+	Forward    string    `json:"forward,omitempty"`
+	Payload    string    `json:"payload"`
+}
+
+// webhookStore keeps deliveries as <id>.json files in a directory.
+type webhookStore struct {
+	dir string
+}
+
+func defaultWebhookStore() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "rs-webhooks")
+	}
+	return filepath.Join(dir, "rs", "webhooks")
+}
+
+var safeID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// file maps an ID to a file name, hashing IDs that are not file-name safe.
+func (s webhookStore) file(id string) string {
+	name := id
+	if !safeID.MatchString(id) || id == "." || id == ".." {
+		sum := sha256.Sum256([]byte(id))
+		name = "id-" + hex.EncodeToString(sum[:8])
+	}
+	return filepath.Join(s.dir, name+".json")
+}
+
+func (s webhookStore) save(d *storedDelivery) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.file(d.ID), data, 0o600)
+}
+
+func (s webhookStore) load(id string) (*storedDelivery, error) {
+	data, err := os.ReadFile(s.file(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no stored delivery %q in %s", id, s.dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d storedDelivery
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("delivery %s: %w", id, err)
+	}
+	return &d, nil
+}
+
+// list returns stored deliveries, oldest first.
+func (s webhookStore) list() ([]*storedDelivery, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]*storedDelivery, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var d storedDelivery
+		if err := json.Unmarshal(data, &d); err != nil {
+			continue
+		}
+		deliveries = append(deliveries, &d)
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ReceivedAt.Before(deliveries[j].ReceivedAt)
+	})
+	return deliveries, nil
+}
+
+// webhookListener is the handler behind 'rs webhook listen'. It verifies,
+// prints and stores each delivery, then optionally forwards it unchanged.
+type webhookListener struct {
+	secrets   []string // empty disables verification
+	tolerance time.Duration
+	store     webhookStore
+	forward   string
+	client    *http.Client
+	jsonLines bool
+	out       io.Writer
+	log       io.Writer
+	now       func() time.Time
+
+	mu  sync.Mutex // serialises output and the fallback ID counter
+	seq int
+}
+
+func (l *webhookListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rs.DefaultWebhookMaxBodyBytes))
+	if err != nil {
+		l.logf("rejected delivery: %v", err)
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	headers := rs.ExtractWebhookHTTPHeaders(r.Header)
+	d := &storedDelivery{
+		ID:         headers.ID,
+		ReceivedAt: l.now().UTC(),
+		Signature:  headers.Signature,
+		Timestamp:  headers.Timestamp,
+		Forward:    l.forward,
+		Payload:    string(body),
+	}
+
+	var verification *rs.WebhookVerification
+	if len(l.secrets) > 0 {
+		verification, err = rs.VerifyWebhookSignature(d.Payload, headers.Signature, headers.Timestamp, l.secrets, l.tolerance)
+		if err != nil {
+			l.logf("rejected delivery %s: %v", d.ID, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		d.Verified = true
+	}
+
+	event, err := rs.ParseWebhook(d.Payload)
+	if err != nil {
+		l.logf("rejected delivery %s: %v", d.ID, err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	d.Event = event.Event
+	if d.ID == "" {
+		d.ID = event.ID
+	}
+	if d.ID == "" {
+		l.mu.Lock()
+		l.seq++
+		d.ID = fmt.Sprintf("local_%d_%d", d.ReceivedAt.Unix(), l.seq)
+		l.mu.Unlock()
+	}
+
+	if err := l.store.save(d); err != nil {
+		l.logf("storing delivery %s: %v", d.ID, err)
+		http.Error(w, "could not store delivery", http.StatusInternalServerError)
+		return
+	}
+	l.print(d, event, verification)
+
+	if l.forward == "" {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"received":true}`))
+		return
+	}
+	l.forwardDelivery(r.Context(), w, r.Header, body, d.ID)
+}
+
+// forwardDelivery posts the delivery to the forward URL with its original
+// webhook headers and relays the response, so the application's status
+// codes drive the sender's retries.
+func (l *webhookListener) forwardDelivery(ctx context.Context, w http.ResponseWriter, header http.Header, body []byte, id string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.forward, bytes.NewReader(body))
+	if err != nil {
+		l.logf("forwarding %s: %v", id, err)
+		http.Error(w, "forward failed", http.StatusBadGateway)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, name := range []string{rs.SignatureHeader, rs.TimestampHeader, rs.IDHeader} {
+		for _, v := range header.Values(name) {
+			req.Header.Add(name, v)
+		}
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		l.logf("forwarding %s to %s: %v", id, l.forward, err)
+		http.Error(w, "forward failed", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	l.logf("forwarded %s to %s: %s", id, l.forward, resp.Status)
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, io.LimitReader(resp.Body, 1<<20))
+}
+
+// print writes the delivery to out: a summary line and indented payload,
+// or one JSON object per line with --json.
+func (l *webhookListener) print(d *storedDelivery, event *rs.WebhookEvent, v *rs.WebhookVerification) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.jsonLines {
+		_ = writeJSON(l.out, map[string]interface{}{
+			"id":          d.ID,
+			"event":       d.Event,
+			"received_at": d.ReceivedAt.Format(time.RFC3339),
+			"verified":    d.Verified,
+			"payload":     json.RawMessage(d.Payload),
+		}, false)
+		return
+	}
+
+	status := "unverified"
+	if v != nil {
+		status = "verified with secret #" + strconv.Itoa(v.SecretIndex)
+	}
+	fmt.Fprintf(l.out, "%s  %s  %s  (%s)\n", d.ReceivedAt.Local().Format("15:04:05"), event.Event, d.ID, status)
+	if len(event.Tags) > 0 {
+		fmt.Fprintf(l.out, "  tags: %v\n", event.Tags)
+	}
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, []byte(d.Payload), "  ", "  ") == nil {
+		fmt.Fprintf(l.out, "  %s\n\n", pretty.String())
+	}
+}
+
+func (l *webhookListener) logf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.log, format+"\n", args...)
+}
+
+func runWebhookListen(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("webhook listen", "[flags]")
+	port := fs.Int("port", 8787, "port to listen on")
+	host := fs.String("host", "127.0.0.1", "interface to listen on")
+	path := fs.String("path", "/", "URL path to receive deliveries on")
+	var secrets webhookSecretsFlag
+	fs.Var(&secrets, "secret", "webhook secret; repeat during rotation (default $RS_WEBHOOK_SECRET or config)")
+	noVerify := fs.Bool("no-verify", false, "accept deliveries without verifying signatures")
+	tolerance := fs.Duration("tolerance", rs.DefaultTolerance, "maximum timestamp age")
+	forward := fs.String("forward", "", "forward verified deliveries to this URL, e.g. http://localhost:3000/webhooks")
+	store := fs.String("store", defaultWebhookStore(), "directory where deliveries are stored for replay")
+	jsonLines := fs.Bool("json", false, "print one JSON object per delivery instead of a summary")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected arguments %q", positional)
+	}
+
+	var keys []string
+	if !*noVerify {
+		if keys, err = secrets.resolve(c.config); err != nil {
+			return usageErrorf("%v (or pass --no-verify)", err)
+		}
+	}
+
+	listener := &webhookListener{
+		secrets:   keys,
+		tolerance: *tolerance,
+		store:     webhookStore{dir: *store},
+		forward:   *forward,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		jsonLines: *jsonLines,
+		out:       c.stdout,
+		log:       c.stderr,
+		now:       time.Now,
+	}
+	mux := http.NewServeMux()
+	mux.Handle(*path, listener)
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(*host, strconv.Itoa(*port)))
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	fmt.Fprintf(c.stderr, "listening for webhooks on http://%s%s (storing in %s)\n", ln.Addr(), *path, *store)
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+func runWebhookReplay(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("webhook replay", "[flags] <id> | --list")
+	store := fs.String("store", defaultWebhookStore(), "directory deliveries were stored in by 'rs webhook listen'")
+	list := fs.Bool("list", false, "list stored deliveries")
+	to := fs.String("to", "", "URL to deliver to (default the delivery's --forward URL)")
+	var secrets webhookSecretsFlag
+	fs.Var(&secrets, "secret", "secret to sign with (default $RS_WEBHOOK_SECRET or config)")
+	attempts := fs.Int("attempts", 1, "delivery attempts, retrying 408, 429 and 5xx responses")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	s := webhookStore{dir: *store}
+
+	if *list {
+		deliveries, err := s.list()
+		if err != nil {
+			return err
+		}
+		summaries := make([]map[string]interface{}, 0, len(deliveries))
+		for _, d := range deliveries {
+			summaries = append(summaries, map[string]interface{}{
+				"id":          d.ID,
+				"event":       d.Event,
+				"received_at": d.ReceivedAt.Format(time.RFC3339),
+				"verified":    d.Verified,
+			})
+		}
+		return c.print(summaries)
+	}
+
+	if len(positional) != 1 {
+		return usageErrorf("expected one delivery ID")
+	}
+	d, err := s.load(positional[0])
+	if err != nil {
+		return err
+	}
+	target := *to
+	if target == "" {
+		target = d.Forward
+	}
+	if target == "" {
+		return usageErrorf("no target: pass --to (the delivery was not forwarded when received)")
+	}
+	keys, err := secrets.resolve(c.config)
+	if err != nil {
+		return err
+	}
+
+	// Each attempt is re-signed with a fresh timestamp, so the replay passes
+	// timestamp tolerance checks however old the delivery is.
+	sender := rs.NewWebhookSender(keys[0])
+	sender.MaxAttempts = *attempts
+	delivery, err := sender.SendPayload(ctx, target, d.ID, []byte(d.Payload))
+	if err != nil && delivery == nil {
+		return err
+	}
+
+	out := map[string]interface{}{
+		"id":        d.ID,
+		"event":     d.Event,
+		"to":        target,
+		"delivered": delivery.Delivered,
+		"attempts":  attemptsJSON(delivery.Attempts),
+	}
+	if perr := c.print(out); perr != nil {
+		return perr
+	}
+	// err describes the last failed attempt when the delivery failed.
+	return err
+}
+
+func attemptsJSON(attempts []rs.WebhookAttempt) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(attempts))
+	for _, a := range attempts {
+		m := map[string]interface{}{"duration_ms": a.Duration.Milliseconds()}
+		if a.StatusCode != 0 {
+			m["status"] = a.StatusCode
+		}
+		if a.Err != nil {
+			m["error"] = a.Err.Error()
+		}
+		out = append(out, m)
+	}
+	return out
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+const testDelivery = `{"event":"screenshot.completed","id":"evt_1","timestamp":1,"data":{"url":"https://example.com"}}`
+
+func newTestListener(t *testing.T, forward string) (*webhookListener, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	return &webhookListener{
+		secrets:   []string{"whsec_test"},
+		tolerance: rs.DefaultTolerance,
+		store:     webhookStore{dir: t.TempDir()},
+		forward:   forward,
+		client:    http.DefaultClient,
+		out:       &out,
+		log:       io.Discard,
+		now:       time.Now,
+	}, &out
+}
+
+func deliver(t *testing.T, h http.Handler, secret, id, payload string) *httptest.ResponseRecorder {
+	t.Helper()
+	ts := time.Now().Unix()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	req.Header.Set(rs.SignatureHeader, rs.SignWebhook(payload, secret, ts))
+	req.Header.Set(rs.TimestampHeader, fmt.Sprint(ts))
+	if id != "" {
+		req.Header.Set(rs.IDHeader, id)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookListenerStoresDelivery(t *testing.T) {
+	l, out := newTestListener(t, "")
+
+	rec := deliver(t, l, "whsec_test", "dlv_1", testDelivery)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(out.String(), "screenshot.completed  dlv_1  (verified with secret #0)") {
+		t.Errorf("output = %q", out)
+	}
+
+	d, err := l.store.load("dlv_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Event != "screenshot.completed" || !d.Verified || d.Payload != testDelivery {
+		t.Errorf("stored = %+v", d)
+	}
+
+	// Without an X-Webhook-ID header the event ID is used.
+	deliver(t, l, "whsec_test", "", testDelivery)
+	if _, err := l.store.load("evt_1"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebhookListenerRejects(t *testing.T) {
+	l, out := newTestListener(t, "")
+
+	if rec := deliver(t, l, "whsec_wrong", "dlv_1", testDelivery); rec.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature: status = %d, want 401", rec.Code)
+	}
+	if rec := deliver(t, l, "whsec_test", "dlv_2", "not json"); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad payload: status = %d, want 400", rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want 405", rec.Code)
+	}
+	if out.Len() != 0 {
+		t.Errorf("rejected deliveries were printed: %q", out)
+	}
+	if deliveries, _ := l.store.list(); len(deliveries) != 0 {
+		t.Errorf("stored %d rejected deliveries", len(deliveries))
+	}
+}
+
+func TestWebhookListenerForwards(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer app.Close()
+	l, _ := newTestListener(t, app.URL)
+
+	rec := deliver(t, l, "whsec_test", "dlv_1", testDelivery)
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want the application's 202", rec.Code)
+	}
+	if got == nil {
+		t.Fatal("delivery was not forwarded")
+	}
+	if string(body) != testDelivery || got.Header.Get(rs.IDHeader) != "dlv_1" || got.Header.Get(rs.SignatureHeader) == "" {
+		t.Errorf("forwarded body = %s, headers = %v", body, got.Header)
+	}
+
+	app.Close()
+	if rec := deliver(t, l, "whsec_test", "dlv_2", testDelivery); rec.Code != http.StatusBadGateway {
+		t.Errorf("unreachable application: status = %d, want 502", rec.Code)
+	}
+}
+
+func TestWebhookStoreUnsafeIDs(t *testing.T) {
+	s := webhookStore{dir: t.TempDir()}
+	for _, id := range []string{"../escape", "..", "a/b"} {
+		if err := s.save(&storedDelivery{ID: id, Payload: "{}"}); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(s.file(id), s.dir) || strings.Contains(s.file(id)[len(s.dir):], "..") {
+			t.Errorf("file(%q) = %s escapes the store", id, s.file(id))
+		}
+		d, err := s.load(id)
+		if err != nil || d.ID != id {
+			t.Errorf("load(%q) = %+v, %v", id, d, err)
+		}
+	}
+}
+
+func TestWebhookReplay(t *testing.T) {
+	var verified *rs.WebhookVerification
+	var verifyErr error
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified, verifyErr = rs.VerifyWebhookSignature(string(body),
+			r.Header.Get(rs.SignatureHeader), r.Header.Get(rs.TimestampHeader), []string{"whsec_test"}, 0)
+	}))
+	defer app.Close()
+
+	store := t.TempDir()
+	s := webhookStore{dir: store}
+	// Stored an hour ago, so the original signature would be rejected.
+	if err := s.save(&storedDelivery{
+		ID:         "dlv_1",
+		Event:      "screenshot.completed",
+		ReceivedAt: time.Now().Add(-time.Hour),
+		Forward:    app.URL,
+		Payload:    testDelivery,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{envWebhookSecret: "whsec_test", envConfig: writeConfig(t, config{})}
+
+	r := runEnv(t, env, "", "webhook", "replay", "--store", store, "dlv_1")
+	if r.code != exitOK {
+		t.Fatalf("exit code = %d, stdout = %s, stderr = %s", r.code, r.stdout, r.stderr)
+	}
+	if verifyErr != nil || verified == nil {
+		t.Fatalf("replayed delivery failed verification: %v", verifyErr)
+	}
+	var out map[string]interface{}
+	decode(t, r.stdout, &out)
+	if out["delivered"] != true || out["to"] != app.URL {
+		t.Errorf("output = %v", out)
+	}
+
+	r = runEnv(t, env, "", "webhook", "replay", "--store", store, "--list")
+	var list []map[string]interface{}
+	decode(t, r.stdout, &list)
+	if len(list) != 1 || list[0]["id"] != "dlv_1" {
+		t.Errorf("--list = %v", list)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	r = runEnv(t, env, "", "webhook", "replay", "--store", store, "--to", failing.URL, "dlv_1")
+	if r.code != exitError || !strings.Contains(r.stdout, `"delivered":false`) {
+		t.Errorf("failing target: exit code = %d, stdout = %s", r.code, r.stdout)
+	}
+
+	if r := runEnv(t, env, "", "webhook", "replay", "--store", store, "missing"); r.code != exitError {
+		t.Errorf("unknown ID: exit code = %d, want %d", r.code, exitError)
+	}
+}
+
+func TestWebhookReplayNeedsTarget(t *testing.T) {
+	store := t.TempDir()
+	if err := (webhookStore{dir: store}).save(&storedDelivery{ID: "dlv_1", Payload: testDelivery}); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{envWebhookSecret: "whsec_test", envConfig: writeConfig(t, config{})}
+	r := runEnv(t, env, "", "webhook", "replay", "--store", store, "dlv_1")
+	if r.code != exitUsage || !strings.Contains(r.stderr, "--to") {
+		t.Errorf("exit code = %d, stderr = %s", r.code, r.stderr)
+	}
+}
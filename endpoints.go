@@ -0,0 +1,126 @@
+package renderscreenshot
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// endpointCooldown is how long a failing endpoint is deprioritised before
+	// it is tried again ahead of healthy endpoints.
+	endpointCooldown = 30 * time.Second
+	// latencySmoothing is the weight of the newest sample in the latency EWMA.
+	latencySmoothing = 0.3
+)
+
+// EndpointStatus is a snapshot of the health of one API endpoint.
+type EndpointStatus struct {
+	BaseURL  string
+	Healthy  bool
+	Latency  time.Duration
+	Failures int
+}
+
+type endpoint struct {
+	baseURL        string
+	latency        time.Duration
+	failures       int
+	unhealthyUntil time.Time
+}
+
+func (e *endpoint) healthy(now time.Time) bool {
+	return !now.Before(e.unhealthyUntil)
+}
+
+// endpointPool tracks the health and latency of a set of API base URLs and
+// orders them by preference for each request.
+type endpointPool struct {
+	mu        sync.Mutex
+	endpoints []*endpoint
+	now       func() time.Time
+}
+
+func newEndpointPool(baseURLs []string) *endpointPool {
+	p := &endpointPool{now: time.Now}
+	for _, u := range baseURLs {
+		u = strings.TrimRight(u, "/")
+		if u == "" {
+			continue
+		}
+		p.endpoints = append(p.endpoints, &endpoint{baseURL: u})
+	}
+	return p
+}
+
+// ordered returns the endpoints in the order they should be tried: healthy
+// endpoints by ascending latency, then unhealthy ones by soonest recovery.
+func (p *endpointPool) ordered() []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	result := make([]*endpoint, len(p.endpoints))
+	copy(result, p.endpoints)
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		ah, bh := a.healthy(now), b.healthy(now)
+		if ah != bh {
+			return ah
+		}
+		if !ah {
+			return a.unhealthyUntil.Before(b.unhealthyUntil)
+		}
+		return a.latency < b.latency
+	})
+	return result
+}
+
+// record updates an endpoint's health after a request attempt.
+func (p *endpointPool) record(e *endpoint, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil && shouldFailover(err) {
+		e.failures++
+		e.unhealthyUntil = p.now().Add(endpointCooldown)
+		return
+	}
+
+	e.failures = 0
+	e.unhealthyUntil = time.Time{}
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(e.latency))
+	}
+}
+
+func (p *endpointPool) status() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	result := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		result = append(result, EndpointStatus{
+			BaseURL:  e.baseURL,
+			Healthy:  e.healthy(now),
+			Latency:  e.latency,
+			Failures: e.failures,
+		})
+	}
+	return result
+}
+
+// shouldFailover reports whether err indicates the endpoint itself is
+// unavailable, so the request should be retried against another endpoint.
+func shouldFailover(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == CodeConnectionError || apiErr.HTTPStatus >= 500
+}
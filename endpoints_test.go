@@ -0,0 +1,148 @@
+package renderscreenshot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpointsFailoverOn5xx(t *testing.T) {
+	eu := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(502)
+	}))
+	defer eu.Close()
+
+	us := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"credits": 100.0})
+	}))
+	defer us.Close()
+
+	client, _ := New("rs_live_test", WithEndpoints([]string{eu.URL, us.URL}))
+	usage, err := client.Usage(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage.Credits != 100 {
+		t.Errorf("Credits = %d, want 100", usage.Credits)
+	}
+
+	status := client.Endpoints()
+	if len(status) != 2 {
+		t.Fatalf("expected 2 endpoints, got %d", len(status))
+	}
+	if status[0].Healthy || status[0].Failures != 1 {
+		t.Errorf("EU endpoint = %+v, want unhealthy with 1 failure", status[0])
+	}
+	if !status[1].Healthy {
+		t.Errorf("US endpoint = %+v, want healthy", status[1])
+	}
+}
+
+func TestEndpointsFailoverOnConnectionError(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downURL := down.URL
+	down.Close()
+
+	hits := 0
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{})
+	}))
+	defer up.Close()
+
+	client, _ := New("rs_live_test", WithEndpoints([]string{downURL, up.URL}))
+	for i := 0; i < 3; i++ {
+		if _, err := client.Usage(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if hits != 3 {
+		t.Errorf("expected 3 requests to healthy endpoint, got %d", hits)
+	}
+	// The failed endpoint stays deprioritised during its cooldown.
+	if status := client.Endpoints(); status[0].Failures != 1 {
+		t.Errorf("down endpoint failures = %d, want 1", status[0].Failures)
+	}
+}
+
+func TestEndpointsNoFailoverOn4xx(t *testing.T) {
+	second := 0
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	}))
+	defer first.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		second++
+	}))
+	defer other.Close()
+
+	client, _ := New("rs_live_test", WithEndpoints([]string{first.URL, other.URL}))
+	_, err := client.Preset(context.Background(), "missing")
+	if !IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+	if second != 0 {
+		t.Errorf("expected no failover on 4xx, got %d requests", second)
+	}
+}
+
+func TestEndpointPoolPrefersLowerLatency(t *testing.T) {
+	pool := newEndpointPool([]string{"https://eu.example.com/", "https://us.example.com"})
+	eu, us := pool.endpoints[0], pool.endpoints[1]
+	if eu.baseURL != "https://eu.example.com" {
+		t.Errorf("baseURL = %q, want trailing slash trimmed", eu.baseURL)
+	}
+
+	pool.record(eu, 200*time.Millisecond, nil)
+	pool.record(us, 50*time.Millisecond, nil)
+	if got := pool.ordered()[0]; got != us {
+		t.Errorf("preferred = %q, want us", got.baseURL)
+	}
+
+	pool.record(us, 0, &Error{Code: CodeConnectionError})
+	if got := pool.ordered()[0]; got != eu {
+		t.Errorf("preferred = %q, want eu after us failure", got.baseURL)
+	}
+
+	// After the cooldown the endpoint is considered healthy again.
+	now := time.Now().Add(endpointCooldown + time.Second)
+	pool.now = func() time.Time { return now }
+	if got := pool.ordered()[0]; got != us {
+		t.Errorf("preferred = %q, want us after cooldown", got.baseURL)
+	}
+}
+
+func TestGenerateURLForEndpoint(t *testing.T) {
+	client, _ := New("rs_live_test",
+		WithEndpoints([]string{"https://eu.api.renderscreenshot.com", "https://us.api.renderscreenshot.com"}),
+		WithSigningKey("rs_secret_test123"),
+		WithPublicKeyID("rs_pub_test456"),
+	)
+
+	expires := time.Unix(1700000000, 0)
+	opts := URL("https://example.com")
+	euURL, err := client.GenerateURL(opts, expires, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	usURL, err := client.GenerateURLForEndpoint("https://us.api.renderscreenshot.com/", opts, expires, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(euURL, "https://eu.api.renderscreenshot.com/v1/screenshot?") {
+		t.Errorf("default URL = %q, want first endpoint", euURL)
+	}
+	if !strings.HasPrefix(usURL, "https://us.api.renderscreenshot.com/v1/screenshot?") {
+		t.Errorf("regional URL = %q", usURL)
+	}
+	if euURL[strings.Index(euURL, "?"):] != usURL[strings.Index(usURL, "?"):] {
+		t.Error("expected identical signed query for every region")
+	}
+}
@@ -1,6 +1,10 @@
 package renderscreenshot
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 // ErrorCode represents API error codes.
 type ErrorCode string
@@ -23,6 +27,28 @@ const (
 	CodeConnectionError ErrorCode = "connection_error"
 )
 
+// Sentinel errors for each ErrorCode, for use with errors.Is:
+//
+//	if errors.Is(err, renderscreenshot.ErrRateLimited) { ... }
+//
+// An *Error matches a sentinel when their codes are equal.
+var (
+	ErrInvalidURL       = &Error{Message: "invalid URL", Code: CodeInvalidURL}
+	ErrInvalidRequest   = &Error{Message: "invalid request", Code: CodeInvalidRequest}
+	ErrMissingRequired  = &Error{Message: "missing required parameter", Code: CodeMissingRequired}
+	ErrUnauthorized     = &Error{Message: "unauthorized", Code: CodeUnauthorized}
+	ErrInvalidAPIKey    = &Error{Message: "invalid API key", Code: CodeInvalidAPIKey}
+	ErrExpiredSignature = &Error{Message: "expired signature", Code: CodeExpiredSig}
+	ErrForbidden        = &Error{Message: "forbidden", Code: CodeForbidden}
+	ErrNoCredits        = &Error{Message: "insufficient credits", Code: CodeNoCredits}
+	ErrNotFound         = &Error{Message: "not found", Code: CodeNotFound}
+	ErrRateLimited      = &Error{Message: "rate limited", Code: CodeRateLimited}
+	ErrTimeout          = &Error{Message: "timeout", Code: CodeTimeout}
+	ErrRenderFailed     = &Error{Message: "render failed", Code: CodeRenderFailed}
+	ErrInternal         = &Error{Message: "internal error", Code: CodeInternalError}
+	ErrConnection       = &Error{Message: "connection error", Code: CodeConnectionError}
+)
+
 // Error represents an API error from RenderScreenshot.
 type Error struct {
 	Message    string
@@ -30,6 +56,24 @@ type Error struct {
 	Code       ErrorCode
 	RequestID  string
 	RetryAfter int
+	// Details lists the individual fields rejected by a 400/422 response.
+	Details []FieldError
+	// DocumentationURL links to documentation about the error, if provided.
+	DocumentationURL string
+	// Cause is the underlying error, such as a network failure, if any.
+	Cause error
+}
+
+// FieldError describes a single invalid field in a rejected request.
+type FieldError struct {
+	// Path is the dotted path of the field, e.g. "viewport.width".
+	Path string
+	// Constraint is the rule that failed, e.g. "max" or "required".
+	Constraint string
+	// Message is a human-readable description of the problem.
+	Message string
+	// Received is the value the API received, if reported.
+	Received interface{}
 }
 
 // Error implements the error interface.
@@ -43,6 +87,22 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("renderscreenshot: %s (code=%s)", e.Message, e.Code)
 }
 
+// Unwrap returns the underlying cause, allowing errors.Is and errors.As to
+// inspect network and context errors.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is an *Error with the same non-empty code,
+// which makes the package's sentinel errors usable with errors.Is.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Code != "" && t.Code == e.Code
+}
+
 // IsRetryable returns true if the error represents a transient failure that can be retried.
 func (e *Error) IsRetryable() bool {
 	switch e.Code {
@@ -57,8 +117,8 @@ func (e *Error) IsRetryable() bool {
 
 // IsNotFound returns true if the error represents a 404 not found response.
 func IsNotFound(err error) bool {
-	e, ok := err.(*Error)
-	if !ok {
+	var e *Error
+	if !errors.As(err, &e) {
 		return false
 	}
 	return e.HTTPStatus == 404 || e.Code == CodeNotFound
@@ -66,8 +126,8 @@ func IsNotFound(err error) bool {
 
 // IsRetryable returns true if the error is retryable.
 func IsRetryable(err error) bool {
-	e, ok := err.(*Error)
-	if !ok {
+	var e *Error
+	if !errors.As(err, &e) {
 		return false
 	}
 	return e.IsRetryable()
@@ -75,35 +135,40 @@ func IsRetryable(err error) bool {
 
 // IsRateLimited returns true if the error represents a rate limit response.
 func IsRateLimited(err error) bool {
-	e, ok := err.(*Error)
-	if !ok {
+	var e *Error
+	if !errors.As(err, &e) {
 		return false
 	}
 	return e.Code == CodeRateLimited || e.HTTPStatus == 429
 }
 
-// IsAuthentication returns true if the error represents an authentication failure.
+// IsAuthentication returns true if the error represents an authentication
+// failure, including a proxy rejecting its credentials (407).
 func IsAuthentication(err error) bool {
-	e, ok := err.(*Error)
-	if !ok {
+	var e *Error
+	if !errors.As(err, &e) {
 		return false
 	}
-	return e.HTTPStatus == 401
+	return e.HTTPStatus == 401 || e.HTTPStatus == 407
 }
 
 // IsValidation returns true if the error represents a validation failure.
 func IsValidation(err error) bool {
-	e, ok := err.(*Error)
-	if !ok {
+	var e *Error
+	if !errors.As(err, &e) {
 		return false
 	}
 	return e.HTTPStatus == 400 || (e.HTTPStatus == 422 && e.Code != CodeRenderFailed)
 }
 
 // errorFromResponse creates an Error from an HTTP response status and body.
+// Both the API's {"error": {...}} envelope and RFC 7807 problem details
+// (application/problem+json) bodies are understood.
 func errorFromResponse(httpStatus int, body map[string]interface{}, retryAfter int, requestID string) *Error {
 	message := fmt.Sprintf("HTTP %d error", httpStatus)
 	var code ErrorCode
+	var details []FieldError
+	var docURL string
 
 	if errObj, ok := body["error"]; ok {
 		if errMap, ok := errObj.(map[string]interface{}); ok {
@@ -116,6 +181,20 @@ func errorFromResponse(httpStatus int, body map[string]interface{}, retryAfter i
 			if rid, ok := errMap["request_id"].(string); ok && requestID == "" {
 				requestID = rid
 			}
+			details = parseFieldErrors(errMap)
+			docURL = firstString(errMap, "documentation_url", "doc_url", "docs_url")
+		}
+	} else if isProblemDetails(body) {
+		if msg := firstString(body, "detail", "title"); msg != "" {
+			message = msg
+		}
+		if c, ok := body["code"].(string); ok {
+			code = ErrorCode(c)
+		}
+		details = parseFieldErrors(body)
+		docURL = firstString(body, "documentation_url")
+		if t, ok := body["type"].(string); ok && docURL == "" && t != "about:blank" {
+			docURL = t
 		}
 	}
 
@@ -147,10 +226,89 @@ func errorFromResponse(httpStatus int, body map[string]interface{}, retryAfter i
 	}
 
 	return &Error{
-		Message:    message,
-		HTTPStatus: httpStatus,
-		Code:       code,
-		RequestID:  requestID,
-		RetryAfter: retryAfter,
+		Message:          message,
+		HTTPStatus:       httpStatus,
+		Code:             code,
+		RequestID:        requestID,
+		RetryAfter:       retryAfter,
+		Details:          details,
+		DocumentationURL: docURL,
+	}
+}
+
+// isProblemDetails reports whether body looks like an RFC 7807 document.
+func isProblemDetails(body map[string]interface{}) bool {
+	for _, k := range []string{"type", "title", "detail"} {
+		if _, ok := body[k].(string); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFieldErrors reads field-level validation errors from the first of
+// the commonly used list keys present in m.
+func parseFieldErrors(m map[string]interface{}) []FieldError {
+	var list []interface{}
+	for _, k := range []string{"details", "errors", "fields", "invalid_params", "invalid-params"} {
+		if v, ok := m[k].([]interface{}); ok {
+			list = v
+			break
+		}
+	}
+
+	var result []FieldError
+	for _, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fe := FieldError{
+			Constraint: firstString(entry, "constraint", "rule", "code", "type"),
+			Message:    firstString(entry, "message", "reason", "detail"),
+		}
+		switch p := firstValue(entry, "path", "field", "name", "pointer", "param").(type) {
+		case string:
+			fe.Path = normalizeFieldPath(p)
+		case []interface{}:
+			parts := make([]string, 0, len(p))
+			for _, part := range p {
+				parts = append(parts, fmt.Sprint(part))
+			}
+			fe.Path = strings.Join(parts, ".")
+		}
+		fe.Received = firstValue(entry, "received", "value", "received_value")
+		result = append(result, fe)
+	}
+	return result
+}
+
+// normalizeFieldPath converts JSON pointers ("/viewport/width") to dotted paths.
+func normalizeFieldPath(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		return p
+	}
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+	}
+	return strings.Join(parts, ".")
+}
+
+func firstValue(m map[string]interface{}, keys ...string) interface{} {
+	for _, k := range keys {
+		if v, ok := m[k]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func firstString(m map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := m[k].(string); ok && v != "" {
+			return v
+		}
 	}
+	return ""
 }
@@ -1,8 +1,14 @@
 package renderscreenshot
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestErrorMessage(t *testing.T) {
@@ -208,3 +214,161 @@ func TestErrorFromResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestHelpersWithWrappedErrors(t *testing.T) {
+	wrap := func(e *Error) error { return fmt.Errorf("capture homepage: %w", e) }
+
+	if !IsNotFound(wrap(&Error{HTTPStatus: 404, Code: CodeNotFound})) {
+		t.Error("IsNotFound should unwrap wrapped errors")
+	}
+	if !IsRetryable(wrap(&Error{Code: CodeTimeout})) {
+		t.Error("IsRetryable should unwrap wrapped errors")
+	}
+	if !IsRateLimited(wrap(&Error{HTTPStatus: 429})) {
+		t.Error("IsRateLimited should unwrap wrapped errors")
+	}
+	if !IsAuthentication(wrap(&Error{HTTPStatus: 401})) {
+		t.Error("IsAuthentication should unwrap wrapped errors")
+	}
+	if !IsValidation(wrap(&Error{HTTPStatus: 400})) {
+		t.Error("IsValidation should unwrap wrapped errors")
+	}
+}
+
+func TestErrorIsSentinel(t *testing.T) {
+	err := fmt.Errorf("batch: %w", &Error{Message: "Too many requests", HTTPStatus: 429, Code: CodeRateLimited})
+
+	if !errors.Is(err, ErrRateLimited) {
+		t.Error("errors.Is(err, ErrRateLimited) = false, want true")
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Error("errors.Is(err, ErrNotFound) = true, want false")
+	}
+	if errors.Is(&Error{Message: "no code"}, &Error{}) {
+		t.Error("errors with empty codes should not match")
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != 429 {
+		t.Errorf("errors.As did not extract *Error: %v", apiErr)
+	}
+}
+
+func TestErrorUnwrapCause(t *testing.T) {
+	cause := context.DeadlineExceeded
+	err := &Error{Message: "Request timed out", Code: CodeTimeout, Cause: cause}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("errors.Is should reach the underlying cause")
+	}
+	if !errors.Is(err, ErrTimeout) {
+		t.Error("errors.Is(err, ErrTimeout) = false, want true")
+	}
+	if (&Error{}).Unwrap() != nil {
+		t.Error("Unwrap() should be nil without a cause")
+	}
+}
+
+func TestConnectionErrorPreservesCause(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	client := newHTTPClient("test_key", serverURL, 10*time.Second, 0, 1.0)
+	_, err := client.get(context.Background(), "/test", nil, nil)
+	if !errors.Is(err, ErrConnection) {
+		t.Fatalf("expected connection error, got %v", err)
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) {
+		t.Errorf("expected underlying net.Error to be preserved, got %T", errors.Unwrap(err))
+	}
+}
+
+func TestErrorFromResponseFieldDetails(t *testing.T) {
+	body := map[string]interface{}{
+		"error": map[string]interface{}{
+			"message":           "Validation failed",
+			"code":              "invalid_request",
+			"documentation_url": "https://docs.renderscreenshot.com/errors/invalid_request",
+			"details": []interface{}{
+				map[string]interface{}{
+					"path":       "viewport.width",
+					"constraint": "max",
+					"message":    "must be at most 3840",
+					"received":   5000.0,
+				},
+				map[string]interface{}{
+					"path":       []interface{}{"output", "format"},
+					"constraint": "enum",
+					"received":   "gif",
+				},
+			},
+		},
+	}
+
+	err := errorFromResponse(422, body, 0, "")
+	if err.DocumentationURL != "https://docs.renderscreenshot.com/errors/invalid_request" {
+		t.Errorf("DocumentationURL = %q", err.DocumentationURL)
+	}
+	if len(err.Details) != 2 {
+		t.Fatalf("expected 2 details, got %d", len(err.Details))
+	}
+
+	first := err.Details[0]
+	if first.Path != "viewport.width" || first.Constraint != "max" || first.Message != "must be at most 3840" {
+		t.Errorf("Details[0] = %+v", first)
+	}
+	if first.Received != 5000.0 {
+		t.Errorf("Details[0].Received = %v, want 5000", first.Received)
+	}
+	if err.Details[1].Path != "output.format" || err.Details[1].Received != "gif" {
+		t.Errorf("Details[1] = %+v", err.Details[1])
+	}
+}
+
+func TestErrorFromResponseProblemDetails(t *testing.T) {
+	body := map[string]interface{}{
+		"type":   "https://docs.renderscreenshot.com/problems/validation",
+		"title":  "Your request parameters didn't validate.",
+		"status": 400.0,
+		"detail": "2 parameters are invalid",
+		"invalid-params": []interface{}{
+			map[string]interface{}{"name": "/wait/timeout", "reason": "must be a positive integer", "value": -1.0},
+			map[string]interface{}{"name": "url", "reason": "is required"},
+		},
+	}
+
+	err := errorFromResponse(400, body, 0, "")
+	if err.Message != "2 parameters are invalid" {
+		t.Errorf("Message = %q", err.Message)
+	}
+	if err.Code != CodeInvalidRequest {
+		t.Errorf("Code = %q, want %q", err.Code, CodeInvalidRequest)
+	}
+	if err.DocumentationURL != "https://docs.renderscreenshot.com/problems/validation" {
+		t.Errorf("DocumentationURL = %q", err.DocumentationURL)
+	}
+	if len(err.Details) != 2 {
+		t.Fatalf("expected 2 details, got %d", len(err.Details))
+	}
+	if err.Details[0].Path != "wait.timeout" || err.Details[0].Message != "must be a positive integer" || err.Details[0].Received != -1.0 {
+		t.Errorf("Details[0] = %+v", err.Details[0])
+	}
+	if err.Details[1].Path != "url" {
+		t.Errorf("Details[1].Path = %q, want url", err.Details[1].Path)
+	}
+}
+
+func TestErrorFromResponseProblemDetailsAboutBlank(t *testing.T) {
+	err := errorFromResponse(404, map[string]interface{}{"type": "about:blank", "title": "Not Found"}, 0, "")
+	if err.Message != "Not Found" {
+		t.Errorf("Message = %q, want Not Found", err.Message)
+	}
+	if err.DocumentationURL != "" {
+		t.Errorf("DocumentationURL = %q, want empty", err.DocumentationURL)
+	}
+	if err.Code != CodeNotFound {
+		t.Errorf("Code = %q, want %q", err.Code, CodeNotFound)
+	}
+}
@@ -0,0 +1,62 @@
+package renderscreenshot
+
+import (
+	"context"
+	"time"
+)
+
+type hedgeResult struct {
+	resp *httpResponse
+	err  error
+}
+
+// takeHedged issues the capture and, if it is still in flight after
+// c.hedgeDelay, a second identical request. The first success wins and the
+// remaining request is cancelled. An error is returned only once every
+// launched request has failed.
+func (c *Client) takeHedged(ctx context.Context, params map[string]interface{}, headers map[string]string) (*httpResponse, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	launch := func() {
+		go func() {
+			resp, err := c.http.postBinary(ctx, "/v1/screenshot", params, headers)
+			results <- hedgeResult{resp: resp, err: err}
+		}()
+	}
+
+	launch()
+	launched, pending := 1, 1
+
+	timer := time.NewTimer(c.hedgeDelay)
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if launched == 1 {
+				launch()
+				launched++
+				pending++
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.resp, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			// The primary failed outright before the hedge was due; its
+			// retries are already exhausted, so don't start another request.
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
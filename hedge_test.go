@@ -0,0 +1,152 @@
+package renderscreenshot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTakeHedgedReturnsFasterResponse(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		keys     []string
+		canceled = make(chan struct{}, 1)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		mu.Lock()
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		n := len(keys)
+		mu.Unlock()
+
+		if n == 1 {
+			// Primary request stalls until the client gives up on it.
+			select {
+			case <-r.Context().Done():
+				canceled <- struct{}{}
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("hedge"))
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithHedging(20*time.Millisecond))
+	start := time.Now()
+	data, err := client.Take(context.Background(), URL("https://example.com"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "hedge" {
+		t.Errorf("data = %q, want hedge", data)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("hedged request took %v", elapsed)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Error("expected primary request to be cancelled")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(keys))
+	}
+	if keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("expected hedged requests to share an idempotency key, got %v", keys)
+	}
+}
+
+func TestTakeHedgedNoHedgeWhenFast(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		_, _ = w.Write([]byte("primary"))
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithHedging(time.Second))
+	data, err := client.Take(context.Background(), URL("https://example.com"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "primary" {
+		t.Errorf("data = %q, want primary", data)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestTakeHedgedBothFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(400)
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithHedging(10*time.Millisecond))
+	_, err := client.Take(context.Background(), URL("https://example.com"))
+	if !IsValidation(err) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestTakeHedgedKeepsEndpointsHealthy(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		canceled = make(chan struct{}, 1)
+	)
+	eu := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+
+		if n == 1 {
+			// The primary stalls until it loses to the hedge.
+			<-r.Context().Done()
+			canceled <- struct{}{}
+			return
+		}
+		_, _ = w.Write([]byte("hedge"))
+	}))
+	defer eu.Close()
+	us := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("us"))
+	}))
+	defer us.Close()
+
+	client, _ := New("rs_live_test", WithEndpoints([]string{eu.URL, us.URL}), WithHedging(20*time.Millisecond))
+	if _, err := client.Take(context.Background(), URL("https://example.com")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected primary request to be cancelled")
+	}
+	// Give the cancelled attempt time to return from the failover loop.
+	time.Sleep(50 * time.Millisecond)
+
+	for _, status := range client.Endpoints() {
+		if !status.Healthy || status.Failures != 0 {
+			t.Errorf("endpoint = %+v, want healthy", status)
+		}
+	}
+}
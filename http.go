@@ -2,12 +2,15 @@ package renderscreenshot
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -29,6 +32,7 @@ type httpClient struct {
 	retryDelay float64
 	client     *http.Client
 	userAgent  string
+	endpoints  *endpointPool
 }
 
 // httpResponse wraps an HTTP response with parsed data.
@@ -59,46 +63,55 @@ func newHTTPClient(apiKey, baseURL string, timeout time.Duration, maxRetries int
 	}
 }
 
-func (c *httpClient) get(path string, params, headers map[string]string) (map[string]interface{}, error) {
-	return c.requestJSON(http.MethodGet, path, params, nil, headers)
+func (c *httpClient) get(ctx context.Context, path string, params, headers map[string]string) (map[string]interface{}, error) {
+	return c.requestJSON(ctx, http.MethodGet, path, params, nil, headers)
 }
 
-func (c *httpClient) getBinary(path string, params, headers map[string]string) (*httpResponse, error) {
-	return c.requestBinary(http.MethodGet, path, params, nil, headers)
+func (c *httpClient) getBinary(ctx context.Context, path string, params, headers map[string]string) (*httpResponse, error) {
+	return c.requestBinary(ctx, http.MethodGet, path, params, nil, headers)
 }
 
-func (c *httpClient) post(path string, body interface{}, headers map[string]string) (map[string]interface{}, error) {
-	return c.requestJSON(http.MethodPost, path, nil, body, headers)
+func (c *httpClient) post(ctx context.Context, path string, body interface{}, headers map[string]string) (map[string]interface{}, error) {
+	return c.requestJSON(ctx, http.MethodPost, path, nil, body, headers)
 }
 
-func (c *httpClient) postBinary(path string, body interface{}, headers map[string]string) (*httpResponse, error) {
-	return c.requestBinary(http.MethodPost, path, nil, body, headers)
+func (c *httpClient) postWithHeaders(ctx context.Context, path string, body interface{}, headers map[string]string) (map[string]interface{}, http.Header, error) {
+	return c.requestJSONWithHeaders(ctx, http.MethodPost, path, nil, body, headers)
 }
 
-func (c *httpClient) delete(path string, params, headers map[string]string) (map[string]interface{}, error) {
-	return c.requestJSON(http.MethodDelete, path, params, nil, headers)
+func (c *httpClient) postBinary(ctx context.Context, path string, body interface{}, headers map[string]string) (*httpResponse, error) {
+	return c.requestBinary(ctx, http.MethodPost, path, nil, body, headers)
 }
 
-func (c *httpClient) requestJSON(method, path string, params map[string]string, body interface{}, headers map[string]string) (map[string]interface{}, error) {
-	respBody, _, err := c.doWithRetry(method, path, params, body, headers)
+func (c *httpClient) delete(ctx context.Context, path string, params, headers map[string]string) (map[string]interface{}, error) {
+	return c.requestJSON(ctx, http.MethodDelete, path, params, nil, headers)
+}
+
+func (c *httpClient) requestJSON(ctx context.Context, method, path string, params map[string]string, body interface{}, headers map[string]string) (map[string]interface{}, error) {
+	result, _, err := c.requestJSONWithHeaders(ctx, method, path, params, body, headers)
+	return result, err
+}
+
+func (c *httpClient) requestJSONWithHeaders(ctx context.Context, method, path string, params map[string]string, body interface{}, headers map[string]string) (map[string]interface{}, http.Header, error) {
+	respBody, respHeaders, err := c.doWithRetry(ctx, method, path, params, body, headers)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if len(respBody) == 0 {
-		return map[string]interface{}{}, nil
+		return map[string]interface{}{}, respHeaders, nil
 	}
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		// If not valid JSON, return raw body as string
-		return map[string]interface{}{"body": string(respBody)}, nil
+		return map[string]interface{}{"body": string(respBody)}, respHeaders, nil
 	}
-	return result, nil
+	return result, respHeaders, nil
 }
 
-func (c *httpClient) requestBinary(method, path string, params map[string]string, body interface{}, headers map[string]string) (*httpResponse, error) {
-	respBody, respHeaders, err := c.doWithRetry(method, path, params, body, headers)
+func (c *httpClient) requestBinary(ctx context.Context, method, path string, params map[string]string, body interface{}, headers map[string]string) (*httpResponse, error) {
+	respBody, respHeaders, err := c.doWithRetry(ctx, method, path, params, body, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -109,52 +122,78 @@ func (c *httpClient) requestBinary(method, path string, params map[string]string
 	}, nil
 }
 
-func (c *httpClient) doWithRetry(method, path string, params map[string]string, body interface{}, headers map[string]string) ([]byte, http.Header, error) {
+func (c *httpClient) doWithRetry(ctx context.Context, method, path string, params map[string]string, body interface{}, headers map[string]string) ([]byte, http.Header, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	var lastErr error
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		respBody, respHeaders, err := c.doRequest(method, path, params, body, headers)
+		respBody, respHeaders, err := c.doRequestFailover(ctx, method, path, params, body, headers)
 		if err == nil {
 			return respBody, respHeaders, nil
 		}
 
 		lastErr = err
-		apiErr, ok := err.(*Error)
-		if !ok || !apiErr.IsRetryable() || attempt >= c.maxRetries {
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || !apiErr.IsRetryable() || attempt >= c.maxRetries || ctx.Err() != nil {
 			return nil, nil, err
 		}
 
 		delay := c.calculateDelay(apiErr, attempt)
-		time.Sleep(time.Duration(delay * float64(time.Second)))
+		timer := time.NewTimer(time.Duration(delay * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, err
+		case <-timer.C:
+		}
 	}
 
 	return nil, nil, lastErr
 }
 
-func (c *httpClient) doRequest(method, path string, params map[string]string, body interface{}, extraHeaders map[string]string) ([]byte, http.Header, error) {
-	reqURL := c.baseURL + path
+// doRequestFailover performs a single attempt, moving on to the next
+// preferred endpoint when the current one is unreachable or returns 5xx.
+func (c *httpClient) doRequestFailover(ctx context.Context, method, path string, params map[string]string, body interface{}, headers map[string]string) ([]byte, http.Header, error) {
+	if c.endpoints == nil || len(c.endpoints.endpoints) == 0 {
+		return c.doRequest(ctx, c.baseURL, method, path, params, body, headers)
+	}
 
-	// Add query params
-	if len(params) > 0 {
-		parts := make([]string, 0, len(params))
-		for k, v := range params {
-			parts = append(parts, fmt.Sprintf("%s=%s", k, v))
+	var lastErr error
+	for _, ep := range c.endpoints.ordered() {
+		start := time.Now()
+		respBody, respHeaders, err := c.doRequest(ctx, ep.baseURL, method, path, params, body, headers)
+		if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled)) {
+			// The caller gave up, e.g. a hedged request that lost or a
+			// timeout; that says nothing about the endpoint's health.
+			return nil, nil, err
 		}
-		reqURL += "?" + strings.Join(parts, "&")
+		c.endpoints.record(ep, time.Since(start), err)
+		if err == nil || !shouldFailover(err) {
+			return respBody, respHeaders, err
+		}
+		lastErr = err
 	}
+	return nil, nil, lastErr
+}
+
+func (c *httpClient) doRequest(ctx context.Context, baseURL, method, path string, params map[string]string, body interface{}, extraHeaders map[string]string) ([]byte, http.Header, error) {
+	reqURL := buildRequestURL(baseURL, path, params)
 
 	var bodyReader io.Reader
 	if body != nil {
 		data, err := json.Marshal(body)
 		if err != nil {
-			return nil, nil, &Error{Message: "failed to marshal request body", Code: CodeInvalidRequest}
+			return nil, nil, &Error{Message: "failed to marshal request body", Code: CodeInvalidRequest, Cause: err}
 		}
 		bodyReader = bytes.NewReader(data)
 	}
 
-	req, err := http.NewRequest(method, reqURL, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bodyReader)
 	if err != nil {
-		return nil, nil, &Error{Message: "failed to create request: " + err.Error(), Code: CodeConnectionError}
+		return nil, nil, &Error{Message: "failed to create request: " + err.Error(), Code: CodeConnectionError, Cause: err}
 	}
 
 	// Set standard headers
@@ -172,15 +211,30 @@ func (c *httpClient) doRequest(method, path string, params map[string]string, bo
 	resp, err := c.client.Do(req)
 	if err != nil {
 		if isTimeoutError(err) {
-			return nil, nil, &Error{Message: "Request timed out", Code: CodeTimeout, HTTPStatus: 408}
+			return nil, nil, &Error{Message: "Request timed out", Code: CodeTimeout, HTTPStatus: 408, Cause: err}
+		}
+		if phase := connectionPhase(err); phase == "proxy authentication" {
+			return nil, nil, &Error{Message: "Failed to connect to server (proxy authentication): " + err.Error(), HTTPStatus: http.StatusProxyAuthRequired, Code: CodeUnauthorized, Cause: err}
+		} else if phase != "" {
+			return nil, nil, &Error{Message: "Failed to connect to server (" + phase + "): " + err.Error(), Code: CodeConnectionError, Cause: err}
 		}
-		return nil, nil, &Error{Message: "Failed to connect to server: " + err.Error(), Code: CodeConnectionError}
+		return nil, nil, &Error{Message: "Failed to connect to server: " + err.Error(), Code: CodeConnectionError, Cause: err}
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	// Retrying or failing over with the same proxy credentials cannot
+	// succeed, so this is an authentication error rather than a connection error.
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return nil, nil, &Error{
+			Message:    "Failed to connect to server (proxy authentication): " + resp.Status,
+			HTTPStatus: resp.StatusCode,
+			Code:       CodeUnauthorized,
+		}
+	}
+
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, &Error{Message: "failed to read response body: " + err.Error(), Code: CodeConnectionError}
+		return nil, nil, &Error{Message: "failed to read response body: " + err.Error(), Code: CodeConnectionError, Cause: err}
 	}
 
 	if resp.StatusCode >= 400 {
@@ -198,6 +252,32 @@ func (c *httpClient) doRequest(method, path string, params map[string]string, bo
 	return respBody, resp.Header, nil
 }
 
+// buildRequestURL joins the base URL and an already-escaped path, and appends
+// params as a properly encoded query string.
+func buildRequestURL(baseURL, path string, params map[string]string) string {
+	reqURL := baseURL + path
+	if len(params) > 0 {
+		values := make(url.Values, len(params))
+		for k, v := range params {
+			values.Set(k, v)
+		}
+		reqURL += "?" + values.Encode()
+	}
+	return reqURL
+}
+
+// pathSegment escapes s for use as a single URL path segment. Dot segments
+// are percent-encoded as well so they cannot be collapsed by path cleaning.
+func pathSegment(s string) string {
+	switch s {
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	return url.PathEscape(s)
+}
+
 func (c *httpClient) calculateDelay(err *Error, attempt int) float64 {
 	// Use retry_after if available (from rate limit responses)
 	if err.RetryAfter > 0 {
@@ -1,9 +1,12 @@
 package renderscreenshot
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -28,7 +31,7 @@ func TestHTTPClientGet(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	result, err := client.get("/test", nil, nil)
+	result, err := client.get(context.Background(), "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -58,7 +61,7 @@ func TestHTTPClientPost(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	result, err := client.post("/screenshot", map[string]interface{}{"url": "https://example.com"}, nil)
+	result, err := client.post(context.Background(), "/screenshot", map[string]interface{}{"url": "https://example.com"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -77,7 +80,7 @@ func TestHTTPClientPostBinary(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	resp, err := client.postBinary("/screenshot", map[string]interface{}{"url": "https://example.com"}, nil)
+	resp, err := client.postBinary(context.Background(), "/screenshot", map[string]interface{}{"url": "https://example.com"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -97,7 +100,7 @@ func TestHTTPClientDelete(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	result, err := client.delete("/cache/key1", nil, nil)
+	result, err := client.delete(context.Background(), "/cache/key1", nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -165,7 +168,7 @@ func TestHTTPClientErrorResponse(t *testing.T) {
 			defer server.Close()
 
 			client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-			_, err := client.get("/test", nil, nil)
+			_, err := client.get(context.Background(), "/test", nil, nil)
 			if err == nil {
 				t.Fatal("expected error")
 			}
@@ -194,7 +197,7 @@ func TestHTTPClientRetryAfterHeader(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	_, err := client.get("/test", nil, nil)
+	_, err := client.get(context.Background(), "/test", nil, nil)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -227,7 +230,7 @@ func TestHTTPClientRetry(t *testing.T) {
 
 	// Use very small retry delay for testing
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 3, 0.01)
-	result, err := client.get("/test", nil, nil)
+	result, err := client.get(context.Background(), "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error after retries: %v", err)
 	}
@@ -255,7 +258,7 @@ func TestHTTPClientNoRetryOnNonRetryable(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 3, 0.01)
-	_, err := client.get("/test", nil, nil)
+	_, err := client.get(context.Background(), "/test", nil, nil)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -275,7 +278,7 @@ func TestHTTPClientExtraHeaders(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	_, err := client.get("/test", nil, map[string]string{"Accept": "application/json"})
+	_, err := client.get(context.Background(), "/test", nil, map[string]string{"Accept": "application/json"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -292,7 +295,7 @@ func TestHTTPClientQueryParams(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	_, err := client.get("/test", map[string]string{"key": "value"}, nil)
+	_, err := client.get(context.Background(), "/test", map[string]string{"key": "value"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -310,7 +313,7 @@ func TestHTTPClientUserAgent(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	_, _ = client.get("/test", nil, nil)
+	_, _ = client.get(context.Background(), "/test", nil, nil)
 }
 
 func TestParseRetryAfter(t *testing.T) {
@@ -362,7 +365,7 @@ func TestHTTPClientEmptyResponse(t *testing.T) {
 	defer server.Close()
 
 	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
-	result, err := client.get("/test", nil, nil)
+	result, err := client.get(context.Background(), "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -370,3 +373,141 @@ func TestHTTPClientEmptyResponse(t *testing.T) {
 		t.Errorf("expected empty result, got %v", result)
 	}
 }
+
+func TestHTTPClientContextCancelStopsRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(503)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := newHTTPClient("test_key", server.URL, 10*time.Second, 5, 1.0)
+	start := time.Now()
+	_, err := client.get(ctx, "/test", nil, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected retries to stop on context cancellation, took %v", elapsed)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestHTTPClientQueryParamsEscaped(t *testing.T) {
+	params := map[string]string{
+		"pattern": "a&b=c d",
+		"unicode": "héllo/世界",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range params {
+			if got := r.URL.Query().Get(k); got != v {
+				t.Errorf("query %s = %q, want %q", k, got, v)
+			}
+		}
+		if len(r.URL.Query()) != len(params) {
+			t.Errorf("expected %d query params, got %v", len(params), r.URL.Query())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{})
+	}))
+	defer server.Close()
+
+	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
+	if _, err := client.get(context.Background(), "/test", params, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPathSegment(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"key123", "key123"},
+		{"a/b", "a%2Fb"},
+		{"a b", "a%20b"},
+		{"a?b#c", "a%3Fb%23c"},
+		{".", "%2E"},
+		{"..", "%2E%2E"},
+	}
+
+	for _, tt := range tests {
+		if got := pathSegment(tt.input); got != tt.want {
+			t.Errorf("pathSegment(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func FuzzRequestURLRoundTrip(f *testing.F) {
+	f.Add("key123", "value")
+	f.Add("a&b=c", "x y")
+	f.Add("../../v1/usage", "%zz")
+	f.Add("héllo/世界?#", "\x00\xff")
+	f.Add("", "")
+
+	f.Fuzz(func(t *testing.T, segment, value string) {
+		reqURL := buildRequestURL("https://api.example.com", "/v1/cache/"+pathSegment(segment), map[string]string{
+			"key":   value,
+			segment: "other",
+		})
+
+		req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+		if err != nil {
+			t.Fatalf("NewRequest(%q): %v", reqURL, err)
+		}
+		if req.URL.Host != "api.example.com" {
+			t.Fatalf("host = %q, want api.example.com", req.URL.Host)
+		}
+
+		escaped := req.URL.EscapedPath()
+		if !strings.HasPrefix(escaped, "/v1/cache/") {
+			t.Fatalf("path = %q, want /v1/cache/ prefix", escaped)
+		}
+		rest := strings.TrimPrefix(escaped, "/v1/cache/")
+		if strings.Contains(rest, "/") {
+			t.Fatalf("segment %q produced multiple path segments %q", segment, rest)
+		}
+		got, err := url.PathUnescape(rest)
+		if err != nil || got != segment {
+			t.Fatalf("segment round trip = %q (%v), want %q", got, err, segment)
+		}
+
+		query := req.URL.Query()
+		if segment != "key" {
+			if query.Get("key") != value {
+				t.Fatalf("query key = %q, want %q", query.Get("key"), value)
+			}
+			if query.Get(segment) != "other" {
+				t.Fatalf("query %q = %q, want other", segment, query.Get(segment))
+			}
+		}
+	})
+}
+
+func TestHTTPClientProblemJSONResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(422)
+		_, _ = w.Write([]byte(`{"title":"Invalid options","detail":"viewport.width is too large","errors":[{"field":"viewport.width","rule":"max","value":9000}]}`))
+	}))
+	defer server.Close()
+
+	client := newHTTPClient("test_key", server.URL, 10*time.Second, 0, 1.0)
+	_, err := client.post(context.Background(), "/v1/screenshot", map[string]interface{}{}, nil)
+	apiErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if apiErr.Message != "viewport.width is too large" {
+		t.Errorf("Message = %q", apiErr.Message)
+	}
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Path != "viewport.width" || apiErr.Details[0].Constraint != "max" {
+		t.Errorf("Details = %+v", apiErr.Details)
+	}
+}
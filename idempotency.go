@@ -0,0 +1,95 @@
+package renderscreenshot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader is the HTTP header carrying the idempotency key of a request.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is the HTTP header set by the API when a response
+	// was replayed from an earlier request with the same idempotency key.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+type idempotencyKeyContextKey struct{}
+
+// ContextWithIdempotencyKey returns a copy of ctx that carries an explicit
+// idempotency key. Take, TakeJSON, Batch and BatchAdvanced use it instead of
+// generating a random key, so a logical operation can be safely resubmitted
+// across process restarts.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// idempotencyKey returns the key carried by ctx, or a new random key.
+// The key is resolved once per logical call so that every retry attempt
+// sends the same value.
+func idempotencyKey(ctx context.Context) string {
+	if ctx != nil {
+		if key, ok := ctx.Value(idempotencyKeyContextKey{}).(string); ok && key != "" {
+			return key
+		}
+	}
+	return newIdempotencyKey()
+}
+
+// randRead is crypto/rand.Read, replaceable in tests.
+var randRead = rand.Read
+
+var keyCounter uint64
+
+// newIdempotencyKey returns 32 random hex digits. If the system's random
+// source fails it falls back to a key built from the time, process ID and
+// a counter, which is still unique, rather than sending no key at all.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := randRead(b); err != nil {
+		return fmt.Sprintf("%016x%08x%08x", uint64(time.Now().UnixNano()), uint32(os.Getpid()), uint32(atomic.AddUint64(&keyCounter, 1)))
+	}
+	return hex.EncodeToString(b)
+}
+
+// withIdempotencyKey returns a copy of headers with the idempotency key set.
+func withIdempotencyKey(headers map[string]string, key string) map[string]string {
+	result := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		result[k] = v
+	}
+	if key != "" {
+		result[IdempotencyKeyHeader] = key
+	}
+	return result
+}
+
+// ResponseMeta contains transport-level metadata about an API response.
+type ResponseMeta struct {
+	// RequestID is the server-assigned request ID, if any.
+	RequestID string
+	// IdempotencyKey is the key sent with the request.
+	IdempotencyKey string
+	// Replayed is true if the server returned a stored response for a
+	// previously seen idempotency key instead of performing the work again.
+	Replayed bool
+}
+
+func newResponseMeta(headers http.Header, key string) ResponseMeta {
+	meta := ResponseMeta{IdempotencyKey: key}
+	if headers == nil {
+		return meta
+	}
+	meta.RequestID = headers.Get("X-Request-Id")
+	meta.Replayed = strings.EqualFold(headers.Get(IdempotentReplayedHeader), "true")
+	if echoed := headers.Get(IdempotencyKeyHeader); echoed != "" {
+		meta.IdempotencyKey = echoed
+	}
+	return meta
+}
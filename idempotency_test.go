@@ -0,0 +1,135 @@
+package renderscreenshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTakeSendsStableIdempotencyKeyAcrossRetries(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte{0x89, 0x50})
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL), WithMaxRetries(3), WithRetryDelay(0.01))
+	if _, err := client.Take(context.Background(), URL("https://example.com")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(keys))
+	}
+	if keys[0] == "" {
+		t.Fatal("expected Idempotency-Key header to be set")
+	}
+	for i, k := range keys {
+		if k != keys[0] {
+			t.Errorf("attempt %d key = %q, want %q", i, k, keys[0])
+		}
+	}
+}
+
+func TestTakeGeneratesNewKeyPerCall(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		_, _ = w.Write([]byte{0x89})
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL))
+	_, _ = client.Take(context.Background(), URL("https://example.com"))
+	_, _ = client.Take(context.Background(), URL("https://example.com"))
+	if len(keys) != 2 || keys[0] == keys[1] {
+		t.Errorf("expected distinct keys per call, got %v", keys)
+	}
+}
+
+func TestBatchIdempotencyKeyOverride(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(IdempotencyKeyHeader); got != "batch-import-42" {
+			t.Errorf("Idempotency-Key = %q, want batch-import-42", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.Header().Set("X-Request-Id", "req_1")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "batch_1", "status": "queued"})
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL))
+	ctx := ContextWithIdempotencyKey(context.Background(), "batch-import-42")
+	resp, err := client.Batch(ctx, []string{"https://example.com"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Meta.Replayed {
+		t.Error("Meta.Replayed = false, want true")
+	}
+	if resp.Meta.IdempotencyKey != "batch-import-42" {
+		t.Errorf("Meta.IdempotencyKey = %q, want batch-import-42", resp.Meta.IdempotencyKey)
+	}
+	if resp.Meta.RequestID != "req_1" {
+		t.Errorf("Meta.RequestID = %q, want req_1", resp.Meta.RequestID)
+	}
+}
+
+func TestTakeJSONResponseMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "req_123"})
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL))
+	resp, err := client.TakeJSON(context.Background(), URL("https://example.com"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Meta.Replayed {
+		t.Error("Meta.Replayed = true, want false")
+	}
+	if resp.Meta.IdempotencyKey == "" {
+		t.Error("expected Meta.IdempotencyKey to be populated")
+	}
+}
+
+func TestBatchAdvancedSendsIdempotencyKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(IdempotencyKeyHeader) == "" {
+			t.Error("expected Idempotency-Key header")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "batch_2"})
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL))
+	_, err := client.BatchAdvanced(context.Background(), []BatchRequest{{URL: "https://example.com"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewIdempotencyKeyWithoutRandomness(t *testing.T) {
+	defer func(orig func([]byte) (int, error)) { randRead = orig }(randRead)
+	randRead = func([]byte) (int, error) { return 0, errors.New("no entropy") }
+
+	a, b := newIdempotencyKey(), newIdempotencyKey()
+	if a == "" || b == "" || a == b {
+		t.Errorf("keys = %q, %q, want two distinct non-empty keys", a, b)
+	}
+	if len(a) != 32 {
+		t.Errorf("len(key) = %d, want 32", len(a))
+	}
+}
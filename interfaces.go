@@ -0,0 +1,49 @@
+package renderscreenshot
+
+import (
+	"context"
+	"time"
+)
+
+// Screenshotter captures single screenshots. *Client implements it.
+type Screenshotter interface {
+	Take(ctx context.Context, options *TakeOptions) ([]byte, error)
+	TakeJSON(ctx context.Context, options *TakeOptions) (*ScreenshotResponse, error)
+}
+
+// Batcher submits and polls batch jobs. *Client implements it.
+type Batcher interface {
+	Batch(ctx context.Context, urls []string, options *TakeOptions) (*BatchResponse, error)
+	BatchAdvanced(ctx context.Context, requests []BatchRequest) (*BatchResponse, error)
+	GetBatch(ctx context.Context, batchID string) (*BatchResponse, error)
+}
+
+// MetadataAPI reads presets, devices and account usage. *Client implements it.
+type MetadataAPI interface {
+	Presets(ctx context.Context) ([]PresetInfo, error)
+	Preset(ctx context.Context, id string) (*PresetInfo, error)
+	Devices(ctx context.Context) ([]DeviceInfo, error)
+	Usage(ctx context.Context) (*UsageInfo, error)
+	UsageForTags(ctx context.Context, tags map[string]string) (*UsageInfo, error)
+	UsageHistory(ctx context.Context, from, to time.Time, granularity UsageGranularity) (*UsageHistory, error)
+	UsageHistoryForTags(ctx context.Context, from, to time.Time, granularity UsageGranularity, tags map[string]string) (*UsageHistory, error)
+}
+
+// CacheAPI manages cached screenshots. *CacheManager, returned by
+// Client.Cache, implements it.
+type CacheAPI interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	List(ctx context.Context, opts CacheListOptions) (*CacheList, error)
+	Delete(ctx context.Context, key string) (bool, error)
+	Purge(ctx context.Context, keys []string) (*PurgeResult, error)
+	PurgeURL(ctx context.Context, pattern string) (*PurgeResult, error)
+	PurgeBefore(ctx context.Context, before time.Time) (*PurgeResult, error)
+	PurgePattern(ctx context.Context, pattern string) (*PurgeResult, error)
+}
+
+var (
+	_ Screenshotter = (*Client)(nil)
+	_ Batcher       = (*Client)(nil)
+	_ MetadataAPI   = (*Client)(nil)
+	_ CacheAPI      = (*CacheManager)(nil)
+)
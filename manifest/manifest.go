@@ -0,0 +1,329 @@
+// Package manifest runs batches described by CSV or JSON-lines manifests
+// and writes a results manifest.
+//
+// Each manifest row is a URL plus optional per-row overrides:
+//
+//	url,preset,width,height,format,quality,device,full_page,output,tag.team
+//	https://example.com,og_card,,,,,,,shots/home.png,web
+//	https://example.com/pricing,,1280,800,jpeg,80,,true,shots/pricing.jpg,sales
+//
+// The same keys are used as JSON-lines fields, with "tags" as an object:
+//
+//	{"url":"https://example.com","preset":"og_card","output":"shots/home.png","tags":{"team":"web"}}
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// Format is a manifest file format.
+type Format string
+
+// Supported manifest formats.
+const (
+	CSV   Format = "csv"
+	JSONL Format = "jsonl"
+)
+
+// FormatFromPath infers the format from a file extension: .csv, or .jsonl,
+// .ndjson and .json for JSON lines.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return CSV, nil
+	case ".jsonl", ".ndjson", ".json":
+		return JSONL, nil
+	}
+	return "", fmt.Errorf("manifest: cannot infer format of %q (use .csv or .jsonl)", path)
+}
+
+// tagPrefix prefixes CSV columns holding attribution tags.
+const tagPrefix = "tag."
+
+// columns lists the recognised row fields, for error messages.
+var columns = []string{"url", "output", "preset", "device", "width", "height", "format", "quality", "full_page"}
+
+// Row is one manifest entry. Zero-valued fields are not sent, so the
+// API (or Runner.Defaults) decides.
+type Row struct {
+	// Line is the 1-based line of the row in the manifest.
+	Line     int
+	URL      string
+	Output   string
+	Preset   string
+	Device   string
+	Width    int
+	Height   int
+	Format   rs.ImageFormat
+	Quality  int
+	FullPage *bool
+	Tags     map[string]string
+}
+
+// TakeOptions returns the row's options. defaults, if non-nil, is applied
+// first so that row values override it.
+func (r Row) TakeOptions(defaults func(*rs.TakeOptions)) *rs.TakeOptions {
+	o := rs.URL(r.URL)
+	if defaults != nil {
+		defaults(o)
+	}
+	if r.Preset != "" {
+		o.Preset(r.Preset)
+	}
+	if r.Device != "" {
+		o.Device(r.Device)
+	}
+	if r.Width > 0 {
+		o.Width(r.Width)
+	}
+	if r.Height > 0 {
+		o.Height(r.Height)
+	}
+	if r.Format != "" {
+		o.Format(r.Format)
+	}
+	if r.Quality > 0 {
+		o.Quality(r.Quality)
+	}
+	if r.FullPage != nil {
+		o.FullPage(*r.FullPage)
+	}
+	if len(r.Tags) > 0 {
+		o.Tags(r.Tags)
+	}
+	return o
+}
+
+// Read parses a manifest. CSV manifests need a header row; lines starting
+// with # are ignored in both formats. Unknown columns or fields are errors,
+// so typos do not silently drop overrides.
+func Read(r io.Reader, format Format) ([]Row, error) {
+	var rows []Row
+	var err error
+	switch format {
+	case CSV:
+		rows, err = readCSV(r)
+	case JSONL:
+		rows, err = readJSONL(r)
+	default:
+		return nil, fmt.Errorf("manifest: unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("manifest: no rows")
+	}
+	return rows, nil
+}
+
+func readCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(skipBOM(r))
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var rows []Row
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("manifest: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		cells := make(map[string]string, len(header))
+		for i, name := range header {
+			cells[name] = strings.TrimSpace(record[i])
+		}
+		row, err := rowFromCells(line, cells)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readJSONL(r io.Reader) ([]Row, error) {
+	var rows []Row
+	scanner := bufio.NewScanner(skipBOM(r))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		var fields map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(text))
+		dec.UseNumber()
+		if err := dec.Decode(&fields); err != nil {
+			return nil, fmt.Errorf("manifest: line %d: %w", line, err)
+		}
+		cells := map[string]string{}
+		for k, v := range fields {
+			k = strings.ToLower(k)
+			if k == "tags" {
+				tags, ok := v.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("manifest: line %d: tags must be an object", line)
+				}
+				for tk, tv := range tags {
+					cells[tagPrefix+tk] = fmt.Sprint(tv)
+				}
+				continue
+			}
+			if v != nil {
+				cells[k] = fmt.Sprint(v)
+			}
+		}
+		row, err := rowFromCells(line, cells)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	return rows, nil
+}
+
+func rowFromCells(line int, cells map[string]string) (Row, error) {
+	row := Row{Line: line}
+	fail := func(format string, args ...interface{}) (Row, error) {
+		return Row{}, fmt.Errorf("manifest: line %d: %s", line, fmt.Sprintf(format, args...))
+	}
+
+	for name, value := range cells {
+		if value == "" {
+			continue
+		}
+		var err error
+		switch {
+		case name == "url":
+			row.URL = value
+		case name == "output":
+			row.Output = value
+		case name == "preset":
+			row.Preset = value
+		case name == "device":
+			row.Device = value
+		case name == "width":
+			row.Width, err = strconv.Atoi(value)
+		case name == "height":
+			row.Height, err = strconv.Atoi(value)
+		case name == "format":
+			row.Format = rs.ImageFormat(strings.ToLower(value))
+		case name == "quality":
+			row.Quality, err = strconv.Atoi(value)
+		case name == "full_page":
+			var b bool
+			b, err = strconv.ParseBool(value)
+			row.FullPage = &b
+		case strings.HasPrefix(name, tagPrefix) && len(name) > len(tagPrefix):
+			if row.Tags == nil {
+				row.Tags = map[string]string{}
+			}
+			row.Tags[name[len(tagPrefix):]] = value
+		default:
+			return fail("unknown column %q (known: %s, %s<key>)", name, strings.Join(columns, ", "), tagPrefix)
+		}
+		if err != nil {
+			return fail("invalid %s %q", name, value)
+		}
+	}
+	if row.URL == "" {
+		return fail("missing url")
+	}
+	return row, nil
+}
+
+// Result is the outcome of one manifest row.
+type Result struct {
+	Line     int    `json:"line"`
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+	Output   string `json:"output,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Result statuses. Rows that were never reported by the API keep the
+// batch item status (e.g. "pending").
+const (
+	StatusCompleted      = "completed"
+	StatusFailed         = "failed"
+	StatusDownloadFailed = "download_failed"
+)
+
+// resultColumns is the CSV header written by WriteResults.
+var resultColumns = []string{"line", "url", "status", "output", "image_url", "error"}
+
+// WriteResults writes results as a CSV (with header) or JSON-lines manifest.
+func WriteResults(w io.Writer, format Format, results []Result) error {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(resultColumns); err != nil {
+			return err
+		}
+		for _, r := range results {
+			if err := cw.Write([]string{strconv.Itoa(r.Line), r.URL, r.Status, r.Output, r.ImageURL, r.Error}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case JSONL:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, r := range results {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("manifest: unsupported format %q", format)
+}
+
+// Summary counts results by status, for reporting.
+func Summary(results []Result) map[string]int {
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	return counts
+}
+
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && bytes.Equal(b, []byte{0xEF, 0xBB, 0xBF}) {
+		_, _ = br.Discard(3)
+	}
+	return br
+}
@@ -0,0 +1,161 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := "\ufeffurl, Width,height,format,full_page,output,tag.team\n" +
+		"# comment\n" +
+		"https://example.com,1280,800,JPEG,true,home.jpg,web\n" +
+		"https://example.com/about,,,,,,\n"
+	rows, err := Read(strings.NewReader(input), CSV)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, want 2", len(rows))
+	}
+	r := rows[0]
+	if r.Line != 3 || r.URL != "https://example.com" || r.Width != 1280 || r.Height != 800 ||
+		r.Format != rs.FormatJPEG || r.FullPage == nil || !*r.FullPage || r.Output != "home.jpg" || r.Tags["team"] != "web" {
+		t.Errorf("rows[0] = %+v", r)
+	}
+	if r := rows[1]; r.Line != 4 || r.Width != 0 || r.FullPage != nil || r.Tags != nil {
+		t.Errorf("rows[1] = %+v, want only a URL", r)
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"url":"https://example.com","width":1280,"quality":"80","full_page":false,"tags":{"team":"web"}}
+
+# comment
+{"url":"https://example.com/about","preset":"og_card","output":null}
+`
+	rows, err := Read(strings.NewReader(input), JSONL)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, want 2", len(rows))
+	}
+	if r := rows[0]; r.Line != 1 || r.Width != 1280 || r.Quality != 80 || r.FullPage == nil || *r.FullPage || r.Tags["team"] != "web" {
+		t.Errorf("rows[0] = %+v", r)
+	}
+	if r := rows[1]; r.Line != 4 || r.Preset != "og_card" || r.Output != "" {
+		t.Errorf("rows[1] = %+v", r)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		want   string
+	}{
+		{"unknown column", CSV, "url,widht\nhttps://example.com,10\n", `line 2: unknown column "widht"`},
+		{"bad width", CSV, "url,width\nhttps://example.com,wide\n", `line 2: invalid width "wide"`},
+		{"missing url", CSV, "url,width\n,10\n", "line 2: missing url"},
+		{"ragged", CSV, "url,width\nhttps://example.com\n", "wrong number of fields"},
+		{"empty", CSV, "url\n", "no rows"},
+		{"bad json", JSONL, "{\"url\":\n", "line 1"},
+		{"bad tags", JSONL, `{"url":"https://example.com","tags":"x"}`, "tags must be an object"},
+		{"format", Format("xml"), "", "unsupported format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input), tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Read() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]Format{"rows.csv": CSV, "rows.JSONL": JSONL, "rows.ndjson": JSONL}
+	for path, want := range tests {
+		if got, err := FormatFromPath(path); err != nil || got != want {
+			t.Errorf("FormatFromPath(%q) = %q, %v, want %q", path, got, err, want)
+		}
+	}
+	if _, err := FormatFromPath("rows.txt"); err == nil {
+		t.Error("FormatFromPath(rows.txt) error = nil, want error")
+	}
+}
+
+func TestRowTakeOptionsOverridesDefaults(t *testing.T) {
+	full := true
+	row := Row{URL: "https://example.com", Width: 800, Format: rs.FormatWebP, FullPage: &full}
+	params := row.TakeOptions(func(o *rs.TakeOptions) {
+		o.Width(1200).Height(630).Format(rs.FormatPNG).BlockAds()
+	}).ToParams()
+
+	viewport := params["viewport"].(map[string]interface{})
+	if viewport["width"] != 800 || viewport["height"] != 630 {
+		t.Errorf("viewport = %v, want width 800 from row and height 630 from defaults", viewport)
+	}
+	output := params["output"].(map[string]interface{})
+	if output["format"] != "webp" {
+		t.Errorf("output = %v, want webp", output)
+	}
+	if params["url"] != "https://example.com" {
+		t.Errorf("url = %v", params["url"])
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+	results := []Result{
+		{Line: 2, URL: "https://example.com", Status: StatusCompleted, Output: "a.png", ImageURL: "https://cdn/a.png"},
+		{Line: 3, URL: "https://broken.com", Status: StatusFailed, Error: "Navigation timeout, retried"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, CSV, results); err != nil {
+		t.Fatal(err)
+	}
+	want := "line,url,status,output,image_url,error\n" +
+		"2,https://example.com,completed,a.png,https://cdn/a.png,\n" +
+		"3,https://broken.com,failed,,,\"Navigation timeout, retried\"\n"
+	if buf.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteResults(&buf, JSONL, results); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("JSONL lines = %d, want 2", len(lines))
+	}
+	var got Result
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil || got != results[1] {
+		t.Errorf("JSONL line 2 = %+v, %v, want %+v", got, err, results[1])
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		index       int
+		url         string
+		contentType string
+		want        string
+	}{
+		{0, "https://Example.com/About/", "image/png", "001-example-com-about.png"},
+		{9, "https://example.com", "image/jpeg; charset=binary", "010-example-com.jpg"},
+		{1, "not a url", "application/octet-stream", "002-not-a-url.bin"},
+		{2, "", "application/pdf", "003-capture.pdf"},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.index, tt.url, tt.contentType); got != tt.want {
+			t.Errorf("FileName(%d, %q, %q) = %q, want %q", tt.index, tt.url, tt.contentType, got, tt.want)
+		}
+	}
+}
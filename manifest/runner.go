@@ -0,0 +1,227 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// DefaultPollInterval is the default interval between batch status polls.
+const DefaultPollInterval = 2 * time.Second
+
+// Progress reports the state of a running manifest.
+type Progress struct {
+	BatchID    string
+	Total      int
+	Completed  int
+	Failed     int
+	Downloaded int
+}
+
+// Runner submits manifest rows as one advanced batch, waits for it and
+// downloads captures for rows with an Output path.
+type Runner struct {
+	// Client submits and polls the batch.
+	Client rs.Batcher
+	// Defaults, if set, is applied to every row's options before the row's
+	// own overrides.
+	Defaults func(*rs.TakeOptions)
+	// OutputDir is the directory Output paths are resolved against. When it
+	// is set, every Output must be a relative path inside it; absolute
+	// paths and paths that climb out with ".." are rejected. When it is
+	// empty, Output paths are used as given.
+	OutputDir string
+	// Fetch downloads a completed capture. Defaults to an anonymous HTTP GET.
+	Fetch func(ctx context.Context, imageURL string) ([]byte, error)
+	// PollInterval is the batch polling interval. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+	// OnProgress, if set, is called after every poll and download.
+	OnProgress func(Progress)
+}
+
+// Run executes rows and returns one result per row, in manifest order.
+// Per-row failures are reported in the results; the error is non-nil only
+// if a row's Output is outside OutputDir, in which case nothing is
+// submitted, or if the batch could not be submitted or polled.
+func (r *Runner) Run(ctx context.Context, rows []Row) ([]Result, error) {
+	requests := make([]rs.BatchRequest, len(rows))
+	for i, row := range rows {
+		if r.OutputDir != "" && row.Output != "" && !filepath.IsLocal(row.Output) {
+			return nil, fmt.Errorf("manifest: line %d: output %q is outside the output directory", row.Line, row.Output)
+		}
+		requests[i] = rs.BatchRequest{URL: row.URL, Options: row.TakeOptions(r.Defaults)}
+	}
+
+	batch, err := r.Client.BatchAdvanced(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+	progress := Progress{BatchID: batch.ID}
+	report := func(b *rs.BatchResponse) {
+		progress.Total, progress.Completed, progress.Failed = b.Total, b.Completed, b.Failed
+		if r.OnProgress != nil {
+			r.OnProgress(progress)
+		}
+	}
+	report(batch)
+
+	interval := r.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	for !batchDone(batch) {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		if batch, err = r.Client.GetBatch(ctx, batch.ID); err != nil {
+			return nil, err
+		}
+		report(batch)
+	}
+
+	results := matchResults(rows, batch.Results)
+	for i, row := range rows {
+		res := &results[i]
+		if res.Status != StatusCompleted || row.Output == "" {
+			continue
+		}
+		res.Output = row.Output
+		if r.OutputDir != "" {
+			res.Output = filepath.Join(r.OutputDir, res.Output)
+		}
+		if err := r.download(ctx, res.ImageURL, res.Output); err != nil {
+			res.Status = StatusDownloadFailed
+			res.Error = err.Error()
+			continue
+		}
+		progress.Downloaded++
+		if r.OnProgress != nil {
+			r.OnProgress(progress)
+		}
+	}
+	return results, nil
+}
+
+// batchDone reports whether b has finished. A batch whose items have not
+// been counted yet has a zero Total and is still running.
+func batchDone(b *rs.BatchResponse) bool {
+	return b.Status == StatusCompleted || b.Status == StatusFailed || b.Total > 0 && b.Completed+b.Failed >= b.Total
+}
+
+// matchResults pairs batch results with rows. Results are expected in
+// request order; if the API returned a different number, they are matched
+// by URL instead.
+func matchResults(rows []Row, batchResults []rs.BatchResult) []Result {
+	results := make([]Result, len(rows))
+	used := make([]bool, len(batchResults))
+	for i, row := range rows {
+		results[i] = Result{Line: row.Line, URL: row.URL, Status: "missing", Error: "no result returned for row"}
+
+		j := -1
+		if len(batchResults) == len(rows) {
+			j = i
+		} else {
+			for k, br := range batchResults {
+				if !used[k] && br.URL == row.URL {
+					j = k
+					break
+				}
+			}
+		}
+		if j < 0 {
+			continue
+		}
+		used[j] = true
+		br := batchResults[j]
+		results[i].Status = br.Status
+		results[i].ImageURL = br.ImageURL
+		results[i].Error = br.Error
+	}
+	return results
+}
+
+func (r *Runner) download(ctx context.Context, imageURL, path string) error {
+	if imageURL == "" {
+		return fmt.Errorf("no image URL")
+	}
+	fetch := r.Fetch
+	if fetch == nil {
+		fetch = httpFetch
+	}
+	data, err := fetch(ctx, imageURL)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func httpFetch(ctx context.Context, imageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", imageURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+var extensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// FileName returns a file name for the index-th (0-based) capture of
+// pageURL, such as "003-example-com-about.png". The extension comes from
+// contentType, falling back to ".bin".
+func FileName(index int, pageURL, contentType string) string {
+	ext := ".bin"
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if e, ok := extensions[mediaType]; ok {
+			ext = e
+		}
+	}
+	return fmt.Sprintf("%03d-%s%s", index+1, slug(pageURL), ext)
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug turns a page URL into a short file-name-safe string.
+func slug(pageURL string) string {
+	s := pageURL
+	if u, err := url.Parse(pageURL); err == nil && u.Host != "" {
+		s = u.Host + u.Path
+	}
+	s = strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(s) > 60 {
+		s = strings.TrimRight(s[:60], "-")
+	}
+	if s == "" {
+		s = "capture"
+	}
+	return s
+}
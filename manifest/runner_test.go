@@ -0,0 +1,172 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/rsmock"
+	"github.com/Render-Screenshot/rs-go/rstest"
+)
+
+func TestRunnerRun(t *testing.T) {
+	srv := rstest.NewServer()
+	defer srv.Close()
+	srv.FailURL("https://broken.example.com", "Navigation timeout")
+	client := srv.Client()
+	dir := t.TempDir()
+
+	rows, err := Read(strings.NewReader(
+		"url,width,height,format,output\n"+
+			"https://example.com,100,50,jpeg,shots/home.jpg\n"+
+			"https://broken.example.com,,,,shots/broken.png\n"+
+			"https://example.com/about,,,,\n"), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []Progress
+	runner := &Runner{
+		Client:       client,
+		Defaults:     func(o *rs.TakeOptions) { o.Width(64).Height(64) },
+		OutputDir:    dir,
+		PollInterval: time.Millisecond,
+		Fetch: func(ctx context.Context, imageURL string) ([]byte, error) {
+			return client.Cache().Get(ctx, imageURL[strings.LastIndex(imageURL, "/")+1:])
+		},
+		OnProgress: func(p Progress) { progress = append(progress, p) },
+	}
+	results, err := runner.Run(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, want 3", len(results))
+	}
+	home := filepath.Join(dir, "shots", "home.jpg")
+	if r := results[0]; r.Line != 2 || r.Status != StatusCompleted || r.Output != home || r.ImageURL == "" {
+		t.Errorf("results[0] = %+v", r)
+	}
+	if r := results[1]; r.Status != StatusFailed || r.Error != "Navigation timeout" || r.Output != "" {
+		t.Errorf("results[1] = %+v", r)
+	}
+	if r := results[2]; r.Status != StatusCompleted || r.Output != "" {
+		t.Errorf("results[2] = %+v, want completed without download", r)
+	}
+
+	data, err := os.ReadFile(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte{0xff, 0xd8}) {
+		t.Error("downloaded file is not a JPEG")
+	}
+
+	last := progress[len(progress)-1]
+	if last.Total != 3 || last.Completed != 2 || last.Failed != 1 || last.Downloaded != 1 {
+		t.Errorf("last progress = %+v", last)
+	}
+
+	// Row overrides win over defaults.
+	body := string(srv.Requests()[0].Body)
+	if !strings.Contains(body, `"width":100`) || !strings.Contains(body, `"width":64`) {
+		t.Errorf("batch body = %s, want row width 100 and default width 64", body)
+	}
+}
+
+func TestRunnerDownloadFailure(t *testing.T) {
+	m := &rsmock.Client{
+		BatchAdvancedFunc: func(ctx context.Context, reqs []rs.BatchRequest) (*rs.BatchResponse, error) {
+			return &rs.BatchResponse{ID: "b1", Status: "completed", Total: 1, Completed: 1, Results: []rs.BatchResult{
+				{URL: reqs[0].URL, Status: "completed", ImageURL: "https://cdn.example.com/a.png"},
+			}}, nil
+		},
+	}
+	runner := &Runner{
+		Client:    m,
+		OutputDir: t.TempDir(),
+		Fetch: func(context.Context, string) ([]byte, error) {
+			return nil, os.ErrPermission
+		},
+	}
+	results, err := runner.Run(context.Background(), []Row{{Line: 2, URL: "https://example.com", Output: "a.png"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != StatusDownloadFailed || results[0].Error == "" {
+		t.Errorf("result = %+v, want download_failed", results[0])
+	}
+	if got := Summary(results); got[StatusDownloadFailed] != 1 {
+		t.Errorf("Summary() = %v", got)
+	}
+}
+
+func TestRunnerWaitsForUncountedBatch(t *testing.T) {
+	polls := 0
+	m := &rsmock.Client{
+		BatchAdvancedFunc: func(context.Context, []rs.BatchRequest) (*rs.BatchResponse, error) {
+			return &rs.BatchResponse{ID: "b1", Status: "processing"}, nil
+		},
+		GetBatchFunc: func(context.Context, string) (*rs.BatchResponse, error) {
+			polls++
+			return &rs.BatchResponse{ID: "b1", Status: "completed", Total: 1, Completed: 1, Results: []rs.BatchResult{
+				{URL: "https://example.com", Status: "completed"},
+			}}, nil
+		},
+	}
+	runner := &Runner{Client: m, PollInterval: time.Millisecond}
+	results, err := runner.Run(context.Background(), []Row{{Line: 2, URL: "https://example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polls != 1 || results[0].Status != StatusCompleted {
+		t.Errorf("polls = %d, result = %+v, want the batch polled until completed", polls, results[0])
+	}
+}
+
+func TestMatchResultsByURL(t *testing.T) {
+	rows := []Row{{Line: 2, URL: "https://a.com"}, {Line: 3, URL: "https://b.com"}, {Line: 4, URL: "https://a.com"}}
+	results := matchResults(rows, []rs.BatchResult{
+		{URL: "https://a.com", Status: "completed"},
+		{URL: "https://a.com", Status: "failed"},
+	})
+	if results[0].Status != "completed" || results[2].Status != "failed" {
+		t.Errorf("duplicate URLs matched as %q, %q, want completed, failed", results[0].Status, results[2].Status)
+	}
+	if results[1].Status != "missing" {
+		t.Errorf("unmatched row status = %q, want missing", results[1].Status)
+	}
+}
+
+func TestRunnerBatchError(t *testing.T) {
+	m := &rsmock.Client{}
+	if _, err := (&Runner{Client: m}).Run(context.Background(), []Row{{URL: "https://example.com"}}); err == nil {
+		t.Error("Run() error = nil, want error from BatchAdvanced")
+	}
+}
+
+func TestRunnerRejectsOutputOutsideDir(t *testing.T) {
+	submitted := false
+	m := &rsmock.Client{BatchAdvancedFunc: func(context.Context, []rs.BatchRequest) (*rs.BatchResponse, error) {
+		submitted = true
+		return &rs.BatchResponse{}, nil
+	}}
+	outside := filepath.Join(t.TempDir(), "x.png")
+	for _, output := range []string{"../x.png", "shots/../../x.png", outside} {
+		r := &Runner{Client: m, OutputDir: t.TempDir()}
+		rows := []Row{{Line: 2, URL: "https://example.com", Output: "ok.png"}, {Line: 3, URL: "https://example.org", Output: output}}
+		_, err := r.Run(context.Background(), rows)
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("Output %q: error = %v, want line 3 rejected", output, err)
+		}
+	}
+	if submitted {
+		t.Error("batch submitted despite an invalid output path")
+	}
+}
@@ -0,0 +1,266 @@
+// Package monitor captures pages on a schedule and reports visual changes.
+//
+//	m := &monitor.Monitor{
+//		Client:  client,
+//		Storage: storage, // monitor.NewFileStorage("monitor-data")
+//		Jobs: []monitor.Job{{
+//			ID:       "acme-pricing",
+//			Options:  rs.URL("https://acme.example/pricing").Element("#plans"),
+//			Schedule: monitor.MustParseSchedule("0 */6 * * *"),
+//		}},
+//		OnChange: func(ctx context.Context, c monitor.Change) {
+//			log.Printf("%s changed (distance %d)", c.Job.ID, c.Distance)
+//		},
+//	}
+//	err := m.Run(ctx)
+//
+// Every capture is stored with its perceptual hash. A capture whose hash is
+// more than the job's Threshold bits away from the job's reference hash is
+// a change: a signed monitor.changed webhook is sent if WebhookURL is set,
+// and once it is delivered OnChange is called and the capture becomes the
+// new reference.
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// EventChanged is the event type of change webhooks.
+const EventChanged = "monitor.changed"
+
+// Job is a page captured on a schedule.
+type Job struct {
+	// ID names the job in storage; letters, digits, '.', '_' and '-'.
+	ID string
+	// Options describes the capture. Use a PNG or JPEG format.
+	Options *rs.TakeOptions
+	// Schedule decides when Run captures the page.
+	Schedule Schedule
+	// Threshold is the hash distance (out of 64 bits) a capture must
+	// exceed to count as a change. Zero reports any change of the hash,
+	// which rendering noise rarely moves. A small change moves a full-page
+	// hash by only a few bits, so capture just the watched part of the page
+	// with TakeOptions.Element where you can.
+	Threshold int
+}
+
+// Change describes a detected change.
+type Change struct {
+	Job Job
+	// Previous is the job's capture before Current.
+	Previous *Capture
+	Current  *Capture
+	// Distance is the hash distance from the job's reference capture.
+	Distance int
+}
+
+// Monitor runs jobs and records their captures in Storage.
+type Monitor struct {
+	// Client takes the captures.
+	Client rs.Screenshotter
+	// Storage keeps the capture history.
+	Storage Storage
+	// Jobs are the jobs started by Run.
+	Jobs []Job
+	// OnCapture, if set, is called after each capture is stored.
+	OnCapture func(ctx context.Context, c *Capture)
+	// OnChange, if set, is called for each change. It is not called while
+	// the change webhook fails, but once it is delivered on a later run.
+	// Callbacks of different jobs may run concurrently.
+	OnChange func(ctx context.Context, c Change)
+	// OnError, if set, is called when a scheduled run fails.
+	OnError func(job Job, err error)
+	// WebhookURL, if set, receives a monitor.changed delivery per change,
+	// signed and retried by Webhook.
+	WebhookURL string
+	Webhook    *rs.WebhookSender
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Run runs every job on its schedule until ctx is done, then returns
+// ctx.Err(). Failed runs are reported to OnError and retried at the next
+// scheduled time.
+func (m *Monitor) Run(ctx context.Context) error {
+	seen := map[string]bool{}
+	for _, job := range m.Jobs {
+		if err := m.validate(job); err != nil {
+			return err
+		}
+		if job.Schedule == nil {
+			return fmt.Errorf("monitor: job %s has no schedule", job.ID)
+		}
+		if d, ok := job.Schedule.(every); ok && d <= 0 {
+			return fmt.Errorf("monitor: job %s: interval %s is not positive", job.ID, time.Duration(d))
+		}
+		if seen[job.ID] {
+			return fmt.Errorf("monitor: duplicate job ID %s", job.ID)
+		}
+		seen[job.ID] = true
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range m.Jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			m.loop(ctx, job)
+		}(job)
+	}
+	<-ctx.Done()
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (m *Monitor) loop(ctx context.Context, job Job) {
+	for {
+		next := job.Schedule.Next(m.now())
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(next.Sub(m.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if _, err := m.Check(ctx, job); err != nil && ctx.Err() == nil && m.OnError != nil {
+			m.OnError(job, err)
+		}
+	}
+}
+
+// Check captures job now, stores the capture and reports a change if there
+// is one. If the change webhook fails, the capture is stored and returned
+// with the error, but does not become the reference, so the change is
+// reported again by the next run.
+func (m *Monitor) Check(ctx context.Context, job Job) (*Capture, error) {
+	if err := m.validate(job); err != nil {
+		return nil, err
+	}
+	data, err := m.Client.Take(ctx, job.Options)
+	if err != nil {
+		return nil, fmt.Errorf("monitor: %s: %w", job.ID, err)
+	}
+	hash, err := HashImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("monitor: %s: %w", job.ID, err)
+	}
+	prev, err := m.Storage.Latest(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("monitor: %s: %w", job.ID, err)
+	}
+
+	now := m.now().UTC()
+	cur := &Capture{
+		ID:          now.Format("20060102T150405.000000000Z"),
+		JobID:       job.ID,
+		TakenAt:     now,
+		ContentType: http.DetectContentType(data),
+		Size:        len(data),
+		Hash:        hash,
+		Reference:   hash,
+	}
+	if u, ok := job.Options.ToParams()["url"].(string); ok {
+		cur.URL = u
+	}
+	if prev != nil {
+		cur.Distance = hash.Distance(prev.Reference)
+		cur.Changed = cur.Distance > job.Threshold
+		if !cur.Changed {
+			cur.Reference = prev.Reference
+		}
+	}
+
+	// The webhook is sent before the capture is stored: if it fails, the
+	// reference is not moved, so the next run reports the change again.
+	change := Change{Job: job, Previous: prev, Current: cur, Distance: cur.Distance}
+	var notifyErr error
+	if cur.Changed && m.WebhookURL != "" {
+		if notifyErr = m.notify(ctx, change); notifyErr != nil {
+			cur.Reference = prev.Reference
+		}
+	}
+
+	if err := m.Storage.Save(ctx, cur, data); err != nil {
+		return nil, fmt.Errorf("monitor: %s: %w", job.ID, err)
+	}
+	if m.OnCapture != nil {
+		m.OnCapture(ctx, cur)
+	}
+	if cur.Changed && notifyErr == nil && m.OnChange != nil {
+		m.OnChange(ctx, change)
+	}
+	return cur, notifyErr
+}
+
+func (m *Monitor) validate(job Job) error {
+	if err := checkID("job", job.ID); err != nil {
+		return err
+	}
+	if job.Options == nil {
+		return fmt.Errorf("monitor: job %s has no options", job.ID)
+	}
+	if m.WebhookURL != "" && m.Webhook == nil {
+		return errors.New("monitor: WebhookURL is set but Webhook is nil")
+	}
+	return nil
+}
+
+// webhookPayload is the body of a monitor.changed delivery, shaped like
+// the service's own webhook events.
+type webhookPayload struct {
+	Event     string      `json:"event"`
+	ID        string      `json:"id"`
+	Timestamp int64       `json:"timestamp"`
+	Data      webhookData `json:"data"`
+}
+
+type webhookData struct {
+	JobID     string   `json:"job_id"`
+	URL       string   `json:"url,omitempty"`
+	Distance  int      `json:"distance"`
+	Threshold int      `json:"threshold"`
+	Previous  *Capture `json:"previous,omitempty"`
+	Current   *Capture `json:"current"`
+}
+
+func (m *Monitor) notify(ctx context.Context, c Change) error {
+	id := "mon_" + c.Job.ID + "_" + c.Current.ID
+	payload, err := json.Marshal(webhookPayload{
+		Event:     EventChanged,
+		ID:        id,
+		Timestamp: c.Current.TakenAt.Unix(),
+		Data: webhookData{
+			JobID:     c.Job.ID,
+			URL:       c.Current.URL,
+			Distance:  c.Distance,
+			Threshold: c.Job.Threshold,
+			Previous:  c.Previous,
+			Current:   c.Current,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := m.Webhook.SendPayload(ctx, m.WebhookURL, id, payload); err != nil {
+		return fmt.Errorf("monitor: %s: %w", c.Job.ID, err)
+	}
+	return nil
+}
+
+func (m *Monitor) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
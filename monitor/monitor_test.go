@@ -0,0 +1,321 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/rsmock"
+)
+
+// pages returns a client whose captures are the given images in turn; the
+// last one repeats.
+func pages(t *testing.T, imgs ...image.Image) *rsmock.Client {
+	var mu sync.Mutex
+	i := 0
+	return &rsmock.Client{
+		TakeFunc: func(context.Context, *rs.TakeOptions) ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			img := imgs[i]
+			if i < len(imgs)-1 {
+				i++
+			}
+			return encode(t, img), nil
+		},
+	}
+}
+
+// clock returns a Now func that advances a minute per call.
+func clock() func() time.Time {
+	var mu sync.Mutex
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(time.Minute)
+		return now
+	}
+}
+
+func TestCheckDetectsChanges(t *testing.T) {
+	base := pricingPage(0, color.Black, false)
+	shifted := pricingPage(1, color.Black, false)
+	banner := pricingPage(0, color.Black, true)
+
+	var changes []Change
+	var captures int
+	m := &Monitor{
+		Client:    pages(t, base, shifted, banner, banner),
+		Storage:   NewMemoryStorage(),
+		OnCapture: func(context.Context, *Capture) { captures++ },
+		OnChange:  func(_ context.Context, c Change) { changes = append(changes, c) },
+		Now:       clock(),
+	}
+	job := Job{ID: "acme-pricing", Options: rs.URL("https://acme.example/pricing")}
+	ctx := context.Background()
+
+	var got []*Capture
+	for i := 0; i < 4; i++ {
+		c, err := m.Check(ctx, job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, c)
+	}
+	for i, want := range []bool{false, false, true, false} {
+		if got[i].Changed != want {
+			t.Errorf("capture %d: Changed = %v, want %v (distance %d)", i, got[i].Changed, want, got[i].Distance)
+		}
+	}
+	if captures != 4 {
+		t.Errorf("OnCapture called %d times, want 4", captures)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("OnChange called %d times, want 1", len(changes))
+	}
+	c := changes[0]
+	if c.Previous.ID != got[1].ID || c.Current.ID != got[2].ID || c.Distance == 0 {
+		t.Errorf("change = %+v", c)
+	}
+	if got[0].URL != "https://acme.example/pricing" || got[0].ContentType != "image/png" {
+		t.Errorf("capture = %+v", got[0])
+	}
+	// Unchanged captures keep the first capture as their reference; the
+	// change becomes the reference for the captures after it.
+	if got[1].Reference != got[0].Hash || got[3].Reference != got[2].Hash {
+		t.Errorf("references = %v, %v", got[1].Reference, got[3].Reference)
+	}
+
+	history, err := m.Storage.History(ctx, job.ID, 0)
+	if err != nil || len(history) != 4 {
+		t.Fatalf("History() = %d captures, %v", len(history), err)
+	}
+	if _, err := m.Storage.Image(ctx, job.ID, got[2].ID); err != nil {
+		t.Errorf("Image() error = %v", err)
+	}
+}
+
+func TestCheckThreshold(t *testing.T) {
+	m := &Monitor{
+		Client:  pages(t, pricingPage(0, color.Black, false), pricingPage(0, color.Black, true)),
+		Storage: NewMemoryStorage(),
+		Now:     clock(),
+	}
+	job := Job{ID: "pricing", Options: rs.URL("https://acme.example"), Threshold: 63}
+	for i := 0; i < 2; i++ {
+		c, err := m.Check(context.Background(), job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Changed {
+			t.Errorf("capture %d changed below the threshold (distance %d)", i, c.Distance)
+		}
+	}
+}
+
+func TestCheckSendsWebhook(t *testing.T) {
+	const secret = "whsec_test"
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		if !rs.VerifyWebhook(string(body), r.Header.Get(rs.SignatureHeader), r.Header.Get(rs.TimestampHeader), secret, rs.DefaultTolerance) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	m := &Monitor{
+		Client:     pages(t, pricingPage(0, color.Black, false), pricingPage(0, color.Black, true)),
+		Storage:    NewMemoryStorage(),
+		WebhookURL: server.URL,
+		Webhook:    rs.NewWebhookSender(secret),
+		Now:        clock(),
+	}
+	job := Job{ID: "pricing", Options: rs.URL("https://acme.example/pricing")}
+	for i := 0; i < 2; i++ {
+		if _, err := m.Check(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var payload struct {
+		Event string `json:"event"`
+		ID    string `json:"id"`
+		Data  struct {
+			JobID    string   `json:"job_id"`
+			URL      string   `json:"url"`
+			Distance int      `json:"distance"`
+			Previous *Capture `json:"previous"`
+			Current  *Capture `json:"current"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload %s: %v", body, err)
+	}
+	if payload.Event != EventChanged || payload.Data.JobID != "pricing" || payload.Data.URL != "https://acme.example/pricing" {
+		t.Errorf("payload = %s", body)
+	}
+	if payload.Data.Distance == 0 || payload.Data.Previous == nil || payload.Data.Current == nil || !payload.Data.Current.Changed {
+		t.Errorf("payload data = %s", body)
+	}
+	if want := "mon_pricing_" + payload.Data.Current.ID; payload.ID != want {
+		t.Errorf("payload ID = %q, want %q", payload.ID, want)
+	}
+}
+
+func TestCheckWebhookFailure(t *testing.T) {
+	var mu sync.Mutex
+	deliveries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries++
+		if deliveries == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	changes := 0
+	m := &Monitor{
+		Client:     pages(t, pricingPage(0, color.Black, false), pricingPage(0, color.Black, true)),
+		Storage:    NewMemoryStorage(),
+		WebhookURL: server.URL,
+		Webhook:    rs.NewWebhookSender("whsec_test"),
+		OnChange:   func(context.Context, Change) { changes++ },
+		Now:        clock(),
+	}
+	job := Job{ID: "pricing", Options: rs.URL("https://acme.example")}
+	if _, err := m.Check(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	c, err := m.Check(context.Background(), job)
+	if err == nil {
+		t.Error("error = nil, want webhook error")
+	}
+	if c == nil || !c.Changed {
+		t.Errorf("capture = %+v, want the changed capture", c)
+	}
+	if changes != 0 {
+		t.Errorf("OnChange calls after a failed webhook = %d, want 0", changes)
+	}
+
+	// The failed change is reported again, then the reference moves on.
+	if c, err = m.Check(context.Background(), job); err != nil || !c.Changed {
+		t.Errorf("retry: capture = %+v, error = %v, want changed", c, err)
+	}
+	if c, err = m.Check(context.Background(), job); err != nil || c.Changed {
+		t.Errorf("after delivery: capture = %+v, error = %v, want unchanged", c, err)
+	}
+	if deliveries != 2 {
+		t.Errorf("deliveries = %d, want 2", deliveries)
+	}
+	if changes != 1 {
+		t.Errorf("OnChange calls = %d, want 1", changes)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	ctx := context.Background()
+	job := Job{ID: "pricing", Options: rs.URL("https://acme.example")}
+
+	m := &Monitor{Client: pages(t, pricingPage(0, color.Black, false)), Storage: NewMemoryStorage()}
+	for _, bad := range []Job{
+		{ID: "", Options: job.Options},
+		{ID: "../x", Options: job.Options},
+		{ID: "pricing"},
+	} {
+		if _, err := m.Check(ctx, bad); err == nil {
+			t.Errorf("Check(%+v) error = nil", bad)
+		}
+	}
+
+	m.WebhookURL = "http://localhost/hook"
+	if _, err := m.Check(ctx, job); err == nil {
+		t.Error("WebhookURL without Webhook: error = nil")
+	}
+
+	m = &Monitor{
+		Client: &rsmock.Client{TakeFunc: func(context.Context, *rs.TakeOptions) ([]byte, error) {
+			return []byte("%PDF-1.7"), nil
+		}},
+		Storage: NewMemoryStorage(),
+	}
+	if _, err := m.Check(ctx, job); err == nil {
+		t.Error("PDF capture: error = nil")
+	}
+}
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	captures := map[string]int{}
+	var errs []error
+	failing := errors.New("boom")
+	png := encode(t, image.NewNRGBA(image.Rect(0, 0, 32, 32)))
+	m := &Monitor{
+		Client: &rsmock.Client{TakeFunc: func(_ context.Context, o *rs.TakeOptions) ([]byte, error) {
+			if o.ToParams()["url"] == "https://down.example" {
+				return nil, failing
+			}
+			return png, nil
+		}},
+		Storage: NewMemoryStorage(),
+		Jobs: []Job{
+			{ID: "a", Options: rs.URL("https://a.example"), Schedule: Every(10 * time.Millisecond)},
+			{ID: "b", Options: rs.URL("https://b.example"), Schedule: Every(15 * time.Millisecond)},
+			{ID: "down", Options: rs.URL("https://down.example"), Schedule: Every(10 * time.Millisecond)},
+		},
+		OnCapture: func(_ context.Context, c *Capture) {
+			mu.Lock()
+			captures[c.JobID]++
+			mu.Unlock()
+		},
+		OnError: func(job Job, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() = %v, want context.DeadlineExceeded", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if captures["a"] < 2 || captures["b"] < 2 {
+		t.Errorf("captures = %v, want at least 2 per job", captures)
+	}
+	if len(errs) == 0 || !errors.Is(errs[0], failing) {
+		t.Errorf("OnError errors = %v", errs)
+	}
+}
+
+func TestRunInvalidJobs(t *testing.T) {
+	opts := rs.URL("https://acme.example")
+	for name, jobs := range map[string][]Job{
+		"no schedule": {{ID: "a", Options: opts}},
+		"duplicate": {
+			{ID: "a", Options: opts, Schedule: Every(time.Hour)},
+			{ID: "a", Options: opts, Schedule: Every(time.Hour)},
+		},
+		"zero interval":     {{ID: "a", Options: opts, Schedule: Every(0)}},
+		"negative interval": {{ID: "a", Options: opts, Schedule: Every(-time.Minute)}},
+	} {
+		m := &Monitor{Client: &rsmock.Client{}, Storage: NewMemoryStorage(), Jobs: jobs}
+		if err := m.Run(context.Background()); err == nil {
+			t.Errorf("%s: Run() error = nil", name)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package monitor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register the JPEG decoder
+	_ "image/png"  // register the PNG decoder
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// Hash is a 64-bit perceptual hash of a capture. Visually similar images
+// have hashes a small Hamming distance apart, so rendering noise, small
+// scroll offsets and compression artefacts barely move it while layout,
+// image and colour changes do.
+type Hash uint64
+
+// Distance returns the number of differing bits, from 0 (identical) to 64.
+func (h Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// String returns the hash as 16 hex digits.
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *Hash) UnmarshalText(text []byte) error {
+	if len(text) != 16 {
+		return fmt.Errorf("monitor: invalid hash %q", text)
+	}
+	v, err := strconv.ParseUint(string(text), 16, 64)
+	if err != nil {
+		return fmt.Errorf("monitor: invalid hash %q", text)
+	}
+	*h = Hash(v)
+	return nil
+}
+
+// hashSize is the side of the grey-scale thumbnail the DCT runs on; the
+// hash keeps the 8×8 lowest frequencies.
+const hashSize = 32
+
+// PerceptualHash computes the DCT-based perceptual hash (pHash) of img:
+// the image is reduced to a 32×32 grey-scale thumbnail, transformed with a
+// discrete cosine transform, and each of the 64 lowest-frequency
+// coefficients becomes a bit set when it is above their median.
+func PerceptualHash(img image.Image) Hash {
+	pixels := thumbnail(img)
+	coeffs := dct2D(pixels)
+
+	low := make([]float64, 0, 64)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			low = append(low, coeffs[y][x])
+		}
+	}
+	// The DC term (overall brightness) is left out of the median.
+	sorted := append([]float64(nil), low[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var h Hash
+	for i, c := range low {
+		if c > median {
+			h |= 1 << uint(63-i)
+		}
+	}
+	return h
+}
+
+// HashImage decodes a PNG or JPEG capture and hashes it.
+func HashImage(data []byte) (Hash, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("monitor: %w", err)
+	}
+	return PerceptualHash(img), nil
+}
+
+// thumbnail scales img to hashSize×hashSize luminance values, averaging
+// the source pixels that fall in each cell.
+func thumbnail(img image.Image) [hashSize][hashSize]float64 {
+	var out [hashSize][hashSize]float64
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return out
+	}
+	for ty := 0; ty < hashSize; ty++ {
+		y0, y1 := cell(ty, h)
+		for tx := 0; tx < hashSize; tx++ {
+			x0, x1 := cell(tx, w)
+			var sum float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+				}
+			}
+			out[ty][tx] = sum / float64((y1-y0)*(x1-x0)) / 257
+		}
+	}
+	return out
+}
+
+// cell returns the source range of thumbnail cell i for a side of n
+// pixels; it is never empty, so images smaller than the thumbnail work.
+func cell(i, n int) (int, int) {
+	lo := i * n / hashSize
+	hi := (i + 1) * n / hashSize
+	if hi <= lo {
+		hi = lo + 1
+	}
+	return lo, hi
+}
+
+// dct2D applies a type-II discrete cosine transform to rows then columns.
+func dct2D(in [hashSize][hashSize]float64) [hashSize][hashSize]float64 {
+	var rows, out [hashSize][hashSize]float64
+	for y := 0; y < hashSize; y++ {
+		rows[y] = dct1D(in[y])
+	}
+	for x := 0; x < hashSize; x++ {
+		var col [hashSize]float64
+		for y := 0; y < hashSize; y++ {
+			col[y] = rows[y][x]
+		}
+		col = dct1D(col)
+		for y := 0; y < hashSize; y++ {
+			out[y][x] = col[y]
+		}
+	}
+	return out
+}
+
+// dctCos holds the cosine terms of a hashSize-point DCT.
+var dctCos = func() (c [hashSize][hashSize]float64) {
+	for k := 0; k < hashSize; k++ {
+		for n := 0; n < hashSize; n++ {
+			c[k][n] = math.Cos(math.Pi / hashSize * (float64(n) + 0.5) * float64(k))
+		}
+	}
+	return c
+}()
+
+func dct1D(in [hashSize]float64) [hashSize]float64 {
+	var out [hashSize]float64
+	for k := 0; k < hashSize; k++ {
+		var sum float64
+		for n := 0; n < hashSize; n++ {
+			sum += in[n] * dctCos[k][n]
+		}
+		out[k] = sum
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// pricingPage draws a small pricing page: a header with a logo, a title
+// and three plan cards shifted by shift pixels, with prices in price.
+func pricingPage(shift int, price color.Color, banner bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 320, 240))
+	fill := func(r image.Rectangle, c color.Color) {
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	fill(img.Rect, color.White)
+	fill(image.Rect(0, 0, 320, 40), color.NRGBA{R: 20, G: 40, B: 90, A: 255})
+	fill(image.Rect(10, 8, 70, 32), color.NRGBA{R: 250, G: 180, A: 255})
+	fill(image.Rect(10, 50, 150, 60), color.NRGBA{R: 60, G: 60, B: 60, A: 255})
+	for i := 0; i < 3; i++ {
+		x := 10 + i*95 + shift
+		fill(image.Rect(x, 70, x+80, 200), color.NRGBA{R: 230, G: 230, B: 235, A: 255})
+		fill(image.Rect(x+10, 90, x+70, 110), price)
+	}
+	if banner {
+		fill(image.Rect(0, 200, 320, 240), color.NRGBA{R: 200, G: 30, B: 30, A: 255})
+	}
+	return img
+}
+
+func encode(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPerceptualHashStable(t *testing.T) {
+	base := PerceptualHash(pricingPage(0, color.Black, false))
+	if again := PerceptualHash(pricingPage(0, color.Black, false)); again != base {
+		t.Fatalf("hash is not deterministic: %v != %v", again, base)
+	}
+
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, pricingPage(0, color.Black, false), &jpeg.Options{Quality: 70}); err != nil {
+		t.Fatal(err)
+	}
+	fromJPEG, err := HashImage(jpg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := pricingPage(0, color.Black, false)
+	scaled := image.NewNRGBA(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			scaled.Set(x, y, src.At(x/2, y/2))
+		}
+	}
+
+	for name, h := range map[string]Hash{
+		"jpeg":    fromJPEG,
+		"shifted": PerceptualHash(pricingPage(1, color.Black, false)),
+		"scaled":  PerceptualHash(scaled),
+	} {
+		if d := base.Distance(h); d > 2 {
+			t.Errorf("%s: distance = %d, want at most 2", name, d)
+		}
+	}
+}
+
+func TestPerceptualHashDetectsChanges(t *testing.T) {
+	base := PerceptualHash(pricingPage(0, color.Black, false))
+	if d := base.Distance(PerceptualHash(pricingPage(0, color.Black, true))); d == 0 {
+		t.Error("banner: distance = 0")
+	}
+	if d := base.Distance(PerceptualHash(pricingPage(0, color.NRGBA{G: 120, A: 255}, false))); d == 0 {
+		t.Error("price colour: distance = 0")
+	}
+
+	inverted := pricingPage(0, color.Black, false)
+	for i := 0; i < len(inverted.Pix); i += 4 {
+		inverted.Pix[i], inverted.Pix[i+1], inverted.Pix[i+2] = 255-inverted.Pix[i], 255-inverted.Pix[i+1], 255-inverted.Pix[i+2]
+	}
+	if d := base.Distance(PerceptualHash(inverted)); d < 20 {
+		t.Errorf("inverted: distance = %d, want at least 20", d)
+	}
+}
+
+func TestHashText(t *testing.T) {
+	h := Hash(0x00ab7954746bc494)
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"00ab7954746bc494"` {
+		t.Errorf("json = %s", data)
+	}
+	var back Hash
+	if err := json.Unmarshal(data, &back); err != nil || back != h {
+		t.Errorf("round trip = %v, %v", back, err)
+	}
+	for _, bad := range []string{`"abc"`, `"zzzzzzzzzzzzzzzz"`, `"+0000000000000000"`} {
+		if err := json.Unmarshal([]byte(bad), &back); err == nil {
+			t.Errorf("Unmarshal(%s) error = nil", bad)
+		}
+	}
+	if d := Hash(0).Distance(Hash(0xff)); d != 8 {
+		t.Errorf("Distance = %d, want 8", d)
+	}
+}
+
+func TestHashImageErrors(t *testing.T) {
+	if _, err := HashImage([]byte("%PDF-1.7")); err == nil {
+		t.Error("PDF: error = nil")
+	}
+	// Images smaller than the thumbnail still hash.
+	if _, err := HashImage(encode(t, image.NewNRGBA(image.Rect(0, 0, 3, 2)))); err != nil {
+		t.Errorf("tiny image: %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a job runs.
+type Schedule interface {
+	// Next returns the first run time after t, or the zero time if there
+	// is none.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a schedule that runs every d. Run rejects jobs whose d is
+// not positive.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// ParseSchedule parses a cron expression with five fields (minute, hour,
+// day of month, month and day of week), e.g. "*/15 9-17 * * mon-fri".
+// Fields accept *, lists, ranges, /steps and month and weekday names; 0 and
+// 7 are both Sunday. When both day fields are restricted, a day matching
+// either runs, as in cron.
+//
+// The descriptors @yearly, @monthly, @weekly, @daily, @hourly and
+// "@every <duration>" are also accepted. A "TZ=<zone> " prefix evaluates
+// the expression in that time zone instead of the time passed to Next.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	var loc *time.Location
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		zone, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("monitor: schedule %q: %w", spec, err)
+		}
+		spec = strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("monitor: invalid schedule %q", spec)
+		}
+		return Every(d), nil
+	}
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("monitor: schedule %q: want 5 fields, got %d", spec, len(fields))
+	}
+	s := &cron{loc: loc}
+	var err error
+	for i, f := range []struct {
+		bits     *uint64
+		min, max int
+		names    []string
+	}{
+		{&s.minute, 0, 59, nil},
+		{&s.hour, 0, 23, nil},
+		{&s.dom, 1, 31, nil},
+		{&s.month, 1, 12, monthNames},
+		{&s.dow, 0, 7, dayNames},
+	} {
+		if *f.bits, err = parseField(fields[i], f.min, f.max, f.names); err != nil {
+			return nil, fmt.Errorf("monitor: schedule %q: %w", spec, err)
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is Sunday too
+	}
+	s.domAny = fields[2] == "*" || fields[2] == "?"
+	s.dowAny = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+// MustParseSchedule is like ParseSchedule but panics on an invalid
+// expression, for schedules written in code.
+func MustParseSchedule(spec string) Schedule {
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// parseField parses one cron field into a bit set of allowed values.
+func parseField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" && rng != "?" {
+			loText, hiText, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(loText, min, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(hiText, min, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = max // "5/15" means from 5 to the end
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, min int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return i + min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// cron is a parsed five-field expression.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+	loc                           *time.Location
+}
+
+// Next implements Schedule. It searches up to five years ahead, so
+// expressions that never match (such as February 30th) return zero.
+func (s *cron) Next(t time.Time) time.Time {
+	orig := t.Location()
+	if s.loc != nil {
+		t = t.In(s.loc)
+	}
+	loc := t.Location()
+	// Start at the next whole minute. Steps below only ever move forward
+	// in absolute time, so daylight saving transitions cannot loop.
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t.In(orig)
+		}
+	}
+	return time.Time{}
+}
+
+func (s *cron) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
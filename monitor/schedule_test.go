@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	// Wednesday, 2024-05-15 10:07:30 UTC.
+	from := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2024, 5, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 9-17 * * mon-fri", time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2024, 5, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2024, 5, 15, 10, 25, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{"0 0 1 * fri", time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2024, 5, 15, 11, 37, 30, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.spec, from, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleTimeZone(t *testing.T) {
+	s, err := ParseSchedule("TZ=America/New_York 0 9 * * *")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	from := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC) // 08:00 in New York
+	got := s.Next(from)
+	if want := time.Date(2024, 5, 15, 13, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+	if got.Location() != time.UTC {
+		t.Errorf("Next() location = %v, want the location of the argument", got.Location())
+	}
+
+	// The nonexistent 02:30 on the spring-forward day is skipped.
+	ny, _ := time.LoadLocation("America/New_York")
+	s = MustParseSchedule("30 2 * * *")
+	got = s.Next(time.Date(2024, 3, 9, 12, 0, 0, 0, ny))
+	if want := time.Date(2024, 3, 11, 2, 30, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("DST Next() = %v, want %v", got, want)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every",
+		"@every -1m",
+		"@sometimes",
+		"TZ=Nowhere/Special * * * * *",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) error = nil", spec)
+		}
+	}
+}
+
+func TestScheduleNeverMatches(t *testing.T) {
+	s := MustParseSchedule("0 0 30 feb *")
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %v, want zero", got)
+	}
+}
+
+func TestMustParseSchedulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustParseSchedule did not panic")
+		}
+	}()
+	MustParseSchedule("not a schedule")
+}
@@ -0,0 +1,262 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Capture is one stored run of a job.
+type Capture struct {
+	ID          string    `json:"id"`
+	JobID       string    `json:"job_id"`
+	URL         string    `json:"url,omitempty"`
+	TakenAt     time.Time `json:"taken_at"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	Hash        Hash      `json:"hash"`
+	// Reference is the hash changes are measured against: that of the
+	// last capture reported as a change, or of the job's first capture.
+	// Measuring against it rather than the previous capture means slow
+	// drift is reported once it adds up.
+	Reference Hash `json:"reference"`
+	// Distance is the Hamming distance between Hash and the previous
+	// capture's Reference.
+	Distance int  `json:"distance"`
+	Changed  bool `json:"changed"`
+}
+
+// ErrNotFound is returned by Storage.Image for unknown captures.
+var ErrNotFound = errors.New("monitor: capture not found")
+
+// Storage keeps the capture history of jobs. Implementations must be safe
+// for concurrent use.
+type Storage interface {
+	// Save stores a capture and its image.
+	Save(ctx context.Context, c *Capture, image []byte) error
+	// Latest returns the most recent capture of a job, or nil if there is
+	// none.
+	Latest(ctx context.Context, jobID string) (*Capture, error)
+	// History returns up to limit captures of a job, newest first. A limit
+	// of zero or less returns them all.
+	History(ctx context.Context, jobID string, limit int) ([]*Capture, error)
+	// Image returns the image of a capture, or ErrNotFound.
+	Image(ctx context.Context, jobID, captureID string) ([]byte, error)
+}
+
+// MemoryStorage is an in-process Storage, for tests and short-lived
+// monitors.
+type MemoryStorage struct {
+	mu       sync.Mutex
+	captures map[string][]Capture
+	images   map[string][]byte
+}
+
+// NewMemoryStorage creates an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		captures: map[string][]Capture{},
+		images:   map[string][]byte{},
+	}
+}
+
+// Save implements Storage.
+func (s *MemoryStorage) Save(_ context.Context, c *Capture, image []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captures[c.JobID] = append(s.captures[c.JobID], *c)
+	s.images[c.JobID+"/"+c.ID] = append([]byte(nil), image...)
+	return nil
+}
+
+// Latest implements Storage.
+func (s *MemoryStorage) Latest(ctx context.Context, jobID string) (*Capture, error) {
+	history, _ := s.History(ctx, jobID, 1)
+	if len(history) == 0 {
+		return nil, nil
+	}
+	return history[0], nil
+}
+
+// History implements Storage.
+func (s *MemoryStorage) History(_ context.Context, jobID string, limit int) ([]*Capture, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return newestFirst(s.captures[jobID], limit), nil
+}
+
+// Image implements Storage.
+func (s *MemoryStorage) Image(_ context.Context, jobID, captureID string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	image, ok := s.images[jobID+"/"+captureID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), image...), nil
+}
+
+// FileStorage is a Storage in a directory, with one subdirectory per job
+// holding the images and a captures.jsonl index:
+//
+//	<dir>/<job>/captures.jsonl
+//	<dir>/<job>/<capture>.png
+//
+// A FileStorage must not be shared between processes.
+type FileStorage struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStorage opens or creates a FileStorage in dir.
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStorage{dir: dir}, nil
+}
+
+const indexFile = "captures.jsonl"
+
+// Save implements Storage.
+func (s *FileStorage) Save(_ context.Context, c *Capture, image []byte) error {
+	if err := checkID("job", c.JobID); err != nil {
+		return err
+	}
+	if err := checkID("capture", c.ID); err != nil {
+		return err
+	}
+	line, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := filepath.Join(s.dir, c.JobID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// The image is written first so the index never names a missing file.
+	if err := os.WriteFile(filepath.Join(dir, c.ID+imageExt(c.ContentType)), image, 0o644); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, indexFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Latest implements Storage.
+func (s *FileStorage) Latest(ctx context.Context, jobID string) (*Capture, error) {
+	history, err := s.History(ctx, jobID, 1)
+	if err != nil || len(history) == 0 {
+		return nil, err
+	}
+	return history[0], nil
+}
+
+// History implements Storage.
+func (s *FileStorage) History(_ context.Context, jobID string, limit int) ([]*Capture, error) {
+	if err := checkID("job", jobID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	captures, err := s.read(jobID)
+	if err != nil {
+		return nil, err
+	}
+	return newestFirst(captures, limit), nil
+}
+
+// Image implements Storage.
+func (s *FileStorage) Image(_ context.Context, jobID, captureID string) ([]byte, error) {
+	if err := checkID("job", jobID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	captures, err := s.read(jobID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range captures {
+		if c.ID == captureID {
+			data, err := os.ReadFile(filepath.Join(s.dir, jobID, c.ID+imageExt(c.ContentType)))
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, ErrNotFound
+			}
+			return data, err
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// read loads a job's index. Callers must hold s.mu.
+func (s *FileStorage) read(jobID string) ([]Capture, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, jobID, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var captures []Capture
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var c Capture
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("monitor: %s index: %w", jobID, err)
+		}
+		captures = append(captures, c)
+	}
+	return captures, scanner.Err()
+}
+
+func newestFirst(captures []Capture, limit int) []*Capture {
+	if limit <= 0 || limit > len(captures) {
+		limit = len(captures)
+	}
+	out := make([]*Capture, 0, limit)
+	for i := len(captures) - 1; i >= 0 && len(out) < limit; i-- {
+		c := captures[i]
+		out = append(out, &c)
+	}
+	return out
+}
+
+func imageExt(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	}
+	return ".bin"
+}
+
+var safeID = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]{0,127}$`)
+
+func checkID(kind, id string) error {
+	if !safeID.MatchString(id) {
+		return fmt.Errorf("monitor: invalid %s ID %q (use letters, digits, '.', '_' and '-')", kind, id)
+	}
+	return nil
+}
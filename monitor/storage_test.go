@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testStorage(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	if c, err := s.Latest(ctx, "pricing"); c != nil || err != nil {
+		t.Fatalf("Latest on empty storage = %v, %v, want nil, nil", c, err)
+	}
+	start := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		c := &Capture{
+			ID:          fmt.Sprintf("c%d", i),
+			JobID:       "pricing",
+			TakenAt:     start.Add(time.Duration(i) * time.Hour),
+			ContentType: "image/png",
+			Hash:        Hash(i),
+		}
+		if err := s.Save(ctx, c, []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	latest, err := s.Latest(ctx, "pricing")
+	if err != nil || latest == nil || latest.ID != "c2" {
+		t.Fatalf("Latest = %v, %v, want c2", latest, err)
+	}
+	history, err := s.History(ctx, "pricing", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].ID != "c2" || history[1].ID != "c1" {
+		t.Errorf("History(2) = %v, want c2, c1", history)
+	}
+	if all, _ := s.History(ctx, "pricing", 0); len(all) != 3 {
+		t.Errorf("History(0) returned %d captures, want 3", len(all))
+	}
+	if !history[0].TakenAt.Equal(start.Add(2*time.Hour)) || history[0].Hash != 2 {
+		t.Errorf("History()[0] = %+v", history[0])
+	}
+	if other, _ := s.History(ctx, "other", 0); len(other) != 0 {
+		t.Errorf("History(other) = %v, want none", other)
+	}
+
+	data, err := s.Image(ctx, "pricing", "c1")
+	if err != nil || len(data) != 1 || data[0] != 1 {
+		t.Errorf("Image(c1) = %v, %v", data, err)
+	}
+	if _, err := s.Image(ctx, "pricing", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Image(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage())
+}
+
+func TestFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+
+	if _, err := os.Stat(filepath.Join(dir, "pricing", "c0.png")); err != nil {
+		t.Errorf("image file: %v", err)
+	}
+	reopened, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := reopened.History(context.Background(), "pricing", 0); len(history) != 3 {
+		t.Errorf("reopened History() returned %d captures, want 3", len(history))
+	}
+}
+
+func TestFileStorageInvalidIDs(t *testing.T) {
+	s, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, c := range []*Capture{
+		{ID: "c1", JobID: "../escape"},
+		{ID: "c1", JobID: ""},
+		{ID: "a/b", JobID: "pricing"},
+		{ID: ".hidden", JobID: "pricing"},
+	} {
+		if err := s.Save(ctx, c, nil); err == nil {
+			t.Errorf("Save(%q, %q) error = nil", c.JobID, c.ID)
+		}
+	}
+	if _, err := s.History(ctx, "../escape", 0); err == nil {
+		t.Error("History(../escape) error = nil")
+	}
+}
@@ -88,6 +88,9 @@ type TakeOptions struct {
 	storageEnabled *bool
 	storagePath    string
 	storageACL     StorageACL
+
+	// Attribution
+	tags map[string]string
 }
 
 type basicAuth struct {
@@ -530,6 +533,20 @@ func (o *TakeOptions) StorageACL(value StorageACL) *TakeOptions {
 	return o
 }
 
+// Tags sets attribution tags (e.g., {"tenant": "acme"}) that are stored with
+// the capture, echoed back in responses and webhooks, and usable as filters
+// in usage and cache listing APIs. The map is copied.
+func (o *TakeOptions) Tags(value map[string]string) *TakeOptions {
+	o.tags = nil
+	if value != nil {
+		o.tags = make(map[string]string, len(value))
+		for k, v := range value {
+			o.tags[k] = v
+		}
+	}
+	return o
+}
+
 // ToParams converts the options to nested JSON params for POST requests.
 // The structure matches the API's expected JSON format.
 func (o *TakeOptions) ToParams() map[string]interface{} {
@@ -786,6 +803,10 @@ func (o *TakeOptions) ToParams() map[string]interface{} {
 		result["storage"] = storage
 	}
 
+	if len(o.tags) > 0 {
+		result["tags"] = o.tags
+	}
+
 	return result
 }
 
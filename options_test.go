@@ -552,3 +552,28 @@ func splitKeyValue(kv string) []string {
 	}
 	return []string{kv}
 }
+
+func TestToParamsTags(t *testing.T) {
+	params := URL("https://example.com").
+		Tags(map[string]string{"tenant": "acme", "plan": "pro"}).
+		ToParams()
+
+	tags, ok := params["tags"].(map[string]string)
+	if !ok {
+		t.Fatalf("expected tags map, got %T", params["tags"])
+	}
+	if tags["tenant"] != "acme" || tags["plan"] != "pro" {
+		t.Errorf("tags = %v", tags)
+	}
+
+	if _, ok := URL("https://example.com").ToParams()["tags"]; ok {
+		t.Error("expected no tags key when unset")
+	}
+
+	shared := map[string]string{"tenant": "acme"}
+	opts := URL("https://example.com").Tags(shared)
+	shared["tenant"] = "other"
+	if got := opts.ToParams()["tags"].(map[string]string)["tenant"]; got != "acme" {
+		t.Errorf("tenant after modifying the caller's map = %q, want acme", got)
+	}
+}
@@ -0,0 +1,86 @@
+package rsmock
+
+import (
+	"context"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// Cache is a mock of rs.CacheAPI. The zero value is ready to use.
+type Cache struct {
+	recorder
+
+	GetFunc          func(ctx context.Context, key string) ([]byte, error)
+	ListFunc         func(ctx context.Context, opts rs.CacheListOptions) (*rs.CacheList, error)
+	DeleteFunc       func(ctx context.Context, key string) (bool, error)
+	PurgeFunc        func(ctx context.Context, keys []string) (*rs.PurgeResult, error)
+	PurgeURLFunc     func(ctx context.Context, pattern string) (*rs.PurgeResult, error)
+	PurgeBeforeFunc  func(ctx context.Context, before time.Time) (*rs.PurgeResult, error)
+	PurgePatternFunc func(ctx context.Context, pattern string) (*rs.PurgeResult, error)
+}
+
+var _ rs.CacheAPI = (*Cache)(nil)
+
+// Get implements rs.CacheAPI.
+func (m *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.record("Get", key)
+	if m.GetFunc == nil {
+		return nil, notMocked("Get")
+	}
+	return m.GetFunc(ctx, key)
+}
+
+// List implements rs.CacheAPI.
+func (m *Cache) List(ctx context.Context, opts rs.CacheListOptions) (*rs.CacheList, error) {
+	m.record("List", opts)
+	if m.ListFunc == nil {
+		return nil, notMocked("List")
+	}
+	return m.ListFunc(ctx, opts)
+}
+
+// Delete implements rs.CacheAPI.
+func (m *Cache) Delete(ctx context.Context, key string) (bool, error) {
+	m.record("Delete", key)
+	if m.DeleteFunc == nil {
+		return false, notMocked("Delete")
+	}
+	return m.DeleteFunc(ctx, key)
+}
+
+// Purge implements rs.CacheAPI.
+func (m *Cache) Purge(ctx context.Context, keys []string) (*rs.PurgeResult, error) {
+	m.record("Purge", keys)
+	if m.PurgeFunc == nil {
+		return nil, notMocked("Purge")
+	}
+	return m.PurgeFunc(ctx, keys)
+}
+
+// PurgeURL implements rs.CacheAPI.
+func (m *Cache) PurgeURL(ctx context.Context, pattern string) (*rs.PurgeResult, error) {
+	m.record("PurgeURL", pattern)
+	if m.PurgeURLFunc == nil {
+		return nil, notMocked("PurgeURL")
+	}
+	return m.PurgeURLFunc(ctx, pattern)
+}
+
+// PurgeBefore implements rs.CacheAPI.
+func (m *Cache) PurgeBefore(ctx context.Context, before time.Time) (*rs.PurgeResult, error) {
+	m.record("PurgeBefore", before)
+	if m.PurgeBeforeFunc == nil {
+		return nil, notMocked("PurgeBefore")
+	}
+	return m.PurgeBeforeFunc(ctx, before)
+}
+
+// PurgePattern implements rs.CacheAPI.
+func (m *Cache) PurgePattern(ctx context.Context, pattern string) (*rs.PurgeResult, error) {
+	m.record("PurgePattern", pattern)
+	if m.PurgePatternFunc == nil {
+		return nil, notMocked("PurgePattern")
+	}
+	return m.PurgePatternFunc(ctx, pattern)
+}
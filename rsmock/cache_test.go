@@ -0,0 +1,33 @@
+package rsmock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+func TestCacheMock(t *testing.T) {
+	m := &Cache{
+		PurgeBeforeFunc: func(_ context.Context, before time.Time) (*rs.PurgeResult, error) {
+			return &rs.PurgeResult{Purged: 3}, nil
+		},
+	}
+	m.Expect("PurgeBefore", 1)
+
+	var api rs.CacheAPI = m
+	cutoff := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	result, err := api.PurgeBefore(context.Background(), cutoff)
+	if err != nil || result.Purged != 3 {
+		t.Fatalf("PurgeBefore() = %+v, %v", result, err)
+	}
+	if got := m.CallsTo("PurgeBefore")[0].Args[0]; got != cutoff {
+		t.Errorf("recorded arg = %v, want %v", got, cutoff)
+	}
+	m.AssertExpectations(t)
+
+	if deleted, err := api.Delete(context.Background(), "k"); deleted || err == nil {
+		t.Errorf("unmocked Delete() = %v, %v; want false, ErrNotMocked", deleted, err)
+	}
+}
@@ -0,0 +1,141 @@
+package rsmock
+
+import (
+	"context"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// Client is a mock of rs.Screenshotter, rs.Batcher and rs.MetadataAPI.
+// The zero value is ready to use.
+type Client struct {
+	recorder
+
+	TakeFunc                func(ctx context.Context, options *rs.TakeOptions) ([]byte, error)
+	TakeJSONFunc            func(ctx context.Context, options *rs.TakeOptions) (*rs.ScreenshotResponse, error)
+	BatchFunc               func(ctx context.Context, urls []string, options *rs.TakeOptions) (*rs.BatchResponse, error)
+	BatchAdvancedFunc       func(ctx context.Context, requests []rs.BatchRequest) (*rs.BatchResponse, error)
+	GetBatchFunc            func(ctx context.Context, batchID string) (*rs.BatchResponse, error)
+	PresetsFunc             func(ctx context.Context) ([]rs.PresetInfo, error)
+	PresetFunc              func(ctx context.Context, id string) (*rs.PresetInfo, error)
+	DevicesFunc             func(ctx context.Context) ([]rs.DeviceInfo, error)
+	UsageFunc               func(ctx context.Context) (*rs.UsageInfo, error)
+	UsageForTagsFunc        func(ctx context.Context, tags map[string]string) (*rs.UsageInfo, error)
+	UsageHistoryFunc        func(ctx context.Context, from, to time.Time, granularity rs.UsageGranularity) (*rs.UsageHistory, error)
+	UsageHistoryForTagsFunc func(ctx context.Context, from, to time.Time, granularity rs.UsageGranularity, tags map[string]string) (*rs.UsageHistory, error)
+}
+
+var (
+	_ rs.Screenshotter = (*Client)(nil)
+	_ rs.Batcher       = (*Client)(nil)
+	_ rs.MetadataAPI   = (*Client)(nil)
+)
+
+// Take implements rs.Screenshotter.
+func (m *Client) Take(ctx context.Context, options *rs.TakeOptions) ([]byte, error) {
+	m.record("Take", options)
+	if m.TakeFunc == nil {
+		return nil, notMocked("Take")
+	}
+	return m.TakeFunc(ctx, options)
+}
+
+// TakeJSON implements rs.Screenshotter.
+func (m *Client) TakeJSON(ctx context.Context, options *rs.TakeOptions) (*rs.ScreenshotResponse, error) {
+	m.record("TakeJSON", options)
+	if m.TakeJSONFunc == nil {
+		return nil, notMocked("TakeJSON")
+	}
+	return m.TakeJSONFunc(ctx, options)
+}
+
+// Batch implements rs.Batcher.
+func (m *Client) Batch(ctx context.Context, urls []string, options *rs.TakeOptions) (*rs.BatchResponse, error) {
+	m.record("Batch", urls, options)
+	if m.BatchFunc == nil {
+		return nil, notMocked("Batch")
+	}
+	return m.BatchFunc(ctx, urls, options)
+}
+
+// BatchAdvanced implements rs.Batcher.
+func (m *Client) BatchAdvanced(ctx context.Context, requests []rs.BatchRequest) (*rs.BatchResponse, error) {
+	m.record("BatchAdvanced", requests)
+	if m.BatchAdvancedFunc == nil {
+		return nil, notMocked("BatchAdvanced")
+	}
+	return m.BatchAdvancedFunc(ctx, requests)
+}
+
+// GetBatch implements rs.Batcher.
+func (m *Client) GetBatch(ctx context.Context, batchID string) (*rs.BatchResponse, error) {
+	m.record("GetBatch", batchID)
+	if m.GetBatchFunc == nil {
+		return nil, notMocked("GetBatch")
+	}
+	return m.GetBatchFunc(ctx, batchID)
+}
+
+// Presets implements rs.MetadataAPI.
+func (m *Client) Presets(ctx context.Context) ([]rs.PresetInfo, error) {
+	m.record("Presets")
+	if m.PresetsFunc == nil {
+		return nil, notMocked("Presets")
+	}
+	return m.PresetsFunc(ctx)
+}
+
+// Preset implements rs.MetadataAPI.
+func (m *Client) Preset(ctx context.Context, id string) (*rs.PresetInfo, error) {
+	m.record("Preset", id)
+	if m.PresetFunc == nil {
+		return nil, notMocked("Preset")
+	}
+	return m.PresetFunc(ctx, id)
+}
+
+// Devices implements rs.MetadataAPI.
+func (m *Client) Devices(ctx context.Context) ([]rs.DeviceInfo, error) {
+	m.record("Devices")
+	if m.DevicesFunc == nil {
+		return nil, notMocked("Devices")
+	}
+	return m.DevicesFunc(ctx)
+}
+
+// Usage implements rs.MetadataAPI.
+func (m *Client) Usage(ctx context.Context) (*rs.UsageInfo, error) {
+	m.record("Usage")
+	if m.UsageFunc == nil {
+		return nil, notMocked("Usage")
+	}
+	return m.UsageFunc(ctx)
+}
+
+// UsageForTags implements rs.MetadataAPI.
+func (m *Client) UsageForTags(ctx context.Context, tags map[string]string) (*rs.UsageInfo, error) {
+	m.record("UsageForTags", tags)
+	if m.UsageForTagsFunc == nil {
+		return nil, notMocked("UsageForTags")
+	}
+	return m.UsageForTagsFunc(ctx, tags)
+}
+
+// UsageHistory implements rs.MetadataAPI.
+func (m *Client) UsageHistory(ctx context.Context, from, to time.Time, granularity rs.UsageGranularity) (*rs.UsageHistory, error) {
+	m.record("UsageHistory", from, to, granularity)
+	if m.UsageHistoryFunc == nil {
+		return nil, notMocked("UsageHistory")
+	}
+	return m.UsageHistoryFunc(ctx, from, to, granularity)
+}
+
+// UsageHistoryForTags implements rs.MetadataAPI.
+func (m *Client) UsageHistoryForTags(ctx context.Context, from, to time.Time, granularity rs.UsageGranularity, tags map[string]string) (*rs.UsageHistory, error) {
+	m.record("UsageHistoryForTags", from, to, granularity, tags)
+	if m.UsageHistoryForTagsFunc == nil {
+		return nil, notMocked("UsageHistoryForTags")
+	}
+	return m.UsageHistoryForTagsFunc(ctx, from, to, granularity, tags)
+}
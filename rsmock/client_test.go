@@ -0,0 +1,65 @@
+package rsmock
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// countingScreenshotter is an example decorator built on rs.Screenshotter.
+type countingScreenshotter struct {
+	rs.Screenshotter
+	takes int64
+}
+
+func (c *countingScreenshotter) Take(ctx context.Context, o *rs.TakeOptions) ([]byte, error) {
+	atomic.AddInt64(&c.takes, 1)
+	return c.Screenshotter.Take(ctx, o)
+}
+
+func TestClientRecordsCalls(t *testing.T) {
+	m := &Client{
+		TakeFunc: func(_ context.Context, o *rs.TakeOptions) ([]byte, error) {
+			return []byte("png"), nil
+		},
+		GetBatchFunc: func(_ context.Context, id string) (*rs.BatchResponse, error) {
+			return &rs.BatchResponse{ID: id, Status: "completed"}, nil
+		},
+	}
+
+	opts := rs.URL("https://example.com")
+	data, err := m.Take(context.Background(), opts)
+	if err != nil || string(data) != "png" {
+		t.Fatalf("Take() = %q, %v", data, err)
+	}
+	batch, _ := m.GetBatch(context.Background(), "batch_1")
+	if batch.ID != "batch_1" {
+		t.Errorf("GetBatch().ID = %q, want batch_1", batch.ID)
+	}
+
+	calls := m.Calls()
+	if len(calls) != 2 || calls[0].Method != "Take" || calls[1].Method != "GetBatch" {
+		t.Fatalf("Calls() = %+v", calls)
+	}
+	if calls[0].Args[0] != opts {
+		t.Error("expected Take options to be recorded")
+	}
+	if got := m.CallsTo("GetBatch"); len(got) != 1 || got[0].Args[0] != "batch_1" {
+		t.Errorf("CallsTo(GetBatch) = %+v", got)
+	}
+}
+
+func TestClientAsDecoratorTarget(t *testing.T) {
+	m := &Client{TakeFunc: func(context.Context, *rs.TakeOptions) ([]byte, error) { return nil, nil }}
+	d := &countingScreenshotter{Screenshotter: m}
+
+	var s rs.Screenshotter = d
+	_, _ = s.Take(context.Background(), rs.URL("https://a.com"))
+	_, _ = s.Take(context.Background(), rs.URL("https://b.com"))
+
+	if d.takes != 2 || len(m.CallsTo("Take")) != 2 {
+		t.Errorf("decorator counted %d, mock recorded %d; want 2, 2", d.takes, len(m.CallsTo("Take")))
+	}
+}
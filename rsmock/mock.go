@@ -0,0 +1,127 @@
+// Package rsmock provides in-memory mocks of the renderscreenshot client
+// interfaces for unit tests.
+//
+// Set the XxxFunc field of a method to control its result, inspect calls
+// with Calls or CallsTo, and declare expected call counts with Expect:
+//
+//	m := &rsmock.Client{
+//		TakeFunc: func(ctx context.Context, o *rs.TakeOptions) ([]byte, error) {
+//			return []byte("png"), nil
+//		},
+//	}
+//	m.Expect("Take", 1)
+//
+//	svc := NewThumbnailService(m) // accepts rs.Screenshotter
+//	svc.Generate(ctx, "https://example.com")
+//
+//	m.AssertExpectations(t)
+//
+// Methods without a XxxFunc return ErrNotMocked.
+package rsmock
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// ErrNotMocked is returned by mock methods whose XxxFunc is nil.
+var ErrNotMocked = errors.New("rsmock: method not mocked")
+
+// Call is a recorded method call. Args excludes the context.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// recorder records calls and checks expected call counts.
+type recorder struct {
+	mu       sync.Mutex
+	calls    []Call
+	expected map[string]int
+}
+
+func (r *recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns every recorded call in order.
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the recorded calls to method in order.
+func (r *recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Expect declares that method must be called exactly times times before
+// AssertExpectations is called.
+func (r *recorder) Expect(method string, times int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.expected == nil {
+		r.expected = map[string]int{}
+	}
+	r.expected[method] = times
+}
+
+// AssertExpectations reports every method whose call count differs from
+// its expectation.
+func (r *recorder) AssertExpectations(t testing.TB) {
+	t.Helper()
+	for _, msg := range r.unmet() {
+		t.Error(msg)
+	}
+}
+
+// Reset clears recorded calls and expectations.
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+	r.expected = nil
+}
+
+func (r *recorder) unmet() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	methods := make([]string, 0, len(r.expected))
+	for m := range r.expected {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+
+	var msgs []string
+	for _, m := range methods {
+		got := 0
+		for _, c := range r.calls {
+			if c.Method == m {
+				got++
+			}
+		}
+		if want := r.expected[m]; got != want {
+			msgs = append(msgs, fmt.Sprintf("rsmock: %s called %d time(s), want %d", m, got, want))
+		}
+	}
+	return msgs
+}
+
+func notMocked(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotMocked, method)
+}
@@ -0,0 +1,63 @@
+package rsmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// fakeTB captures errors reported through testing.TB.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Error(args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprint(args...))
+}
+
+func TestExpectations(t *testing.T) {
+	m := &Client{}
+	m.Expect("Take", 2)
+	m.Expect("Usage", 0)
+
+	_, _ = m.Take(context.Background(), rs.URL("https://a.com"))
+
+	tb := &fakeTB{}
+	m.AssertExpectations(tb)
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "Take called 1 time(s), want 2") {
+		t.Errorf("errors = %v", tb.errors)
+	}
+
+	_, _ = m.Take(context.Background(), rs.URL("https://b.com"))
+	tb = &fakeTB{}
+	m.AssertExpectations(tb)
+	if len(tb.errors) != 0 {
+		t.Errorf("unexpected errors: %v", tb.errors)
+	}
+}
+
+func TestReset(t *testing.T) {
+	m := &Cache{}
+	m.Expect("Get", 1)
+	_, _ = m.Get(context.Background(), "k")
+	m.Reset()
+
+	if len(m.Calls()) != 0 {
+		t.Errorf("Calls() = %v after Reset", m.Calls())
+	}
+	m.AssertExpectations(t)
+}
+
+func TestNotMocked(t *testing.T) {
+	_, err := (&Client{}).Presets(context.Background())
+	if !errors.Is(err, ErrNotMocked) || !strings.Contains(err.Error(), "Presets") {
+		t.Errorf("err = %v, want ErrNotMocked naming Presets", err)
+	}
+}
@@ -0,0 +1,127 @@
+package rstest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+const dateLayout = "2006-01-02"
+
+func (s *Server) getPreset(id string) *response {
+	for _, p := range s.presets {
+		if p.ID == id {
+			return jsonResponse(http.StatusOK, p)
+		}
+	}
+	return errorResponse(http.StatusNotFound, rs.CodeNotFound, "Preset not found: "+id)
+}
+
+func (s *Server) usage(q url.Values) *response {
+	tags := queryTags(q)
+	now := s.now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+
+	s.mu.Lock()
+	used := 0
+	for _, c := range s.captures {
+		if hasTags(c.tags, tags) && !c.at.Before(start) {
+			used++
+		}
+	}
+	credits := s.credits
+	s.mu.Unlock()
+
+	return jsonResponse(http.StatusOK, map[string]interface{}{
+		"credits":      credits,
+		"used":         used,
+		"remaining":    credits - used,
+		"period_start": start.Format(dateLayout),
+		"period_end":   end.Format(dateLayout),
+	})
+}
+
+// usageHistory buckets recorded captures by day, week (starting Monday)
+// or month.
+func (s *Server) usageHistory(q url.Values) *response {
+	from, err := time.Parse(dateLayout, q.Get("from"))
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, rs.CodeInvalidRequest, "Invalid from date")
+	}
+	to, err := time.Parse(dateLayout, q.Get("to"))
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, rs.CodeInvalidRequest, "Invalid to date")
+	}
+	granularity := rs.UsageGranularity(q.Get("granularity"))
+	if granularity == "" {
+		granularity = rs.GranularityDay
+	}
+	tags := queryTags(q)
+
+	type bucket struct {
+		credits  int
+		byFormat map[string]int
+		byPreset map[string]int
+	}
+	buckets := map[string]*bucket{}
+
+	s.mu.Lock()
+	for _, c := range s.captures {
+		day := c.at.UTC().Truncate(24 * time.Hour)
+		if day.Before(from) || day.After(to) || !hasTags(c.tags, tags) {
+			continue
+		}
+		date := bucketStart(day, granularity).Format(dateLayout)
+		b, ok := buckets[date]
+		if !ok {
+			b = &bucket{byFormat: map[string]int{}, byPreset: map[string]int{}}
+			buckets[date] = b
+		}
+		b.credits++
+		b.byFormat[c.format]++
+		if c.preset != "" {
+			b.byPreset[c.preset]++
+		}
+	}
+	apiKey := s.apiKey
+	s.mu.Unlock()
+
+	dates := make([]string, 0, len(buckets))
+	for d := range buckets {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+
+	data := make([]map[string]interface{}, 0, len(dates))
+	for _, d := range dates {
+		b := buckets[d]
+		data = append(data, map[string]interface{}{
+			"date":       d,
+			"credits":    b.credits,
+			"by_format":  b.byFormat,
+			"by_preset":  b.byPreset,
+			"by_api_key": map[string]int{apiKey: b.credits},
+		})
+	}
+	return jsonResponse(http.StatusOK, map[string]interface{}{
+		"granularity": string(granularity),
+		"from":        from.Format(dateLayout),
+		"to":          to.Format(dateLayout),
+		"data":        data,
+	})
+}
+
+func bucketStart(day time.Time, granularity rs.UsageGranularity) time.Time {
+	switch granularity {
+	case rs.GranularityWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case rs.GranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
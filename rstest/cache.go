@@ -0,0 +1,187 @@
+package rstest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// defaultCacheListLimit is the page size of GET /v1/cache without a limit.
+const defaultCacheListLimit = 100
+
+func (s *Server) getCache(key string) *response {
+	s.mu.Lock()
+	entry, ok := s.liveEntry(key)
+	s.mu.Unlock()
+	if !ok {
+		return errorResponse(http.StatusNotFound, rs.CodeNotFound, "Cache entry not found: "+key)
+	}
+	return binaryResponse(entry)
+}
+
+func (s *Server) deleteCache(key string) *response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.liveEntry(key); !ok {
+		return errorResponse(http.StatusNotFound, rs.CodeNotFound, "Cache entry not found: "+key)
+	}
+	delete(s.cache, key)
+	return jsonResponse(http.StatusOK, map[string]interface{}{"deleted": true, "key": key})
+}
+
+func (s *Server) listCache(q url.Values) *response {
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultCacheListLimit
+	}
+	offset, _ := strconv.Atoi(q.Get("cursor"))
+	tags := queryTags(q)
+
+	s.mu.Lock()
+	var matched []*cacheEntry
+	for key := range s.cache {
+		entry, ok := s.liveEntry(key)
+		if !ok || !hasTags(entry.tags, tags) {
+			continue
+		}
+		if pattern := q.Get("url"); pattern != "" && !globMatch(pattern, entry.url) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].created.Equal(matched[j].created) {
+			return matched[i].created.Before(matched[j].created)
+		}
+		return matched[i].key < matched[j].key
+	})
+
+	entries := []map[string]interface{}{}
+	for i := offset; i < len(matched) && len(entries) < limit; i++ {
+		e := matched[i]
+		item := map[string]interface{}{
+			"key":        e.key,
+			"url":        e.url,
+			"format":     e.format,
+			"size":       len(e.data),
+			"created_at": e.created.UTC().Format(time.RFC3339),
+			"expires_at": e.expires.UTC().Format(time.RFC3339),
+		}
+		if len(e.tags) > 0 {
+			item["tags"] = e.tags
+		}
+		entries = append(entries, item)
+	}
+
+	body := map[string]interface{}{"entries": entries}
+	if next := offset + len(entries); next < len(matched) {
+		body["next_cursor"] = strconv.Itoa(next)
+	}
+	return jsonResponse(http.StatusOK, body)
+}
+
+// purgeCache handles the keys, url, before and pattern purge variants.
+func (s *Server) purgeCache(params map[string]interface{}) *response {
+	var match func(*cacheEntry) bool
+	switch {
+	case params["keys"] != nil:
+		keys := map[string]bool{}
+		if list, ok := params["keys"].([]interface{}); ok {
+			for _, k := range list {
+				if str, ok := k.(string); ok {
+					keys[str] = true
+				}
+			}
+		}
+		match = func(e *cacheEntry) bool { return keys[e.key] }
+	case params["url"] != nil:
+		pattern := stringParam(params, "url")
+		match = func(e *cacheEntry) bool { return globMatch(pattern, e.url) }
+	case params["before"] != nil:
+		before, err := time.Parse(time.RFC3339, stringParam(params, "before"))
+		if err != nil {
+			return errorResponse(http.StatusBadRequest, rs.CodeInvalidRequest, "Invalid before timestamp")
+		}
+		match = func(e *cacheEntry) bool { return e.created.Before(before) }
+	case params["pattern"] != nil:
+		pattern := stringParam(params, "pattern")
+		match = func(e *cacheEntry) bool { return globMatch(pattern, e.key) }
+	default:
+		return errorResponse(http.StatusBadRequest, rs.CodeMissingRequired, "keys, url, before or pattern is required")
+	}
+
+	s.mu.Lock()
+	purged := []string{}
+	for key, entry := range s.cache {
+		if match(entry) {
+			delete(s.cache, key)
+			purged = append(purged, key)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Strings(purged)
+	return jsonResponse(http.StatusOK, map[string]interface{}{"purged": len(purged), "keys": purged})
+}
+
+// liveEntry returns the unexpired entry for key. Callers must hold s.mu.
+func (s *Server) liveEntry(key string) (*cacheEntry, bool) {
+	entry, ok := s.cache[key]
+	if !ok || !s.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// queryTags extracts tags[key]=value filters.
+func queryTags(q url.Values) map[string]string {
+	tags := map[string]string{}
+	for k, v := range q {
+		if strings.HasPrefix(k, "tags[") && strings.HasSuffix(k, "]") && len(v) > 0 {
+			tags[k[len("tags["):len(k)-1]] = v[0]
+		}
+	}
+	return tags
+}
+
+func hasTags(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// globMatch reports whether s matches pattern, where * matches any run of
+// characters (including /) and ? matches exactly one.
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if globMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
@@ -0,0 +1,454 @@
+package rstest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// defaultCacheTTL is how long captures stay cached unless cache.ttl is set.
+const defaultCacheTTL = 24 * time.Hour
+
+// renderSpec is a normalized capture request.
+type renderSpec struct {
+	url      string
+	html     string
+	preset   string
+	device   string
+	format   string
+	width    int
+	height   int
+	fullPage bool
+	tags     map[string]string
+	cacheTTL time.Duration
+	refresh  bool
+}
+
+type cacheEntry struct {
+	key     string
+	url     string
+	format  string
+	width   int
+	height  int
+	data    []byte
+	created time.Time
+	expires time.Time
+	tags    map[string]string
+}
+
+// capture records a billed capture for usage reporting.
+type capture struct {
+	at     time.Time
+	format string
+	preset string
+	tags   map[string]string
+}
+
+type batch struct {
+	mu    sync.Mutex
+	id    string
+	items []*batchItem
+}
+
+type batchItem struct {
+	spec   renderSpec
+	status string
+	key    string
+	err    string
+}
+
+// specFromParams builds a renderSpec from a JSON request body as produced
+// by TakeOptions.ToParams.
+func (s *Server) specFromParams(p map[string]interface{}) renderSpec {
+	spec := renderSpec{
+		url:    stringParam(p, "url"),
+		html:   stringParam(p, "html"),
+		preset: stringParam(p, "preset"),
+		tags:   tagsParam(p["tags"]),
+	}
+	scale := 1.0
+	if vp, ok := p["viewport"].(map[string]interface{}); ok {
+		spec.device = stringParam(vp, "device")
+		spec.width = intParam(vp, "width")
+		spec.height = intParam(vp, "height")
+		if v, ok := vp["scale"].(float64); ok && v > 0 {
+			scale = v
+		}
+	}
+	if c, ok := p["capture"].(map[string]interface{}); ok {
+		spec.fullPage = stringParam(c, "mode") == "full_page"
+	}
+	if o, ok := p["output"].(map[string]interface{}); ok {
+		spec.format = stringParam(o, "format")
+	}
+	if c, ok := p["cache"].(map[string]interface{}); ok {
+		spec.cacheTTL = time.Duration(intParam(c, "ttl")) * time.Second
+		spec.refresh, _ = c["refresh"].(bool)
+	}
+	s.resolveDimensions(&spec, scale)
+	return spec
+}
+
+// specFromQuery builds a renderSpec from signed URL query parameters.
+func (s *Server) specFromQuery(q url.Values) renderSpec {
+	spec := renderSpec{
+		url:      q.Get("url"),
+		html:     q.Get("html"),
+		preset:   q.Get("preset"),
+		device:   q.Get("device"),
+		format:   q.Get("format"),
+		fullPage: q.Get("full_page") == "true",
+	}
+	spec.width, _ = strconv.Atoi(q.Get("width"))
+	spec.height, _ = strconv.Atoi(q.Get("height"))
+	if ttl, err := strconv.Atoi(q.Get("cache_ttl")); err == nil {
+		spec.cacheTTL = time.Duration(ttl) * time.Second
+	}
+	scale, err := strconv.ParseFloat(q.Get("scale"), 64)
+	if err != nil || scale <= 0 {
+		scale = 1
+	}
+	s.resolveDimensions(&spec, scale)
+	return spec
+}
+
+// resolveDimensions applies preset and device sizes, explicit overrides,
+// the scale factor and the output format default. Full-page captures are
+// three viewports tall.
+func (s *Server) resolveDimensions(spec *renderSpec, scale float64) {
+	width, height := defaultWidth, defaultHeight
+	for _, p := range s.presets {
+		if p.ID == spec.preset {
+			width, height = p.Width, p.Height
+		}
+	}
+	for _, d := range s.devices {
+		if d.ID == spec.device {
+			width, height = d.Width, d.Height
+		}
+	}
+	if spec.width > 0 {
+		width = spec.width
+	}
+	if spec.height > 0 {
+		height = spec.height
+	}
+	if spec.fullPage {
+		height *= 3
+	}
+	spec.width = int(float64(width) * scale)
+	spec.height = int(float64(height) * scale)
+	if spec.format == "" {
+		spec.format = string(rs.FormatPNG)
+	}
+}
+
+func (spec renderSpec) cacheKey() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%dx%d",
+		spec.url, spec.html, spec.format, spec.width, spec.height)))
+	return hex.EncodeToString(sum[:12])
+}
+
+// validate reports a missing or unknown source or format.
+func (s *Server) validate(spec renderSpec) *apiError {
+	if spec.url == "" && spec.html == "" {
+		return &apiError{
+			status:  http.StatusBadRequest,
+			code:    rs.CodeMissingRequired,
+			message: "Either url or html is required",
+			details: []map[string]interface{}{
+				{"path": "url", "constraint": "required", "message": "url or html is required"},
+			},
+		}
+	}
+	if spec.url != "" {
+		if u, err := url.Parse(spec.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &apiError{status: http.StatusBadRequest, code: rs.CodeInvalidURL, message: "Invalid URL: " + spec.url}
+		}
+	}
+	switch rs.ImageFormat(spec.format) {
+	case rs.FormatPNG, rs.FormatJPEG, rs.FormatWebP, rs.FormatPDF:
+	default:
+		return &apiError{status: http.StatusBadRequest, code: rs.CodeInvalidRequest, message: "Unsupported format: " + spec.format}
+	}
+	return nil
+}
+
+// render returns the cache entry for spec, capturing it if needed.
+func (s *Server) render(spec renderSpec) (*cacheEntry, bool, *apiError) {
+	if err := s.validate(spec); err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg, ok := s.failURLs[spec.url]; ok && spec.url != "" {
+		return nil, false, &apiError{status: http.StatusUnprocessableEntity, code: rs.CodeRenderFailed, message: msg}
+	}
+
+	key := spec.cacheKey()
+	now := s.now()
+	if entry, ok := s.cache[key]; ok && !spec.refresh && now.Before(entry.expires) {
+		return entry, true, nil
+	}
+	if s.used >= s.credits {
+		return nil, false, &apiError{status: http.StatusPaymentRequired, code: rs.CodeNoCredits, message: "Insufficient credits"}
+	}
+
+	ttl := spec.cacheTTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	seed := spec.url
+	if seed == "" {
+		seed = spec.html
+	}
+	entry := &cacheEntry{
+		key:     key,
+		url:     spec.url,
+		format:  spec.format,
+		width:   spec.width,
+		height:  spec.height,
+		data:    placeholder(spec.format, spec.width, spec.height, seed),
+		created: now,
+		expires: now.Add(ttl),
+		tags:    spec.tags,
+	}
+	s.cache[key] = entry
+	s.used++
+	s.captures = append(s.captures, capture{at: now, format: spec.format, preset: spec.preset, tags: spec.tags})
+	return entry, false, nil
+}
+
+func (s *Server) screenshot(params map[string]interface{}, accept string) *response {
+	spec := s.specFromParams(params)
+	entry, hit, apiErr := s.render(spec)
+	if apiErr != nil {
+		return apiErr.response()
+	}
+
+	var resp *response
+	if strings.Contains(accept, "application/json") {
+		body := map[string]interface{}{
+			"id":     "scr_" + entry.key,
+			"status": "completed",
+			"image": map[string]interface{}{
+				"url":    s.URL + "/v1/cache/" + entry.key,
+				"width":  entry.width,
+				"height": entry.height,
+			},
+			"cache": map[string]interface{}{"hit": hit, "key": entry.key},
+		}
+		if len(spec.tags) > 0 {
+			body["tags"] = spec.tags
+		}
+		resp = jsonResponse(http.StatusOK, body)
+	} else {
+		resp = binaryResponse(entry)
+	}
+	s.setCreditHeaders(resp, hit)
+	return resp
+}
+
+// signedScreenshot serves GET /v1/screenshot for URLs built by GenerateURL.
+func (s *Server) signedScreenshot(q url.Values) *response {
+	signature := q.Get("signature")
+	if signature == "" {
+		return errorResponse(http.StatusUnauthorized, rs.CodeUnauthorized, "Missing signature")
+	}
+	if q.Get("key_id") != s.publicKeyID {
+		return errorResponse(http.StatusUnauthorized, rs.CodeUnauthorized, "Unknown key_id")
+	}
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, rs.CodeInvalidRequest, "Invalid expires parameter")
+	}
+	if s.now().Unix() > expires {
+		return errorResponse(http.StatusUnauthorized, rs.CodeExpiredSig, "Signed URL has expired")
+	}
+
+	keys := make([]string, 0, len(q))
+	for k, v := range q {
+		if len(v) > 1 {
+			return errorResponse(http.StatusBadRequest, rs.CodeInvalidRequest, "Duplicate parameter: "+k)
+		}
+		if k != "signature" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+url.QueryEscape(q.Get(k)))
+	}
+	mac := hmac.New(sha256.New, []byte(s.signingKey))
+	mac.Write([]byte(strings.Join(parts, "&")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errorResponse(http.StatusForbidden, rs.CodeForbidden, "Invalid signature")
+	}
+
+	entry, hit, apiErr := s.render(s.specFromQuery(q))
+	if apiErr != nil {
+		return apiErr.response()
+	}
+	resp := binaryResponse(entry)
+	s.setCreditHeaders(resp, hit)
+	return resp
+}
+
+func binaryResponse(entry *cacheEntry) *response {
+	header := http.Header{}
+	header.Set("Content-Type", contentType(entry.format))
+	return &response{status: http.StatusOK, header: header, body: entry.data}
+}
+
+func (s *Server) setCreditHeaders(resp *response, hit bool) {
+	used := 1
+	if hit {
+		used = 0
+	}
+	s.mu.Lock()
+	remaining := s.credits - s.used
+	s.mu.Unlock()
+	resp.header.Set(rs.CreditsUsedHeader, strconv.Itoa(used))
+	resp.header.Set(rs.CreditsRemainingHeader, strconv.Itoa(remaining))
+}
+
+func (s *Server) createBatch(params map[string]interface{}) *response {
+	var specs []renderSpec
+	if urls, ok := params["urls"].([]interface{}); ok {
+		options, _ := params["options"].(map[string]interface{})
+		for _, u := range urls {
+			p := map[string]interface{}{}
+			for k, v := range options {
+				p[k] = v
+			}
+			p["url"], _ = u.(string)
+			specs = append(specs, s.specFromParams(p))
+		}
+	} else if reqs, ok := params["requests"].([]interface{}); ok {
+		for _, item := range reqs {
+			if p, ok := item.(map[string]interface{}); ok {
+				specs = append(specs, s.specFromParams(p))
+			}
+		}
+	}
+	if len(specs) == 0 {
+		return errorResponse(http.StatusBadRequest, rs.CodeMissingRequired, "urls or requests is required")
+	}
+
+	s.mu.Lock()
+	b := &batch{id: fmt.Sprintf("batch_%d", len(s.batches)+1)}
+	for _, spec := range specs {
+		b.items = append(b.items, &batchItem{spec: spec, status: "pending"})
+	}
+	s.batches[b.id] = b
+	s.mu.Unlock()
+
+	return jsonResponse(http.StatusOK, s.batchBody(b))
+}
+
+// getBatch advances the batch by the configured step and returns its status.
+func (s *Server) getBatch(id string) *response {
+	s.mu.Lock()
+	b, ok := s.batches[id]
+	s.mu.Unlock()
+	if !ok {
+		return errorResponse(http.StatusNotFound, rs.CodeNotFound, "Batch not found: "+id)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	advanced := 0
+	for _, item := range b.items {
+		if advanced >= s.batchStep {
+			break
+		}
+		if item.status != "pending" {
+			continue
+		}
+		advanced++
+		entry, _, apiErr := s.render(item.spec)
+		if apiErr != nil {
+			item.status = "failed"
+			item.err = apiErr.message
+			continue
+		}
+		item.status = "completed"
+		item.key = entry.key
+	}
+	return jsonResponse(http.StatusOK, s.batchBody(b))
+}
+
+func (s *Server) batchBody(b *batch) map[string]interface{} {
+	completed, failed := 0, 0
+	results := make([]map[string]interface{}, 0, len(b.items))
+	for _, item := range b.items {
+		result := map[string]interface{}{"url": item.spec.url, "status": item.status}
+		switch item.status {
+		case "completed":
+			completed++
+			result["image_url"] = s.URL + "/v1/cache/" + item.key
+		case "failed":
+			failed++
+			result["error"] = item.err
+		}
+		if len(item.spec.tags) > 0 {
+			result["tags"] = item.spec.tags
+		}
+		results = append(results, result)
+	}
+
+	status := "processing"
+	if completed+failed == len(b.items) {
+		status = "completed"
+		if completed == 0 {
+			status = "failed"
+		}
+	}
+	return map[string]interface{}{
+		"id":        b.id,
+		"status":    status,
+		"total":     len(b.items),
+		"completed": completed,
+		"failed":    failed,
+		"results":   results,
+	}
+}
+
+func stringParam(m map[string]interface{}, key string) string {
+	v, _ := m[key].(string)
+	return v
+}
+
+func intParam(m map[string]interface{}, key string) int {
+	v, _ := m[key].(float64)
+	return int(v)
+}
+
+func tagsParam(v interface{}) map[string]string {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(m))
+	for k, val := range m {
+		if str, ok := val.(string); ok {
+			tags[k] = str
+		}
+	}
+	return tags
+}
@@ -0,0 +1,127 @@
+package rstest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// maxDimension caps placeholder width and height.
+const maxDimension = 8192
+
+// contentType returns the MIME type of a capture format.
+func contentType(format string) string {
+	switch rs.ImageFormat(format) {
+	case rs.FormatJPEG:
+		return "image/jpeg"
+	case rs.FormatWebP:
+		return "image/webp"
+	case rs.FormatPDF:
+		return "application/pdf"
+	}
+	return "image/png"
+}
+
+// placeholder renders a solid-colour image of the given size. The colour is
+// derived from seed (usually the captured URL), so different pages produce
+// different images while the same page is always identical.
+//
+// PNG and JPEG output is fully decodable. WebP output is a header-only
+// RIFF/VP8X file that carries the dimensions but no pixel data, and PDF
+// output is a single blank page of width x height points.
+func placeholder(format string, width, height int, seed string) []byte {
+	width = clampDimension(width)
+	height = clampDimension(height)
+
+	sum := sha256.Sum256([]byte(seed))
+	fill := color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 255}
+
+	switch rs.ImageFormat(format) {
+	case rs.FormatJPEG:
+		var buf bytes.Buffer
+		_ = jpeg.Encode(&buf, solidImage(width, height, fill), &jpeg.Options{Quality: 80})
+		return buf.Bytes()
+	case rs.FormatWebP:
+		return webpHeader(width, height)
+	case rs.FormatPDF:
+		return blankPDF(width, height)
+	}
+	// A single-colour paletted image encodes much faster than RGBA.
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{fill})
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func clampDimension(n int) int {
+	if n < 1 {
+		return 1
+	}
+	if n > maxDimension {
+		return maxDimension
+	}
+	return n
+}
+
+func solidImage(width, height int, fill color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = fill.R
+		img.Pix[i+1] = fill.G
+		img.Pix[i+2] = fill.B
+		img.Pix[i+3] = fill.A
+	}
+	return img
+}
+
+// webpHeader returns a RIFF container with a single VP8X chunk.
+func webpHeader(width, height int) []byte {
+	chunk := make([]byte, 10)
+	putUint24(chunk[4:7], uint32(width-1))
+	putUint24(chunk[7:10], uint32(height-1))
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(4+8+len(chunk)))
+	buf.WriteString("WEBPVP8X")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(chunk)))
+	buf.Write(chunk)
+	return buf.Bytes()
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
+
+// blankPDF returns a minimal single-page PDF with a valid xref table.
+func blankPDF(width, height int) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] >>", width, height),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,85 @@
+package rstest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestPlaceholderDimensions(t *testing.T) {
+	for _, format := range []string{"png", "jpeg"} {
+		cfg, got, err := image.DecodeConfig(bytes.NewReader(placeholder(format, 64, 32, "seed")))
+		if err != nil {
+			t.Fatalf("%s: decode: %v", format, err)
+		}
+		if got != format || cfg.Width != 64 || cfg.Height != 32 {
+			t.Errorf("%s: got %s %dx%d", format, got, cfg.Width, cfg.Height)
+		}
+	}
+}
+
+func TestPlaceholderDeterministic(t *testing.T) {
+	a := placeholder("png", 8, 8, "https://a.com")
+	if !bytes.Equal(a, placeholder("png", 8, 8, "https://a.com")) {
+		t.Error("expected identical output for the same seed")
+	}
+	if bytes.Equal(a, placeholder("png", 8, 8, "https://b.com")) {
+		t.Error("expected different output for different seeds")
+	}
+}
+
+func TestPlaceholderWebP(t *testing.T) {
+	data := placeholder("webp", 300, 200, "")
+	if string(data[0:4]) != "RIFF" || string(data[8:16]) != "WEBPVP8X" {
+		t.Fatalf("unexpected header %q", data[:16])
+	}
+	if size := binary.LittleEndian.Uint32(data[4:8]); int(size) != len(data)-8 {
+		t.Errorf("RIFF size = %d, want %d", size, len(data)-8)
+	}
+	width := int(data[24]) | int(data[25])<<8 | int(data[26])<<16
+	height := int(data[27]) | int(data[28])<<8 | int(data[29])<<16
+	if width+1 != 300 || height+1 != 200 {
+		t.Errorf("canvas = %dx%d, want 300x200", width+1, height+1)
+	}
+}
+
+func TestPlaceholderPDF(t *testing.T) {
+	pdf := string(placeholder("pdf", 595, 842, ""))
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Errorf("unexpected PDF framing: %q", pdf)
+	}
+	if !strings.Contains(pdf, "/MediaBox [0 0 595 842]") {
+		t.Error("expected MediaBox with requested size")
+	}
+}
+
+func TestPlaceholderClampsDimensions(t *testing.T) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(placeholder("png", 0, 100000, "")))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if cfg.Width != 1 || cfg.Height != maxDimension {
+		t.Errorf("size = %dx%d, want 1x%d", cfg.Width, cfg.Height, maxDimension)
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"https://a.com/*", "https://a.com/x/y", true},
+		{"https://a.com/*", "https://b.com/x", false},
+		{"*.png", "shot.png", true},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"exact", "exact", true},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+// Package rstest provides an in-process fake of the RenderScreenshot API for
+// tests.
+//
+// The fake implements the screenshot, batch, cache, presets, devices and
+// usage endpoints with in-memory state. Captures return placeholder images of
+// the requested dimensions, batches progress on every poll, signed URLs are
+// validated, and failures (rate limits, 5xx, slow responses) can be scripted
+// to exercise retry paths:
+//
+//	srv := rstest.NewServer()
+//	defer srv.Close()
+//
+//	client := srv.Client()
+//	srv.FailNext(rstest.Failure{Status: 503, Times: 2})
+//	img, err := client.Take(ctx, rs.URL("https://example.com"))
+package rstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// Default credentials accepted by the fake server.
+const (
+	DefaultAPIKey      = "rs_test_key"
+	DefaultSigningKey  = "rs_secret_test"
+	DefaultPublicKeyID = "rs_pub_test"
+)
+
+// DefaultCredits is the default credit allowance of the fake account.
+const DefaultCredits = 10000
+
+// Default viewport size used when a request does not specify one.
+const (
+	defaultWidth  = 1280
+	defaultHeight = 720
+)
+
+// Failure is a scripted error response.
+type Failure struct {
+	// Path restricts the failure to requests whose path starts with Path,
+	// e.g. "/v1/screenshot". Empty matches every request.
+	Path string
+	// Status is the HTTP status to respond with. Defaults to 500.
+	Status int
+	// RetryAfter sets the Retry-After header in seconds.
+	RetryAfter int
+	// Code overrides the error code in the response body.
+	Code rs.ErrorCode
+	// Delay holds the response back, to trigger client timeouts. The
+	// response is abandoned if the client disconnects first.
+	Delay time.Duration
+	// Times is the number of requests to fail. Defaults to 1.
+	Times int
+}
+
+// Request is a request received by the fake server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithAPIKey sets the API key the server accepts.
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithSigningKeys sets the secret and public key ID used to validate signed URLs.
+func WithSigningKeys(signingKey, publicKeyID string) Option {
+	return func(s *Server) {
+		s.signingKey = signingKey
+		s.publicKeyID = publicKeyID
+	}
+}
+
+// WithCredits sets the credit allowance of the fake account.
+func WithCredits(n int) Option {
+	return func(s *Server) {
+		s.credits = n
+	}
+}
+
+// WithBatchStep sets how many batch items complete on each status poll.
+// Defaults to 1.
+func WithBatchStep(n int) Option {
+	return func(s *Server) {
+		s.batchStep = n
+	}
+}
+
+// WithPresets replaces the built-in presets.
+func WithPresets(presets []rs.PresetInfo) Option {
+	return func(s *Server) {
+		s.presets = presets
+	}
+}
+
+// WithDevices replaces the built-in devices.
+func WithDevices(devices []rs.DeviceInfo) Option {
+	return func(s *Server) {
+		s.devices = devices
+	}
+}
+
+// Server is a fake RenderScreenshot API backed by an httptest.Server.
+// It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	apiKey      string
+	signingKey  string
+	publicKeyID string
+	credits     int
+	batchStep   int
+	presets     []rs.PresetInfo
+	devices     []rs.DeviceInfo
+	now         func() time.Time
+
+	mu          sync.Mutex
+	seq         int
+	used        int
+	requests    []Request
+	failures    []Failure
+	failURLs    map[string]string
+	cache       map[string]*cacheEntry
+	batches     map[string]*batch
+	captures    []capture
+	idempotency map[string]*idempotentEntry
+}
+
+// NewServer starts a fake API server. Call Close when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		apiKey:      DefaultAPIKey,
+		signingKey:  DefaultSigningKey,
+		publicKeyID: DefaultPublicKeyID,
+		credits:     DefaultCredits,
+		batchStep:   1,
+		presets:     defaultPresets(),
+		devices:     defaultDevices(),
+		now:         time.Now,
+		failURLs:    map[string]string{},
+		cache:       map[string]*cacheEntry{},
+		batches:     map[string]*batch{},
+		idempotency: map[string]*idempotentEntry{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns a client configured for the server: its URL, API key and
+// signing keys, and a short retry delay. opts are applied afterwards.
+func (s *Server) Client(opts ...rs.Option) *rs.Client {
+	all := append([]rs.Option{
+		rs.WithBaseURL(s.URL),
+		rs.WithSigningKey(s.signingKey),
+		rs.WithPublicKeyID(s.publicKeyID),
+		rs.WithRetryDelay(0.01),
+	}, opts...)
+	client, err := rs.New(s.apiKey, all...)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// FailNext scripts f for the next f.Times matching requests. Failures are
+// consumed in the order they were added.
+func (s *Server) FailNext(f Failure) {
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	if f.Status == 0 {
+		f.Status = http.StatusInternalServerError
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, f)
+}
+
+// FailURL makes every capture of pageURL fail with a render_failed error,
+// both for direct screenshots and inside batches.
+func (s *Server) FailURL(pageURL, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failURLs[pageURL] = message
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// CreditsUsed returns the number of credits consumed by captures.
+func (s *Server) CreditsUsed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.used
+}
+
+// response is a buffered API response.
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.seq++
+	requestID := fmt.Sprintf("req_%d", s.seq)
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	failure, failed := s.nextFailure(r.URL.Path)
+	s.mu.Unlock()
+
+	var resp *response
+	if failed {
+		if failure.Delay > 0 {
+			timer := time.NewTimer(failure.Delay)
+			select {
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+		resp = failureResponse(failure)
+	} else {
+		resp = s.route(r, body)
+	}
+
+	for k, v := range resp.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("X-Request-Id", requestID)
+	w.WriteHeader(resp.status)
+	_, _ = w.Write(resp.body)
+}
+
+// nextFailure pops the first scripted failure matching path. Callers must hold s.mu.
+func (s *Server) nextFailure(path string) (Failure, bool) {
+	for i, f := range s.failures {
+		if f.Path != "" && !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+		s.failures[i].Times--
+		if s.failures[i].Times <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		}
+		return f, true
+	}
+	return Failure{}, false
+}
+
+func (s *Server) route(r *http.Request, body []byte) *response {
+	path := r.URL.Path
+
+	// Signed URLs are authenticated by their signature instead of the API key.
+	if path == "/v1/screenshot" && r.Method == http.MethodGet {
+		return s.signedScreenshot(r.URL.Query())
+	}
+	if r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		return errorResponse(http.StatusUnauthorized, rs.CodeInvalidAPIKey, "Invalid or missing API key")
+	}
+
+	var params map[string]interface{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &params); err != nil {
+			return errorResponse(http.StatusBadRequest, rs.CodeInvalidRequest, "Invalid JSON body: "+err.Error())
+		}
+	}
+
+	switch {
+	case path == "/v1/screenshot" && r.Method == http.MethodPost:
+		return s.idempotent(r, func() *response { return s.screenshot(params, r.Header.Get("Accept")) })
+	case path == "/v1/batch" && r.Method == http.MethodPost:
+		return s.idempotent(r, func() *response { return s.createBatch(params) })
+	case strings.HasPrefix(path, "/v1/batch/") && r.Method == http.MethodGet:
+		return s.getBatch(pathParam(r, "/v1/batch/"))
+	case path == "/v1/cache" && r.Method == http.MethodGet:
+		return s.listCache(r.URL.Query())
+	case path == "/v1/cache/purge" && r.Method == http.MethodPost:
+		return s.purgeCache(params)
+	case strings.HasPrefix(path, "/v1/cache/") && r.Method == http.MethodGet:
+		return s.getCache(pathParam(r, "/v1/cache/"))
+	case strings.HasPrefix(path, "/v1/cache/") && r.Method == http.MethodDelete:
+		return s.deleteCache(pathParam(r, "/v1/cache/"))
+	case path == "/v1/presets" && r.Method == http.MethodGet:
+		return jsonResponse(http.StatusOK, map[string]interface{}{"presets": s.presets})
+	case strings.HasPrefix(path, "/v1/presets/") && r.Method == http.MethodGet:
+		return s.getPreset(pathParam(r, "/v1/presets/"))
+	case path == "/v1/devices" && r.Method == http.MethodGet:
+		return jsonResponse(http.StatusOK, map[string]interface{}{"devices": s.devices})
+	case path == "/v1/usage" && r.Method == http.MethodGet:
+		return s.usage(r.URL.Query())
+	case path == "/v1/usage/history" && r.Method == http.MethodGet:
+		return s.usageHistory(r.URL.Query())
+	}
+	return errorResponse(http.StatusNotFound, rs.CodeNotFound, fmt.Sprintf("No route for %s %s", r.Method, path))
+}
+
+// idempotentEntry is the response for an Idempotency-Key; done is closed
+// once resp is set or the first attempt failed.
+type idempotentEntry struct {
+	done chan struct{}
+	resp *response
+}
+
+// idempotent replays the stored response for a repeated Idempotency-Key.
+// A request arriving while the first one with its key is in flight waits
+// for it, so concurrent duplicates perform the work once.
+func (s *Server) idempotent(r *http.Request, handle func() *response) *response {
+	key := r.Header.Get(rs.IdempotencyKeyHeader)
+	if key == "" {
+		return handle()
+	}
+
+	entry := &idempotentEntry{done: make(chan struct{})}
+	for {
+		s.mu.Lock()
+		stored, ok := s.idempotency[key]
+		if !ok {
+			s.idempotency[key] = entry
+		}
+		s.mu.Unlock()
+		if !ok {
+			break
+		}
+		<-stored.done
+		if stored.resp != nil {
+			replay := &response{status: stored.resp.status, header: stored.resp.header.Clone(), body: stored.resp.body}
+			replay.header.Set(rs.IdempotentReplayedHeader, "true")
+			return replay
+		}
+		// The first attempt failed and was not stored; try again.
+	}
+
+	resp := handle()
+	resp.header.Set(rs.IdempotencyKeyHeader, key)
+	s.mu.Lock()
+	if resp.status < 500 {
+		entry.resp = resp
+	} else {
+		delete(s.idempotency, key)
+	}
+	s.mu.Unlock()
+	close(entry.done)
+	return resp
+}
+
+// pathParam returns the unescaped path segment following prefix.
+func pathParam(r *http.Request, prefix string) string {
+	raw := strings.TrimPrefix(r.URL.EscapedPath(), prefix)
+	v, err := url.PathUnescape(raw)
+	if err != nil {
+		return raw
+	}
+	return v
+}
+
+func jsonResponse(status int, v interface{}) *response {
+	var buf bytes.Buffer
+	_ = json.NewEncoder(&buf).Encode(v)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	return &response{status: status, header: header, body: buf.Bytes()}
+}
+
+// apiError is an error response in the API's error envelope.
+type apiError struct {
+	status  int
+	code    rs.ErrorCode
+	message string
+	details []map[string]interface{}
+}
+
+func (e *apiError) response() *response {
+	body := map[string]interface{}{
+		"code":    string(e.code),
+		"message": e.message,
+	}
+	if len(e.details) > 0 {
+		body["details"] = e.details
+	}
+	return jsonResponse(e.status, map[string]interface{}{"error": body})
+}
+
+func errorResponse(status int, code rs.ErrorCode, message string) *response {
+	return (&apiError{status: status, code: code, message: message}).response()
+}
+
+func failureResponse(f Failure) *response {
+	code := f.Code
+	if code == "" {
+		switch {
+		case f.Status == http.StatusTooManyRequests:
+			code = rs.CodeRateLimited
+		case f.Status == http.StatusGatewayTimeout || f.Status == http.StatusRequestTimeout:
+			code = rs.CodeTimeout
+		default:
+			code = rs.CodeInternalError
+		}
+	}
+	resp := errorResponse(f.Status, code, "Scripted failure: "+http.StatusText(f.Status))
+	if f.RetryAfter > 0 {
+		resp.header.Set("Retry-After", fmt.Sprintf("%d", f.RetryAfter))
+	}
+	return resp
+}
+
+func defaultPresets() []rs.PresetInfo {
+	return []rs.PresetInfo{
+		{ID: "og_card", Name: "Open Graph Card", Width: 1200, Height: 630},
+		{ID: "twitter_card", Name: "Twitter Card", Width: 1200, Height: 600},
+		{ID: "full_hd", Name: "Full HD", Width: 1920, Height: 1080},
+	}
+}
+
+func defaultDevices() []rs.DeviceInfo {
+	return []rs.DeviceInfo{
+		{ID: "iphone_15", Name: "iPhone 15", Width: 393, Height: 852},
+		{ID: "pixel_8", Name: "Pixel 8", Width: 412, Height: 915},
+		{ID: "ipad_pro", Name: "iPad Pro", Width: 1024, Height: 1366},
+	}
+}
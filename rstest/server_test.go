@@ -0,0 +1,445 @@
+package rstest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+func TestTakeReturnsPlaceholderOfRequestedSize(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	tests := []struct {
+		name       string
+		opts       *rs.TakeOptions
+		wantWidth  int
+		wantHeight int
+		wantFormat string
+	}{
+		{"default viewport", rs.URL("https://example.com"), 1280, 720, "png"},
+		{"explicit size", rs.URL("https://example.com").Width(800).Height(600).Format(rs.FormatJPEG), 800, 600, "jpeg"},
+		{"preset", rs.URL("https://example.com").Preset("og_card"), 1200, 630, "png"},
+		{"device", rs.URL("https://example.com").Device("iphone_15"), 393, 852, "png"},
+		{"scale", rs.URL("https://example.com").Width(100).Height(50).Scale(2), 200, 100, "png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := client.Take(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if cfg.Width != tt.wantWidth || cfg.Height != tt.wantHeight {
+				t.Errorf("size = %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantWidth, tt.wantHeight)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestTakeJSONCachesCaptures(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+	opts := rs.URL("https://example.com").Tags(map[string]string{"tenant": "acme"})
+
+	first, err := client.TakeJSON(ctx, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := client.TakeJSON(ctx, opts)
+	if first.Cache.Hit || !second.Cache.Hit {
+		t.Errorf("cache hits = %v, %v; want false, true", first.Cache.Hit, second.Cache.Hit)
+	}
+	if first.Tags["tenant"] != "acme" {
+		t.Errorf("Tags = %v", first.Tags)
+	}
+	if srv.CreditsUsed() != 1 {
+		t.Errorf("CreditsUsed() = %d, want 1", srv.CreditsUsed())
+	}
+
+	data, err := client.Cache().Get(ctx, first.Cache.Key)
+	if err != nil || len(data) == 0 {
+		t.Errorf("Cache().Get() = %d bytes, %v", len(data), err)
+	}
+	list, _ := client.Cache().List(ctx, rs.CacheListOptions{Tags: map[string]string{"tenant": "acme"}})
+	if len(list.Entries) != 1 || list.Entries[0].URL != "https://example.com" {
+		t.Errorf("List() = %+v", list)
+	}
+
+	deleted, _ := client.Cache().Delete(ctx, first.Cache.Key)
+	if !deleted {
+		t.Error("Delete() = false, want true")
+	}
+	if data, _ := client.Cache().Get(ctx, first.Cache.Key); data != nil {
+		t.Error("expected entry to be gone after Delete")
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	for _, u := range []string{"https://a.com/x", "https://a.com/y", "https://b.com/"} {
+		if _, err := client.Take(ctx, rs.URL(u)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	result, err := client.Cache().PurgeURL(ctx, "https://a.com/*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Purged != 2 {
+		t.Errorf("Purged = %d, want 2", result.Purged)
+	}
+	list, _ := client.Cache().List(ctx, rs.CacheListOptions{})
+	if len(list.Entries) != 1 {
+		t.Errorf("remaining entries = %d, want 1", len(list.Entries))
+	}
+}
+
+func TestCacheListPagination(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	for _, u := range []string{"https://a.com", "https://b.com", "https://c.com"} {
+		_, _ = client.Take(ctx, rs.URL(u))
+	}
+
+	var seen int
+	opts := rs.CacheListOptions{Limit: 2}
+	for {
+		page, err := client.Cache().List(ctx, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		seen += len(page.Entries)
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if seen != 3 {
+		t.Errorf("entries across pages = %d, want 3", seen)
+	}
+}
+
+func TestBatchProgress(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.FailURL("https://broken.example.com", "Navigation timeout")
+	client := srv.Client()
+	ctx := context.Background()
+
+	batch, err := client.Batch(ctx, []string{"https://a.com", "https://broken.example.com", "https://c.com"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.Status != "processing" || batch.Total != 3 {
+		t.Errorf("initial batch = %+v", batch)
+	}
+
+	var statuses []string
+	for i := 0; i < 3; i++ {
+		b, err := client.GetBatch(ctx, batch.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		statuses = append(statuses, b.Status)
+		batch = b
+	}
+	if statuses[0] != "processing" || statuses[2] != "completed" {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if batch.Completed != 2 || batch.Failed != 1 {
+		t.Errorf("completed = %d, failed = %d; want 2, 1", batch.Completed, batch.Failed)
+	}
+	if batch.Results[1].Error != "Navigation timeout" || batch.Results[0].ImageURL == "" {
+		t.Errorf("results = %+v", batch.Results)
+	}
+
+	if _, err := client.GetBatch(ctx, "batch_missing"); !rs.IsNotFound(err) {
+		t.Errorf("GetBatch(missing) err = %v, want not found", err)
+	}
+}
+
+func TestBatchAdvancedPerURLOptions(t *testing.T) {
+	srv := NewServer(WithBatchStep(10))
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	batch, err := client.BatchAdvanced(ctx, []rs.BatchRequest{
+		{URL: "https://a.com", Options: rs.URL("https://a.com").Format(rs.FormatJPEG)},
+		{URL: "https://b.com"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done, _ := client.GetBatch(ctx, batch.ID)
+	if done.Status != "completed" || done.Completed != 2 {
+		t.Errorf("batch = %+v", done)
+	}
+}
+
+func TestScriptedFailuresAreRetried(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client(rs.WithMaxRetries(2))
+
+	srv.FailNext(Failure{Path: "/v1/screenshot", Status: http.StatusServiceUnavailable, Times: 2})
+	if _, err := client.Take(context.Background(), rs.URL("https://example.com")); err != nil {
+		t.Fatalf("unexpected error after retries: %v", err)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
+
+func TestScriptedRateLimit(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client(rs.WithMaxRetries(0))
+
+	srv.FailNext(Failure{Status: http.StatusTooManyRequests, RetryAfter: 7})
+	_, err := client.Usage(context.Background())
+	var apiErr *rs.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *rs.Error", err)
+	}
+	if apiErr.Code != rs.CodeRateLimited || apiErr.RetryAfter != 7 {
+		t.Errorf("error = %+v", apiErr)
+	}
+
+	if _, err := client.Usage(context.Background()); err != nil {
+		t.Errorf("failure should be consumed, got %v", err)
+	}
+}
+
+func TestScriptedTimeout(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client(rs.WithTimeout(200*time.Millisecond), rs.WithMaxRetries(1))
+
+	srv.FailNext(Failure{Delay: 5 * time.Second})
+	if _, err := client.Take(context.Background(), rs.URL("https://example.com").Width(10).Height(10)); err != nil {
+		t.Fatalf("expected retry after timeout to succeed, got %v", err)
+	}
+}
+
+func TestAuthAndValidation(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	wrongKey, _ := rs.New("rs_wrong", rs.WithBaseURL(srv.URL), rs.WithMaxRetries(0))
+	if _, err := wrongKey.Usage(context.Background()); !rs.IsAuthentication(err) {
+		t.Errorf("err = %v, want authentication error", err)
+	}
+
+	_, err := srv.Client().Take(context.Background(), rs.URL(""))
+	var apiErr *rs.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != rs.CodeMissingRequired || len(apiErr.Details) != 1 {
+		t.Errorf("err = %#v, want missing_required with details", err)
+	}
+}
+
+func TestSignedURLs(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	opts := rs.URL("https://example.com").Width(300).Height(200)
+
+	signed, err := client.GenerateURL(opts, time.Now().Add(time.Hour), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.StatusCode, data)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width != 300 || cfg.Height != 200 {
+		t.Errorf("image = %+v, %v", cfg, err)
+	}
+
+	tests := []struct {
+		name string
+		url  func() string
+		want int
+	}{
+		{"tampered", func() string { return strings.Replace(signed, "width=300", "width=3000", 1) }, http.StatusForbidden},
+		{"duplicated param", func() string { return signed + "&width=3000" }, http.StatusBadRequest},
+		{"expired", func() string {
+			u, _ := client.GenerateURL(opts, time.Now().Add(-time.Minute), "", "")
+			return u
+		}, http.StatusUnauthorized},
+		{"wrong secret", func() string {
+			u, _ := client.GenerateURL(opts, time.Now().Add(time.Hour), "rs_secret_other", "")
+			return u
+		}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(tt.url())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := rs.ContextWithIdempotencyKey(context.Background(), "import-1")
+
+	first, _ := client.TakeJSON(ctx, rs.URL("https://example.com"))
+	second, err := client.TakeJSON(ctx, rs.URL("https://example.com"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Meta.Replayed || !second.Meta.Replayed {
+		t.Errorf("Replayed = %v, %v; want false, true", first.Meta.Replayed, second.Meta.Replayed)
+	}
+	if second.Cache.Hit {
+		t.Error("replayed response should match the original, not a cache hit")
+	}
+}
+
+func TestIdempotentConcurrentDuplicates(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	// Hold the server's lock until every duplicate is waiting for it, so
+	// all of them look their key up before the first one is captured.
+	srv.mu.Lock()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	replayed := 0
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/screenshot", strings.NewReader(`{"url":"https://example.com"}`))
+			req.Header.Set("Authorization", "Bearer "+DefaultAPIKey)
+			req.Header.Set(rs.IdempotencyKeyHeader, "hedged-1")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.Header.Get(rs.IdempotentReplayedHeader) == "true" {
+				mu.Lock()
+				replayed++
+				mu.Unlock()
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	srv.mu.Unlock()
+	wg.Wait()
+	if replayed != 4 {
+		t.Errorf("replayed = %d, want 4", replayed)
+	}
+	if n := len(srv.Requests()); n != 5 {
+		t.Errorf("requests = %d, want 5", n)
+	}
+}
+
+func TestMetadataAndUsage(t *testing.T) {
+	srv := NewServer(WithCredits(50))
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	presets, err := client.Presets(ctx)
+	if err != nil || len(presets) == 0 {
+		t.Fatalf("Presets() = %v, %v", presets, err)
+	}
+	preset, err := client.Preset(ctx, "og_card")
+	if err != nil || preset.Width != 1200 {
+		t.Errorf("Preset() = %+v, %v", preset, err)
+	}
+	if _, err := client.Preset(ctx, "nope"); !rs.IsNotFound(err) {
+		t.Errorf("Preset(nope) err = %v, want not found", err)
+	}
+	devices, _ := client.Devices(ctx)
+	if len(devices) == 0 {
+		t.Error("expected devices")
+	}
+
+	_, _ = client.Take(ctx, rs.URL("https://a.com").Preset("og_card"))
+	_, _ = client.Take(ctx, rs.URL("https://b.com").Format(rs.FormatPDF).Tags(map[string]string{"team": "docs"}))
+
+	usage, err := client.Usage(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage.Credits != 50 || usage.Used != 2 || usage.Remaining != 48 {
+		t.Errorf("usage = %+v", usage)
+	}
+	tagged, _ := client.UsageForTags(ctx, map[string]string{"team": "docs"})
+	if tagged.Used != 1 {
+		t.Errorf("tagged usage = %d, want 1", tagged.Used)
+	}
+
+	today := time.Now()
+	history, err := client.UsageHistory(ctx, today.AddDate(0, 0, -1), today, rs.GranularityDay)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	totals := history.Totals()
+	if totals.Credits != 2 || totals.ByFormat["pdf"] != 1 || totals.ByPreset["og_card"] != 1 {
+		t.Errorf("totals = %+v", totals)
+	}
+}
+
+func TestInsufficientCredits(t *testing.T) {
+	srv := NewServer(WithCredits(1))
+	defer srv.Close()
+	client := srv.Client(rs.WithMaxRetries(0))
+	ctx := context.Background()
+
+	if _, err := client.Take(ctx, rs.URL("https://a.com")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Take(ctx, rs.URL("https://b.com")); !errors.Is(err, rs.ErrNoCredits) {
+		t.Errorf("err = %v, want ErrNoCredits", err)
+	}
+}
@@ -0,0 +1,327 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+)
+
+// DefaultBatchSize is the number of pages Submit sends per batch.
+const DefaultBatchSize = 100
+
+// Crawler walks a sitemap, following sitemap indexes, and selects pages.
+// The zero value returns every page.
+type Crawler struct {
+	// Include lists globs a page must match at least one of, if non-empty.
+	// Exclude lists globs that drop a page. Patterns containing "://" match
+	// the full URL; others match the URL path. "*" and "?" do not match
+	// "/", while "**" matches anything, so "/blog/**" selects every page
+	// under /blog/.
+	Include []string
+	Exclude []string
+	// ModifiedSince, if set, drops pages whose lastmod is earlier. Index
+	// entries with an earlier lastmod are not fetched at all. Entries
+	// without a lastmod are kept.
+	ModifiedSince time.Time
+	// MaxPages stops the crawl after this many pages. Zero means no limit.
+	MaxPages int
+	// Fetch opens a sitemap location. Defaults to Fetch, an HTTP GET for
+	// http and https URLs and reading the file for file URLs and plain
+	// paths. Child sitemaps of an http or https sitemap must be http or
+	// https too, so a remote index cannot make the crawler read local files.
+	Fetch func(ctx context.Context, loc string) (io.ReadCloser, error)
+	// Options, if set, is applied to the options of every batch request.
+	Options func(*rs.TakeOptions)
+	// BatchSize is the number of pages per batch for Submit. Defaults to
+	// DefaultBatchSize.
+	BatchSize int
+	// OnBatch, if set, is called by Submit after each batch is submitted.
+	OnBatch func(*rs.BatchResponse)
+}
+
+// errDone stops a walk once MaxPages is reached.
+var errDone = errors.New("sitemap: page limit reached")
+
+// Walk calls fn for each selected page in sitemap order, fetching child
+// sitemaps of an index as it reaches them. Pages listed more than once are
+// reported once. An error from fn stops the walk and is returned.
+func (c *Crawler) Walk(ctx context.Context, loc string, fn func(Entry) error) error {
+	w := &walker{
+		crawler: c,
+		include: compileGlobs(c.Include),
+		exclude: compileGlobs(c.Exclude),
+		fn:      fn,
+		visited: map[string]bool{},
+		seen:    map[string]bool{},
+	}
+	err := w.walk(ctx, loc)
+	if errors.Is(err, errDone) {
+		return nil
+	}
+	return err
+}
+
+// Crawl returns the selected pages.
+func (c *Crawler) Crawl(ctx context.Context, loc string) ([]Entry, error) {
+	var pages []Entry
+	err := c.Walk(ctx, loc, func(e Entry) error {
+		pages = append(pages, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// Requests crawls loc and returns one batch request per selected page.
+func (c *Crawler) Requests(ctx context.Context, loc string) ([]rs.BatchRequest, error) {
+	pages, err := c.Crawl(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	return c.BatchRequests(pages), nil
+}
+
+// BatchRequests converts pages to batch requests with Options applied.
+func (c *Crawler) BatchRequests(pages []Entry) []rs.BatchRequest {
+	requests := make([]rs.BatchRequest, len(pages))
+	for i, p := range pages {
+		requests[i] = c.request(p)
+	}
+	return requests
+}
+
+func (c *Crawler) request(p Entry) rs.BatchRequest {
+	o := rs.URL(p.URL)
+	if c.Options != nil {
+		c.Options(o)
+	}
+	return rs.BatchRequest{URL: p.URL, Options: o}
+}
+
+// Submit crawls loc and submits the selected pages as advanced batches of
+// BatchSize pages, each as soon as it is full, so large sites start
+// rendering before the crawl finishes. It returns the submitted batches,
+// including those submitted before an error.
+func (c *Crawler) Submit(ctx context.Context, client rs.Batcher, loc string) ([]*rs.BatchResponse, error) {
+	size := c.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	var batches []*rs.BatchResponse
+	var pending []rs.BatchRequest
+	flush := func() error {
+		resp, err := client.BatchAdvanced(ctx, pending)
+		if err != nil {
+			return err
+		}
+		pending = nil
+		batches = append(batches, resp)
+		if c.OnBatch != nil {
+			c.OnBatch(resp)
+		}
+		return nil
+	}
+
+	err := c.Walk(ctx, loc, func(p Entry) error {
+		pending = append(pending, c.request(p))
+		if len(pending) < size {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(pending) > 0 {
+		err = flush()
+	}
+	return batches, err
+}
+
+type walker struct {
+	crawler          *Crawler
+	include, exclude []glob
+	fn               func(Entry) error
+	visited          map[string]bool // sitemaps, guarding against index cycles
+	seen             map[string]bool // pages
+	pages            int
+}
+
+func (w *walker) walk(ctx context.Context, loc string) error {
+	if w.visited[loc] {
+		return nil
+	}
+	w.visited[loc] = true
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	doc, err := w.crawler.load(ctx, loc)
+	if err != nil {
+		return err
+	}
+	since := w.crawler.ModifiedSince
+
+	for _, child := range doc.Sitemaps {
+		if !since.IsZero() && !child.LastMod.IsZero() && child.LastMod.Before(since) {
+			continue
+		}
+		next := resolve(loc, child.URL)
+		if remote(loc) && !remote(next) {
+			return fmt.Errorf("sitemap: %s lists non-HTTP sitemap %q", loc, child.URL)
+		}
+		if err := w.walk(ctx, next); err != nil {
+			return err
+		}
+	}
+
+	for _, page := range doc.URLs {
+		if w.seen[page.URL] || !w.selected(page.URL) {
+			continue
+		}
+		if !since.IsZero() && !page.LastMod.IsZero() && page.LastMod.Before(since) {
+			continue
+		}
+		w.seen[page.URL] = true
+		page.Sitemap = loc
+		if err := w.fn(page); err != nil {
+			return err
+		}
+		w.pages++
+		if max := w.crawler.MaxPages; max > 0 && w.pages >= max {
+			return errDone
+		}
+	}
+	return nil
+}
+
+func (w *walker) selected(pageURL string) bool {
+	path := pageURL
+	if u, err := url.Parse(pageURL); err == nil {
+		path = u.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+	}
+	matches := func(globs []glob) bool {
+		for _, g := range globs {
+			if g.full && g.re.MatchString(pageURL) || !g.full && g.re.MatchString(path) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(w.include) > 0 && !matches(w.include) {
+		return false
+	}
+	return !matches(w.exclude)
+}
+
+// glob is a compiled Include or Exclude pattern.
+type glob struct {
+	re   *regexp.Regexp
+	full bool // match the full URL rather than the path
+}
+
+func compileGlobs(globs []string) []glob {
+	out := make([]glob, 0, len(globs))
+	for _, g := range globs {
+		var b strings.Builder
+		b.WriteString("^")
+		for i := 0; i < len(g); i++ {
+			switch {
+			case strings.HasPrefix(g[i:], "**"):
+				b.WriteString(".*")
+				i++
+			case g[i] == '*':
+				b.WriteString("[^/]*")
+			case g[i] == '?':
+				b.WriteString("[^/]")
+			default:
+				b.WriteString(regexp.QuoteMeta(g[i : i+1]))
+			}
+		}
+		b.WriteString("$")
+		out = append(out, glob{re: regexp.MustCompile(b.String()), full: strings.Contains(g, "://")})
+	}
+	return out
+}
+
+func (c *Crawler) load(ctx context.Context, loc string) (*Document, error) {
+	fetch := c.Fetch
+	if fetch == nil {
+		fetch = Fetch
+	}
+	body, err := fetch(ctx, loc)
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: %w", err)
+	}
+	defer body.Close()
+	doc, err := Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w (in %s)", err, loc)
+	}
+	return doc, nil
+}
+
+// resolve resolves a child sitemap location against its index, so relative
+// locations work for both URLs and local fixture paths.
+func resolve(base, ref string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	if b.Scheme == "" && r.Scheme == "" && !filepath.IsAbs(ref) {
+		return filepath.Join(filepath.Dir(base), filepath.FromSlash(ref))
+	}
+	return b.ResolveReference(r).String()
+}
+
+// remote reports whether loc is an http or https URL.
+func remote(loc string) bool {
+	u, err := url.Parse(loc)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// Fetch opens loc: http and https URLs with a GET using
+// http.DefaultClient, file URLs and plain paths from the file system.
+func Fetch(ctx context.Context, loc string) (io.ReadCloser, error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "file", "":
+		return os.Open(u.Path)
+	default:
+		return nil, fmt.Errorf("unsupported sitemap location %q", loc)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", loc, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,263 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/rsmock"
+)
+
+func crawlURLs(t *testing.T, c *Crawler, loc string) []string {
+	t.Helper()
+	pages, err := c.Crawl(context.Background(), loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	urls := make([]string, len(pages))
+	for i, p := range pages {
+		urls[i] = strings.TrimPrefix(p.URL, "https://example.com")
+	}
+	return urls
+}
+
+func TestCrawlIndex(t *testing.T) {
+	got := crawlURLs(t, &Crawler{}, "testdata/index.xml")
+	// Duplicates and the index's reference to itself are skipped.
+	want := []string{
+		"/", "/pricing", "/about", "/contact", "/legal/terms",
+		"/blog/", "/blog/launch", "/blog/2023/recap",
+		"/archive/2021",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Crawl() = %v, want %v", got, want)
+	}
+}
+
+func TestCrawlSitemapField(t *testing.T) {
+	pages, err := (&Crawler{}).Crawl(context.Background(), "testdata/index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages[0].Sitemap != "testdata/pages.xml" || pages[5].Sitemap != "testdata/blog.xml.gz" {
+		t.Errorf("Sitemap = %q, %q", pages[0].Sitemap, pages[5].Sitemap)
+	}
+}
+
+func TestCrawlFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		crawler Crawler
+		want    []string
+	}{
+		{
+			name:    "include and exclude",
+			crawler: Crawler{Include: []string{"/blog/**"}, Exclude: []string{"/blog/2023/*"}},
+			want:    []string{"/blog/", "/blog/launch"},
+		},
+		{
+			name:    "single segment",
+			crawler: Crawler{Include: []string{"/*"}},
+			want:    []string{"/", "/pricing", "/about", "/contact"},
+		},
+		{
+			name:    "full URL",
+			crawler: Crawler{Include: []string{"https://example.com/legal/*"}},
+			want:    []string{"/legal/terms"},
+		},
+		{
+			name:    "modified since",
+			crawler: Crawler{ModifiedSince: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+			want:    []string{"/", "/pricing", "/contact", "/legal/terms", "/blog/", "/blog/launch"},
+		},
+		{
+			name:    "max pages",
+			crawler: Crawler{MaxPages: 3},
+			want:    []string{"/", "/pricing", "/about"},
+		},
+		{
+			name:    "max pages after filters",
+			crawler: Crawler{Include: []string{"/blog/**"}, MaxPages: 1},
+			want:    []string{"/blog/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := crawlURLs(t, &tt.crawler, "testdata/index.xml")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Crawl() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawlModifiedSinceSkipsSitemaps(t *testing.T) {
+	var fetched []string
+	c := &Crawler{
+		ModifiedSince: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Fetch: func(ctx context.Context, loc string) (io.ReadCloser, error) {
+			fetched = append(fetched, loc)
+			return Fetch(ctx, loc)
+		},
+	}
+	if _, err := c.Crawl(context.Background(), "testdata/index.xml"); err != nil {
+		t.Fatal(err)
+	}
+	for _, loc := range fetched {
+		if strings.Contains(loc, "archive") {
+			t.Errorf("fetched %s, whose lastmod is before ModifiedSince", loc)
+		}
+	}
+}
+
+func TestCrawlHTTP(t *testing.T) {
+	files := map[string]string{
+		"/sitemap.xml":       "testdata/index.xml",
+		"/pages.xml":         "testdata/pages.xml",
+		"/blog.xml.gz":       "testdata/blog.xml.gz",
+		"/archive.xml":       "testdata/archive.xml",
+		"/index.xml":         "testdata/index.xml",
+		"/sitemap-empty.xml": "",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, ok := files[r.URL.Path]
+		if !ok || path == "" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, path)
+	}))
+	defer srv.Close()
+
+	pages, err := (&Crawler{}).Crawl(context.Background(), srv.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 9 || pages[5].Sitemap != srv.URL+"/blog.xml.gz" {
+		t.Errorf("Crawl() = %d pages, pages[5] = %+v", len(pages), pages[5])
+	}
+
+	_, err = (&Crawler{}).Crawl(context.Background(), srv.URL+"/sitemap-empty.xml")
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing sitemap error = %v, want a 404", err)
+	}
+}
+
+func TestCrawlRemoteIndexCannotReadFiles(t *testing.T) {
+	var fetched []string
+	c := &Crawler{Fetch: func(ctx context.Context, loc string) (io.ReadCloser, error) {
+		fetched = append(fetched, loc)
+		if strings.HasPrefix(loc, "https://") {
+			return io.NopCloser(strings.NewReader(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>file:///etc/passwd</loc></sitemap>
+</sitemapindex>`)), nil
+		}
+		return Fetch(ctx, loc)
+	}}
+	_, err := c.Crawl(context.Background(), "https://example.com/sitemap.xml")
+	if err == nil || !strings.Contains(err.Error(), "file:///etc/passwd") {
+		t.Errorf("Crawl() error = %v, want the file location refused", err)
+	}
+	if len(fetched) != 1 {
+		t.Errorf("fetched %v, want only the remote index", fetched)
+	}
+
+	// A local index may still list local and remote sitemaps.
+	if _, err := (&Crawler{}).Crawl(context.Background(), "testdata/index.xml"); err != nil {
+		t.Errorf("local index: %v", err)
+	}
+}
+
+func TestCrawlErrors(t *testing.T) {
+	if _, err := (&Crawler{}).Crawl(context.Background(), "testdata/missing.xml"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file error = %v, want os.ErrNotExist", err)
+	}
+
+	c := &Crawler{Fetch: func(context.Context, string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("<html></html>")), nil
+	}}
+	_, err := c.Crawl(context.Background(), "https://example.com/sitemap.xml")
+	if err == nil || !strings.Contains(err.Error(), "https://example.com/sitemap.xml") {
+		t.Errorf("parse error = %v, want it to name the sitemap", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (&Crawler{}).Crawl(ctx, "testdata/index.xml"); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled crawl error = %v", err)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	c := &Crawler{
+		Include: []string{"/blog/**"},
+		Options: func(o *rs.TakeOptions) { o.Preset("og_card") },
+	}
+	requests, err := c.Requests(context.Background(), "testdata/index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("Requests() = %d requests, want 3", len(requests))
+	}
+	for _, r := range requests {
+		params := r.Options.ToParams()
+		if params["url"] != r.URL || params["preset"] != "og_card" {
+			t.Errorf("request %s params = %v", r.URL, params)
+		}
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	var sizes []int
+	m := &rsmock.Client{
+		BatchAdvancedFunc: func(ctx context.Context, reqs []rs.BatchRequest) (*rs.BatchResponse, error) {
+			sizes = append(sizes, len(reqs))
+			return &rs.BatchResponse{ID: fmt.Sprintf("batch_%d", len(sizes)), Total: len(reqs)}, nil
+		},
+	}
+	var reported []string
+	c := &Crawler{
+		BatchSize: 4,
+		OnBatch:   func(b *rs.BatchResponse) { reported = append(reported, b.ID) },
+	}
+	batches, err := c.Submit(context.Background(), m, "testdata/index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{4, 4, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("batch sizes = %v, want %v", sizes, want)
+	}
+	if len(batches) != 3 || !reflect.DeepEqual(reported, []string{"batch_1", "batch_2", "batch_3"}) {
+		t.Errorf("batches = %d, reported = %v", len(batches), reported)
+	}
+}
+
+func TestSubmitError(t *testing.T) {
+	calls := 0
+	m := &rsmock.Client{
+		BatchAdvancedFunc: func(ctx context.Context, reqs []rs.BatchRequest) (*rs.BatchResponse, error) {
+			calls++
+			if calls == 2 {
+				return nil, &rs.Error{Code: rs.CodeRateLimited, Message: "slow down"}
+			}
+			return &rs.BatchResponse{ID: "batch_1"}, nil
+		},
+	}
+	batches, err := (&Crawler{BatchSize: 2}).Submit(context.Background(), m, "testdata/index.xml")
+	if !errors.Is(err, rs.ErrRateLimited) {
+		t.Errorf("Submit() error = %v, want ErrRateLimited", err)
+	}
+	if len(batches) != 1 || calls != 2 {
+		t.Errorf("batches = %d, calls = %d, want the first batch and no further calls", len(batches), calls)
+	}
+}
@@ -0,0 +1,151 @@
+// Package sitemap reads sitemap.xml files and sitemap indexes and turns the
+// pages they list into screenshot batches.
+//
+//	c := &sitemap.Crawler{
+//		Include:       []string{"/blog/**"},
+//		ModifiedSince: time.Now().AddDate(0, 0, -7),
+//		MaxPages:      500,
+//		Options:       func(o *rs.TakeOptions) { o.Preset("og_card") },
+//	}
+//	batches, err := c.Submit(ctx, client, "https://example.com/sitemap.xml")
+//
+// Gzipped sitemaps are detected from their content, and locations without a
+// scheme are read from the local file system, so fixtures work in tests.
+package sitemap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSize is the largest uncompressed sitemap accepted, as set by the
+// sitemap protocol.
+const MaxSize = 50 << 20
+
+// Entry is a page listed in a sitemap, or a sitemap listed in an index.
+type Entry struct {
+	URL string
+	// LastMod is zero when the sitemap gives no (valid) lastmod.
+	LastMod    time.Time
+	ChangeFreq string
+	// Priority is zero when absent.
+	Priority float64
+	// Sitemap is the location of the sitemap that listed the entry. It is
+	// set by Crawler, not Parse.
+	Sitemap string
+}
+
+// Document is a parsed sitemap. A <urlset> fills URLs; a <sitemapindex>
+// fills Sitemaps.
+type Document struct {
+	URLs     []Entry
+	Sitemaps []Entry
+}
+
+// IsIndex reports whether the document is a sitemap index.
+func (d *Document) IsIndex() bool {
+	return len(d.Sitemaps) > 0
+}
+
+type xmlEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+type xmlDocument struct {
+	XMLName  xml.Name
+	URLs     []xmlEntry `xml:"url"`
+	Sitemaps []xmlEntry `xml:"sitemap"`
+}
+
+// Parse reads a sitemap or sitemap index, gunzipping it if needed. Entries
+// without a <loc> are skipped, and unparseable lastmod or priority values
+// are ignored, since real-world sitemaps are often sloppy.
+func Parse(r io.Reader) (*Document, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("sitemap: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: %w", err)
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("sitemap: larger than %d bytes", MaxSize)
+	}
+
+	var raw xmlDocument
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("sitemap: %w", err)
+	}
+	doc := &Document{}
+	switch raw.XMLName.Local {
+	case "urlset":
+		doc.URLs = entries(raw.URLs)
+	case "sitemapindex":
+		doc.Sitemaps = entries(raw.Sitemaps)
+	default:
+		return nil, fmt.Errorf("sitemap: unexpected root element <%s>", raw.XMLName.Local)
+	}
+	return doc, nil
+}
+
+func entries(raw []xmlEntry) []Entry {
+	out := make([]Entry, 0, len(raw))
+	for _, e := range raw {
+		loc := strings.TrimSpace(e.Loc)
+		if loc == "" {
+			continue
+		}
+		entry := Entry{
+			URL:        loc,
+			ChangeFreq: strings.TrimSpace(e.ChangeFreq),
+		}
+		entry.LastMod, _ = ParseLastMod(e.LastMod)
+		if p, err := strconv.ParseFloat(strings.TrimSpace(e.Priority), 64); err == nil {
+			entry.Priority = p
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// lastModLayouts are the W3C datetime forms allowed in <lastmod>.
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// ParseLastMod parses a W3C datetime as used in <lastmod>.
+func ParseLastMod(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, errors.New("sitemap: empty lastmod")
+	}
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("sitemap: invalid lastmod %q", s)
+}
@@ -0,0 +1,110 @@
+package sitemap
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseURLSet(t *testing.T) {
+	f, err := os.Open("testdata/pages.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.IsIndex() || len(doc.URLs) != 5 {
+		t.Fatalf("doc = %+v, want 5 URLs", doc)
+	}
+	home := doc.URLs[0]
+	if home.URL != "https://example.com/" || home.ChangeFreq != "daily" || home.Priority != 1 {
+		t.Errorf("URLs[0] = %+v", home)
+	}
+	if want := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC); !home.LastMod.Equal(want) {
+		t.Errorf("URLs[0].LastMod = %v, want %v", home.LastMod, want)
+	}
+	if got := doc.URLs[3].URL; got != "https://example.com/contact" {
+		t.Errorf("URLs[3].URL = %q, want whitespace trimmed", got)
+	}
+	if !doc.URLs[4].LastMod.IsZero() {
+		t.Errorf("invalid lastmod parsed as %v, want zero", doc.URLs[4].LastMod)
+	}
+}
+
+func TestParseGzip(t *testing.T) {
+	f, err := os.Open("testdata/blog.xml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.URLs) != 4 || doc.URLs[0].URL != "https://example.com/blog/" {
+		t.Errorf("URLs = %+v", doc.URLs)
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	f, err := os.Open("testdata/index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !doc.IsIndex() || len(doc.Sitemaps) != 4 || len(doc.URLs) != 0 {
+		t.Fatalf("doc = %+v, want an index of 4 sitemaps", doc)
+	}
+	if doc.Sitemaps[1].URL != "blog.xml.gz" || doc.Sitemaps[1].LastMod.IsZero() {
+		t.Errorf("Sitemaps[1] = %+v", doc.Sitemaps[1])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"html":      "<html><body>Not found</body></html>",
+		"malformed": "<urlset><url><loc>https://example.com</loc>",
+		"gzip":      "\x1f\x8bnot gzip",
+	}
+	for name, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: Parse() error = nil", name)
+		}
+	}
+}
+
+func TestParseLastMod(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-03", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-05T10:30+01:00", time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)},
+		{"2024-03-05T10:30:15Z", time.Date(2024, 3, 5, 10, 30, 15, 0, time.UTC)},
+		{" 2024-03-05T10:30:15.5Z ", time.Date(2024, 3, 5, 10, 30, 15, 5e8, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseLastMod(tt.in)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseLastMod(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "yesterday", "05/03/2024"} {
+		if _, err := ParseLastMod(in); err == nil {
+			t.Errorf("ParseLastMod(%q) error = nil", in)
+		}
+	}
+}
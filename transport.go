@@ -0,0 +1,157 @@
+package renderscreenshot
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// transportConfig holds the network settings used to build the HTTP transport.
+type transportConfig struct {
+	proxyURL   string
+	tlsConfig  *tls.Config
+	clientCert []byte
+	clientKey  []byte
+}
+
+func (tc *transportConfig) isZero() bool {
+	return tc.proxyURL == "" && tc.tlsConfig == nil && tc.clientCert == nil && tc.clientKey == nil
+}
+
+// newTransport builds an *http.Transport from the default transport with
+// the configured proxy, TLS settings and client certificate applied.
+func newTransport(tc *transportConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if tc.proxyURL != "" {
+		// The URL may carry credentials, so it is kept out of the error.
+		proxy, err := url.Parse(tc.proxyURL)
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		if err != nil || proxy.Host == "" {
+			return nil, &Error{Message: "Invalid proxy URL", Code: CodeConnectionError, Cause: err}
+		}
+		t.Proxy = proxyFunc(proxy, noProxyFromEnvironment())
+	}
+
+	if tc.tlsConfig != nil {
+		t.TLSClientConfig = tc.tlsConfig.Clone()
+	}
+
+	if tc.clientCert != nil || tc.clientKey != nil {
+		cert, err := tls.X509KeyPair(tc.clientCert, tc.clientKey)
+		if err != nil {
+			return nil, &Error{Message: "Invalid client certificate: " + err.Error(), Code: CodeConnectionError, Cause: err}
+		}
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		t.TLSClientConfig.Certificates = append(t.TLSClientConfig.Certificates, cert)
+	}
+
+	return t, nil
+}
+
+// proxyFunc returns a Transport.Proxy function that sends every request
+// through proxy unless its host matches the NO_PROXY list.
+func proxyFunc(proxy *url.URL, noProxy string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL, noProxy) {
+			return nil, nil
+		}
+		return proxy, nil
+	}
+}
+
+func noProxyFromEnvironment() string {
+	if v := os.Getenv("NO_PROXY"); v != "" {
+		return v
+	}
+	return os.Getenv("no_proxy")
+}
+
+// bypassProxy reports whether u matches an entry of a NO_PROXY list.
+// Entries may be "*", host names (matching the host and its subdomains),
+// ".domain" suffixes, IP addresses, CIDR ranges, and any of these with a port.
+func bypassProxy(u *url.URL, noProxy string) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && ipNet.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost = strings.TrimPrefix(entryHost, "*")
+		if strings.HasPrefix(entryHost, ".") {
+			if strings.HasSuffix(host, entryHost) || host == entryHost[1:] {
+				return true
+			}
+			continue
+		}
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// connectionPhase classifies a transport error by the stage of the
+// connection that failed, for inclusion in CodeConnectionError messages.
+func connectionPhase(err error) string {
+	msg := err.Error()
+	if strings.Contains(msg, "Proxy Authentication Required") {
+		return "proxy authentication"
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return "proxy connect"
+	}
+
+	switch {
+	case strings.Contains(msg, "tls:") || strings.Contains(msg, "x509:") || strings.Contains(msg, "certificate"):
+		return "TLS handshake"
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+package renderscreenshot
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithProxyRoutesRequests(t *testing.T) {
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+
+	var gotHost, gotAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.URL.Host
+		gotAuth = r.Header.Get("Proxy-Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"credits": 5.0})
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("alice", "s3cret")
+
+	client, err := New("rs_live_test",
+		WithBaseURL("http://api.internal.example"),
+		WithProxy(proxyURL.String()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	usage, err := client.Usage(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage.Credits != 5 {
+		t.Errorf("Credits = %d, want 5", usage.Credits)
+	}
+	if gotHost != "api.internal.example" {
+		t.Errorf("proxied host = %q, want api.internal.example", gotHost)
+	}
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:s3cret"))
+	if gotAuth != want {
+		t.Errorf("Proxy-Authorization = %q, want %q", gotAuth, want)
+	}
+}
+
+func TestWithProxyUnreachable(t *testing.T) {
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	deadURL := dead.URL
+	dead.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL("http://api.internal.example"), WithProxy(deadURL))
+	_, err := client.Usage(context.Background())
+	apiErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if apiErr.Code != CodeConnectionError {
+		t.Errorf("Code = %q, want %q", apiErr.Code, CodeConnectionError)
+	}
+	if !strings.Contains(apiErr.Message, "(proxy connect)") {
+		t.Errorf("Message = %q, want proxy connect phase", apiErr.Message)
+	}
+}
+
+func TestWithProxyAuthenticationRequired(t *testing.T) {
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+
+	// Plain HTTP requests are forwarded; HTTPS ones are tunnelled with CONNECT.
+	for _, scheme := range []string{"http", "https"} {
+		t.Run(scheme, func(t *testing.T) {
+			var requests int32
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(http.StatusProxyAuthRequired)
+			}))
+			defer proxy.Close()
+
+			client, _ := New("rs_live_test",
+				WithEndpoints([]string{scheme + "://eu.internal.example", scheme + "://us.internal.example"}),
+				WithProxy(proxy.URL), WithRetryDelay(0.01))
+			_, err := client.Usage(context.Background())
+			apiErr, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("expected *Error, got %T", err)
+			}
+			if apiErr.Code != CodeUnauthorized || apiErr.IsRetryable() || !IsAuthentication(err) {
+				t.Errorf("err = %v, want a non-retryable authentication error", err)
+			}
+			if !strings.Contains(apiErr.Message, "(proxy authentication)") {
+				t.Errorf("Message = %q, want proxy authentication phase", apiErr.Message)
+			}
+			if n := atomic.LoadInt32(&requests); n != 1 {
+				t.Errorf("proxy received %d requests, want 1 (no retry or failover)", n)
+			}
+			for _, status := range client.Endpoints() {
+				if !status.Healthy {
+					t.Errorf("endpoint = %+v, want healthy", status)
+				}
+			}
+		})
+	}
+}
+
+func TestWithProxyInvalidURL(t *testing.T) {
+	for _, proxy := range []string{"://alice:s3cret@bad", "alice:s3cret@proxy"} {
+		_, err := New("rs_live_test", WithProxy(proxy))
+		apiErr, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("expected *Error, got %T", err)
+		}
+		if apiErr.Code != CodeConnectionError {
+			t.Errorf("Code = %q, want %q", apiErr.Code, CodeConnectionError)
+		}
+		if msg := fmt.Sprintf("%v: %v", err, errors.Unwrap(err)); strings.Contains(msg, "s3cret") {
+			t.Errorf("error %q leaks the proxy credentials", msg)
+		}
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	tests := []struct {
+		url     string
+		noProxy string
+		want    bool
+	}{
+		{"https://api.example.com", "", false},
+		{"https://api.example.com", "*", true},
+		{"https://api.example.com", "example.com", true},
+		{"https://api.example.com", ".example.com", true},
+		{"https://example.com", ".example.com", true},
+		{"https://api.example.com", "*.example.com", true},
+		{"https://api.example.org", "example.com", false},
+		{"https://notexample.com", "example.com", false},
+		{"https://api.example.com", "other.com, api.example.com", true},
+		{"https://api.example.com", "api.example.com:8443", false},
+		{"https://api.example.com:8443", "api.example.com:8443", true},
+		{"http://10.1.2.3", "10.0.0.0/8", true},
+		{"http://192.168.1.1", "10.0.0.0/8", false},
+		{"http://127.0.0.1:8080", "127.0.0.1", true},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := bypassProxy(u, tt.noProxy); got != tt.want {
+			t.Errorf("bypassProxy(%q, %q) = %v, want %v", tt.url, tt.noProxy, got, tt.want)
+		}
+	}
+}
+
+func TestWithClientCertificateMutualTLS(t *testing.T) {
+	certPEM, keyPEM, cert := generateTestCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			t.Error("expected client certificate")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"credits": 1.0})
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	client, err := New("rs_live_test",
+		WithBaseURL(server.URL),
+		WithTLSConfig(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}),
+		WithClientCertificate(certPEM, keyPEM))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Usage(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without the client certificate the handshake must fail.
+	plain, _ := New("rs_live_test",
+		WithBaseURL(server.URL),
+		WithTLSConfig(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}))
+	_, err = plain.Usage(context.Background())
+	if apiErr, ok := err.(*Error); !ok || apiErr.Code != CodeConnectionError {
+		t.Errorf("expected connection error without client certificate, got %v", err)
+	}
+}
+
+func TestWithClientCertificateInvalid(t *testing.T) {
+	_, err := New("rs_live_test", WithClientCertificate([]byte("not a cert"), []byte("not a key")))
+	apiErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if apiErr.Code != CodeConnectionError {
+		t.Errorf("Code = %q, want %q", apiErr.Code, CodeConnectionError)
+	}
+}
+
+func generateTestCertificate(t *testing.T) (certPEM, keyPEM []byte, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "rs-go test client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, cert
+}
@@ -1,5 +1,7 @@
 package renderscreenshot
 
+import "encoding/json"
+
 // ImageFormat represents supported output image formats.
 type ImageFormat string
 
@@ -71,11 +73,13 @@ type Geolocation struct {
 
 // ScreenshotResponse represents the JSON response from a screenshot request.
 type ScreenshotResponse struct {
-	ID     string         `json:"id"`
-	Status string         `json:"status"`
-	Image  ImageInfo      `json:"image"`
-	Cache  CacheInfo      `json:"cache"`
-	Error  *ErrorResponse `json:"error,omitempty"`
+	ID     string            `json:"id"`
+	Status string            `json:"status"`
+	Image  ImageInfo         `json:"image"`
+	Cache  CacheInfo         `json:"cache"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Error  *ErrorResponse    `json:"error,omitempty"`
+	Meta   ResponseMeta      `json:"-"`
 }
 
 // ImageInfo contains details about the captured image.
@@ -100,14 +104,16 @@ type BatchResponse struct {
 	Failed    int            `json:"failed"`
 	Results   []BatchResult  `json:"results"`
 	Error     *ErrorResponse `json:"error,omitempty"`
+	Meta      ResponseMeta   `json:"-"`
 }
 
 // BatchResult represents a single result in a batch response.
 type BatchResult struct {
-	URL      string `json:"url"`
-	Status   string `json:"status"`
-	ImageURL string `json:"image_url"`
-	Error    string `json:"error,omitempty"`
+	URL      string            `json:"url"`
+	Status   string            `json:"status"`
+	ImageURL string            `json:"image_url"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Error    string            `json:"error,omitempty"`
 }
 
 // BatchRequest represents a single request in an advanced batch.
@@ -141,12 +147,73 @@ type UsageInfo struct {
 	PeriodEnd   string `json:"period_end"`
 }
 
+// UsageGranularity is the bucket size of a usage history series.
+type UsageGranularity string
+
+// Supported usage history granularities.
+const (
+	GranularityDay   UsageGranularity = "day"
+	GranularityWeek  UsageGranularity = "week"
+	GranularityMonth UsageGranularity = "month"
+)
+
+// UsageHistory is a series of credit usage buckets.
+type UsageHistory struct {
+	Granularity UsageGranularity `json:"granularity"`
+	From        string           `json:"from"`
+	To          string           `json:"to"`
+	Points      []UsagePoint     `json:"data"`
+}
+
+// UsagePoint is the credit usage of one bucket, broken down by output
+// format, preset and API key.
+type UsagePoint struct {
+	Date     string         `json:"date"`
+	Credits  int            `json:"credits"`
+	ByFormat map[string]int `json:"by_format,omitempty"`
+	ByPreset map[string]int `json:"by_preset,omitempty"`
+	ByAPIKey map[string]int `json:"by_api_key,omitempty"`
+}
+
 // WebhookEvent represents a parsed webhook event.
 type WebhookEvent struct {
 	Event     string                 `json:"event"`
 	ID        string                 `json:"id"`
 	Timestamp int64                  `json:"timestamp"`
 	Data      map[string]interface{} `json:"data"`
+	// Tags are the attribution tags of the capture, taken from Data["tags"].
+	Tags map[string]string `json:"-"`
+	// Raw is the original payload, preserved for forward compatibility.
+	Raw json.RawMessage `json:"-"`
+}
+
+// CacheEntry describes a cached screenshot.
+type CacheEntry struct {
+	Key       string            `json:"key"`
+	URL       string            `json:"url"`
+	Format    string            `json:"format,omitempty"`
+	Size      int               `json:"size"`
+	CreatedAt string            `json:"created_at"`
+	ExpiresAt string            `json:"expires_at,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+// CacheList is a page of cache entries.
+type CacheList struct {
+	Entries    []CacheEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// CacheListOptions filters and paginates CacheManager.List.
+type CacheListOptions struct {
+	// Tags restricts results to entries carrying all of the given tags.
+	Tags map[string]string
+	// URL restricts results to entries whose URL matches a glob pattern.
+	URL string
+	// Limit caps the number of entries per page.
+	Limit int
+	// Cursor continues from a previous page's NextCursor.
+	Cursor string
 }
 
 // PurgeResult represents the result of a cache purge operation.
@@ -0,0 +1,134 @@
+package renderscreenshot
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	usageDateLayout = "2006-01-02"
+	// forecastWindow is the number of most recent days used to estimate the run rate.
+	forecastWindow = 7
+)
+
+// UsageForecast is a projection of credit consumption to the end of a billing period.
+type UsageForecast struct {
+	// UsedToDate is the credits consumed from the period start through the last data point.
+	UsedToDate int
+	// DailyRate is the estimated credits consumed per day.
+	DailyRate float64
+	// DaysRemaining is the number of days between the last data point and the period end.
+	DaysRemaining int
+	// Projected is the estimated total consumption for the whole period.
+	Projected int
+}
+
+// Totals sums the series into a single point, including the breakdowns.
+func (h *UsageHistory) Totals() UsagePoint {
+	total := UsagePoint{
+		Date:     h.From,
+		ByFormat: map[string]int{},
+		ByPreset: map[string]int{},
+		ByAPIKey: map[string]int{},
+	}
+	for _, p := range h.Points {
+		total.Credits += p.Credits
+		addBreakdown(total.ByFormat, p.ByFormat)
+		addBreakdown(total.ByPreset, p.ByPreset)
+		addBreakdown(total.ByAPIKey, p.ByAPIKey)
+	}
+	return total
+}
+
+// Forecast projects end-of-period consumption for the period
+// [periodStart, periodEnd) from the series. The run rate is the average
+// daily usage over the most recent seven days of data.
+func (h *UsageHistory) Forecast(periodStart, periodEnd time.Time) UsageForecast {
+	periodStart = truncateDay(periodStart)
+	periodEnd = truncateDay(periodEnd)
+
+	var (
+		used int
+		last time.Time
+	)
+
+	type bucket struct {
+		start, end time.Time
+		credits    int
+	}
+	buckets := make([]bucket, 0, len(h.Points))
+	for _, p := range h.Points {
+		start, err := time.Parse(usageDateLayout, p.Date)
+		if err != nil || start.Before(periodStart) || !start.Before(periodEnd) {
+			continue
+		}
+		end := bucketEnd(start, h.Granularity)
+		buckets = append(buckets, bucket{start: start, end: end, credits: p.Credits})
+		used += p.Credits
+		if end.After(last) {
+			last = end
+		}
+	}
+	if len(buckets) == 0 {
+		return UsageForecast{DaysRemaining: daysBetween(periodStart, periodEnd)}
+	}
+
+	windowStart := last.AddDate(0, 0, -forecastWindow)
+	if windowStart.Before(periodStart) {
+		windowStart = periodStart
+	}
+	windowCredit := 0
+	for _, b := range buckets {
+		if !b.end.After(windowStart) {
+			continue
+		}
+		// Pro-rate buckets that straddle the window start.
+		span := daysBetween(b.start, b.end)
+		overlap := daysBetween(maxTime(b.start, windowStart), b.end)
+		windowCredit += int(math.Round(float64(b.credits) * float64(overlap) / float64(span)))
+	}
+	windowDays := daysBetween(windowStart, last)
+
+	forecast := UsageForecast{UsedToDate: used}
+	if windowDays > 0 {
+		forecast.DailyRate = float64(windowCredit) / float64(windowDays)
+	}
+	if periodEnd.After(last) {
+		forecast.DaysRemaining = daysBetween(last, periodEnd)
+	}
+	forecast.Projected = used + int(math.Round(forecast.DailyRate*float64(forecast.DaysRemaining)))
+	return forecast
+}
+
+func addBreakdown(dst, src map[string]int) {
+	for k, v := range src {
+		dst[k] += v
+	}
+}
+
+func bucketEnd(start time.Time, granularity UsageGranularity) time.Time {
+	switch granularity {
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,161 @@
+package renderscreenshot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientUsageHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/usage/history" {
+			t.Errorf("path = %q, want /v1/usage/history", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("from") != "2026-03-01" || q.Get("to") != "2026-03-02" || q.Get("granularity") != "day" {
+			t.Errorf("query = %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"granularity": "day",
+			"from":        "2026-03-01",
+			"to":          "2026-03-02",
+			"data": []interface{}{
+				map[string]interface{}{
+					"date":       "2026-03-01",
+					"credits":    120.0,
+					"by_format":  map[string]interface{}{"png": 100.0, "pdf": 20.0},
+					"by_preset":  map[string]interface{}{"og_card": 80.0},
+					"by_api_key": map[string]interface{}{"rs_live_abc": 120.0},
+				},
+				map[string]interface{}{
+					"date":      "2026-03-02",
+					"credits":   30.0,
+					"by_format": map[string]interface{}{"png": 30.0},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL))
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	history, err := client.UsageHistory(context.Background(), from, from.AddDate(0, 0, 1), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if history.Granularity != GranularityDay {
+		t.Errorf("Granularity = %q, want day", history.Granularity)
+	}
+	if len(history.Points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(history.Points))
+	}
+	p := history.Points[0]
+	if p.Date != "2026-03-01" || p.Credits != 120 {
+		t.Errorf("Points[0] = %+v", p)
+	}
+	if p.ByFormat["pdf"] != 20 || p.ByPreset["og_card"] != 80 || p.ByAPIKey["rs_live_abc"] != 120 {
+		t.Errorf("Points[0] breakdown = %+v", p)
+	}
+
+	totals := history.Totals()
+	if totals.Credits != 150 || totals.ByFormat["png"] != 130 || totals.ByFormat["pdf"] != 20 {
+		t.Errorf("Totals() = %+v", totals)
+	}
+}
+
+func TestUsageHistoryForecastDaily(t *testing.T) {
+	h := &UsageHistory{Granularity: GranularityDay}
+	// Ten days at 10 credits/day, then the recent week at 20 credits/day.
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 17; i++ {
+		credits := 10
+		if i >= 10 {
+			credits = 20
+		}
+		h.Points = append(h.Points, UsagePoint{Date: start.AddDate(0, 0, i).Format("2006-01-02"), Credits: credits})
+	}
+
+	f := h.Forecast(start, start.AddDate(0, 1, 0))
+	if f.UsedToDate != 240 {
+		t.Errorf("UsedToDate = %d, want 240", f.UsedToDate)
+	}
+	if f.DailyRate != 20 {
+		t.Errorf("DailyRate = %v, want 20", f.DailyRate)
+	}
+	if f.DaysRemaining != 13 {
+		t.Errorf("DaysRemaining = %d, want 13", f.DaysRemaining)
+	}
+	if f.Projected != 240+13*20 {
+		t.Errorf("Projected = %d, want %d", f.Projected, 240+13*20)
+	}
+}
+
+func TestUsageHistoryForecastIgnoresOtherPeriods(t *testing.T) {
+	h := &UsageHistory{Points: []UsagePoint{
+		{Date: "2026-03-31", Credits: 1000},
+		{Date: "2026-04-01", Credits: 10},
+		{Date: "2026-04-02", Credits: 10},
+	}}
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	f := h.Forecast(start, start.AddDate(0, 0, 10))
+	if f.UsedToDate != 20 {
+		t.Errorf("UsedToDate = %d, want 20", f.UsedToDate)
+	}
+	if f.Projected != 100 {
+		t.Errorf("Projected = %d, want 100", f.Projected)
+	}
+}
+
+func TestUsageHistoryForecastEmpty(t *testing.T) {
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	f := (&UsageHistory{}).Forecast(start, start.AddDate(0, 0, 30))
+	if f.Projected != 0 || f.DaysRemaining != 30 {
+		t.Errorf("Forecast() = %+v", f)
+	}
+}
+
+func TestUsageHistoryForecastWeekly(t *testing.T) {
+	h := &UsageHistory{Granularity: GranularityWeek, Points: []UsagePoint{
+		{Date: "2026-04-01", Credits: 70},
+		{Date: "2026-04-08", Credits: 140},
+	}}
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	f := h.Forecast(start, start.AddDate(0, 0, 28))
+	if f.DailyRate != 20 {
+		t.Errorf("DailyRate = %v, want 20", f.DailyRate)
+	}
+	if f.Projected != 210+14*20 {
+		t.Errorf("Projected = %d, want %d", f.Projected, 210+14*20)
+	}
+}
+
+func TestClientUsageFilteredByTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("tags[tenant]") != "acme" {
+			t.Errorf("%s query = %q, want tag filter", r.URL.Path, r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"used": 42.0})
+	}))
+	defer server.Close()
+
+	client, _ := New("rs_live_test", WithBaseURL(server.URL))
+	tags := map[string]string{"tenant": "acme"}
+
+	usage, err := client.UsageForTags(context.Background(), tags)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage.Used != 42 {
+		t.Errorf("Used = %d, want 42", usage.Used)
+	}
+
+	now := time.Now()
+	if _, err := client.UsageHistoryForTags(context.Background(), now.AddDate(0, 0, -7), now, GranularityDay, tags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+// Package baseline manages approved baseline images for visual regression
+// suites run with go test, comparing captures with the visualdiff package.
+package baseline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/visualdiff"
+)
+
+// Artifact file suffixes written next to a baseline.
+const (
+	baselineExt  = ".png"
+	actualSuffix = ".actual.png"
+	diffSuffix   = ".diff.png"
+)
+
+// Store keeps approved baseline images for named test cases in Dir:
+//
+//	<Dir>/<name>.png         approved baseline
+//	<Dir>/<name>.actual.png  latest render, written when it does not match
+//	<Dir>/<name>.diff.png    diff image, written when it does not match
+//
+// Names may contain "/" to group cases in subdirectories. Baselines are
+// meant to be committed; actual and diff files are review artifacts.
+type Store struct {
+	// Dir is the baseline directory, e.g. "testdata/screenshots".
+	Dir string
+	// Client captures the current renders.
+	Client rs.Screenshotter
+	// Options configures the comparison. Case.Ignore regions are added to
+	// its Ignore list.
+	Options *visualdiff.Options
+	// MaxMismatch is the mismatch percentage tolerated before a case
+	// fails. Captures of a different size always fail.
+	MaxMismatch float64
+	// Update writes the current render as the baseline of every case that
+	// is missing or does not match, instead of failing it.
+	Update bool
+}
+
+// Case is a named capture checked against its baseline.
+type Case struct {
+	Name    string
+	Options *rs.TakeOptions
+	// Ignore lists regions ignored for this case only.
+	Ignore []visualdiff.Region
+}
+
+// Status is the outcome of checking a case.
+type Status string
+
+// Case statuses.
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusMissing Status = "missing"
+	StatusUpdated Status = "updated"
+)
+
+// Outcome reports a checked case and the files involved.
+type Outcome struct {
+	Name     string
+	Status   Status
+	Baseline string
+	// Actual and Diff are the artifacts written for a failing or missing
+	// case; Diff is empty for missing cases.
+	Actual string
+	Diff   string
+	// Result is the comparison, nil if there was no baseline.
+	Result *visualdiff.Result
+}
+
+// OK reports whether the case passed or its baseline was updated.
+func (o *Outcome) OK() bool {
+	return o.Status == StatusPassed || o.Status == StatusUpdated
+}
+
+// validName allows slash-separated segments that do not start with a dot.
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+
+func checkName(name string) error {
+	if !validName.MatchString(name) || strings.HasSuffix(name, ".actual") || strings.HasSuffix(name, ".diff") {
+		return fmt.Errorf("baseline: invalid case name %q", name)
+	}
+	return nil
+}
+
+func (s *Store) path(name, suffix string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(name)+suffix)
+}
+
+// BaselinePath returns the baseline file of a case.
+func (s *Store) BaselinePath(name string) string {
+	return s.path(name, baselineExt)
+}
+
+// Check captures c, compares it with its baseline and writes or removes
+// the actual and diff artifacts. Mismatches are reported in the Outcome;
+// the error is non-nil only if the capture or file access failed.
+func (s *Store) Check(ctx context.Context, c Case) (*Outcome, error) {
+	if err := checkName(c.Name); err != nil {
+		return nil, err
+	}
+	data, err := s.Client.Take(ctx, c.Options)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := toPNG(data)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %s: %w", c.Name, err)
+	}
+
+	out := &Outcome{Name: c.Name, Baseline: s.BaselinePath(c.Name)}
+	baseline, err := os.ReadFile(out.Baseline)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		out.Status = StatusMissing
+	case err != nil:
+		return nil, err
+	default:
+		if out.Result, err = visualdiff.CompareBytes(baseline, actual, s.caseOptions(c)); err != nil {
+			return nil, fmt.Errorf("baseline: %s: %w", c.Name, err)
+		}
+		out.Status = StatusPassed
+		if out.Result.SizeMismatch || out.Result.Mismatch > s.MaxMismatch {
+			out.Status = StatusFailed
+		}
+	}
+
+	switch {
+	case out.Status == StatusPassed:
+		return out, s.removeArtifacts(c.Name)
+	case s.Update:
+		out.Status = StatusUpdated
+		if err := writeFile(out.Baseline, actual); err != nil {
+			return nil, err
+		}
+		return out, s.removeArtifacts(c.Name)
+	}
+
+	out.Actual = s.path(c.Name, actualSuffix)
+	if err := writeFile(out.Actual, actual); err != nil {
+		return nil, err
+	}
+	if out.Result == nil {
+		return out, nil
+	}
+	var diff bytes.Buffer
+	if err := out.Result.WritePNG(&diff); err != nil {
+		return nil, err
+	}
+	out.Diff = s.path(c.Name, diffSuffix)
+	return out, writeFile(out.Diff, diff.Bytes())
+}
+
+// TB is the subset of testing.TB used by Assert.
+type TB interface {
+	Helper()
+	Name() string
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+	Logf(format string, args ...interface{})
+}
+
+// Assert checks c and reports a missing or mismatching baseline as a test
+// error naming the artifacts to review. An empty c.Name defaults to the
+// test name. Run the tests with Update set (typically from an -update
+// flag) to accept the current renders.
+func (s *Store) Assert(t TB, c Case) *Outcome {
+	t.Helper()
+	if c.Name == "" {
+		c.Name = testCaseName(t.Name())
+	}
+	out, err := s.Check(context.Background(), c)
+	if err != nil {
+		t.Fatalf("visual check %s: %v", c.Name, err)
+		return nil
+	}
+	switch out.Status {
+	case StatusMissing:
+		t.Errorf("%s: no baseline at %s; wrote %s (approve it or run with -update)", c.Name, out.Baseline, out.Actual)
+	case StatusFailed:
+		t.Errorf("%s: %.3f%% of pixels differ from %s (%d pixels, size mismatch %v); see %s and %s",
+			c.Name, out.Result.Mismatch, out.Baseline, out.Result.DiffPixels, out.Result.SizeMismatch, out.Actual, out.Diff)
+	case StatusUpdated:
+		t.Logf("%s: updated baseline %s", c.Name, out.Baseline)
+	}
+	return out
+}
+
+// Approve promotes the pending actual render of a case to its baseline and
+// removes its diff.
+func (s *Store) Approve(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := os.Rename(s.path(name, actualSuffix), s.BaselinePath(name)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("baseline: no pending render for %s", name)
+		}
+		return err
+	}
+	return removeIfExists(s.path(name, diffSuffix))
+}
+
+// ApproveAll approves every pending case and returns their names.
+func (s *Store) ApproveAll() ([]string, error) {
+	names, err := s.Pending()
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		if err := s.Approve(name); err != nil {
+			return names[:i], err
+		}
+	}
+	return names, nil
+}
+
+// Pending returns the names of cases with an actual render awaiting
+// approval, sorted.
+func (s *Store) Pending() ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(s.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == s.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, actualSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Dir, strings.TrimSuffix(path, actualSuffix))
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+func (s *Store) caseOptions(c Case) *visualdiff.Options {
+	var opts visualdiff.Options
+	if s.Options != nil {
+		opts = *s.Options
+	}
+	if len(c.Ignore) > 0 {
+		opts.Ignore = append(append([]visualdiff.Region(nil), opts.Ignore...), c.Ignore...)
+	}
+	return &opts
+}
+
+func (s *Store) removeArtifacts(name string) error {
+	if err := removeIfExists(s.path(name, actualSuffix)); err != nil {
+		return err
+	}
+	return removeIfExists(s.path(name, diffSuffix))
+}
+
+// toPNG returns a capture as PNG, converting JPEG captures so baselines
+// have a single format.
+func toPNG(data []byte) ([]byte, error) {
+	if http.DetectContentType(data) == "image/png" {
+		return data, nil
+	}
+	img, err := visualdiff.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %s capture; use PNG or JPEG", http.DetectContentType(data))
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// testCaseName turns a test name into a case name, replacing characters
+// that are not allowed (subtest names may contain "#", spaces and so on).
+func testCaseName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == '/' || r == '.' || r == '-' || r == '_',
+			r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
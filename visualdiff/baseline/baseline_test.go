@@ -0,0 +1,306 @@
+package baseline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	rs "github.com/Render-Screenshot/rs-go"
+	"github.com/Render-Screenshot/rs-go/rsmock"
+	"github.com/Render-Screenshot/rs-go/visualdiff"
+)
+
+func solid(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+var (
+	white = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	black = color.NRGBA{A: 255}
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newStore returns a store whose client renders whatever *render holds.
+func newStore(t *testing.T, render *[]byte) *Store {
+	t.Helper()
+	return &Store{
+		Dir: t.TempDir(),
+		Client: &rsmock.Client{TakeFunc: func(ctx context.Context, o *rs.TakeOptions) ([]byte, error) {
+			return *render, nil
+		}},
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestStoreLifecycle(t *testing.T) {
+	render := encodePNG(t, solid(10, 10, white))
+	s := newStore(t, &render)
+	ctx := context.Background()
+	c := Case{Name: "pages/home", Options: rs.URL("https://example.com")}
+
+	out, err := s.Check(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != StatusMissing || out.OK() || !exists(out.Actual) || exists(out.Baseline) {
+		t.Fatalf("first check = %+v, want missing with an actual render", out)
+	}
+	if want := filepath.Join(s.Dir, "pages", "home.actual.png"); out.Actual != want {
+		t.Errorf("Actual = %s, want %s", out.Actual, want)
+	}
+
+	if pending, _ := s.Pending(); !reflect.DeepEqual(pending, []string{"pages/home"}) {
+		t.Errorf("Pending() = %v", pending)
+	}
+	if err := s.Approve("pages/home"); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(out.Baseline) || exists(out.Actual) {
+		t.Error("Approve did not promote the actual render")
+	}
+
+	out, err = s.Check(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != StatusPassed || !out.OK() || out.Result == nil || !out.Result.Equal() {
+		t.Errorf("unchanged check = %+v, want passed", out)
+	}
+
+	changed := solid(10, 10, white)
+	changed.Set(3, 3, black)
+	render = encodePNG(t, changed)
+	out, err = s.Check(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != StatusFailed || out.Result.DiffPixels != 1 || !exists(out.Actual) || !exists(out.Diff) {
+		t.Fatalf("changed check = %+v, want failed with artifacts", out)
+	}
+	if diff, err := os.ReadFile(out.Diff); err != nil || !bytes.HasPrefix(diff, []byte("\x89PNG")) {
+		t.Errorf("diff artifact is not a PNG: %v", err)
+	}
+
+	// Restoring the page clears stale artifacts.
+	render = encodePNG(t, solid(10, 10, white))
+	out, err = s.Check(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != StatusPassed || exists(s.path(c.Name, actualSuffix)) || exists(s.path(c.Name, diffSuffix)) {
+		t.Errorf("restored check = %+v, want passed without artifacts", out)
+	}
+}
+
+func TestStoreMaxMismatchAndIgnore(t *testing.T) {
+	render := encodePNG(t, solid(10, 10, white))
+	s := newStore(t, &render)
+	s.Update = true
+	ctx := context.Background()
+	if _, err := s.Check(ctx, Case{Name: "home"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Update = false
+
+	changed := solid(10, 10, white)
+	changed.Set(0, 0, black)
+	render = encodePNG(t, changed)
+
+	s.MaxMismatch = 1
+	if out, _ := s.Check(ctx, Case{Name: "home"}); out.Status != StatusPassed {
+		t.Errorf("1%% mismatch with MaxMismatch 1: status = %s, want passed", out.Status)
+	}
+	s.MaxMismatch = 0
+	if out, _ := s.Check(ctx, Case{Name: "home", Ignore: []visualdiff.Region{visualdiff.Rect(0, 0, 1, 1)}}); out.Status != StatusPassed {
+		t.Errorf("ignored change: status = %s, want passed", out.Status)
+	}
+	if s.Options != nil {
+		t.Error("case regions leaked into the store options")
+	}
+
+	render = encodePNG(t, solid(10, 11, white))
+	s.MaxMismatch = 50
+	if out, _ := s.Check(ctx, Case{Name: "home"}); out.Status != StatusFailed {
+		t.Errorf("size change: status = %s, want failed regardless of MaxMismatch", out.Status)
+	}
+}
+
+func TestStoreUpdate(t *testing.T) {
+	render := encodePNG(t, solid(10, 10, white))
+	s := newStore(t, &render)
+	s.Update = true
+	ctx := context.Background()
+
+	out, err := s.Check(ctx, Case{Name: "home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != StatusUpdated || !exists(out.Baseline) {
+		t.Errorf("missing baseline in update mode = %+v, want updated", out)
+	}
+	info, _ := os.Stat(out.Baseline)
+
+	// A matching render leaves the baseline untouched.
+	if out, _ = s.Check(ctx, Case{Name: "home"}); out.Status != StatusPassed {
+		t.Errorf("matching render in update mode: status = %s, want passed", out.Status)
+	}
+	if again, _ := os.Stat(out.Baseline); !again.ModTime().Equal(info.ModTime()) {
+		t.Error("matching render rewrote the baseline")
+	}
+
+	render = encodePNG(t, solid(10, 10, black))
+	if out, _ = s.Check(ctx, Case{Name: "home"}); out.Status != StatusUpdated {
+		t.Errorf("changed render in update mode: status = %s, want updated", out.Status)
+	}
+	baseline, _ := os.ReadFile(out.Baseline)
+	if !bytes.Equal(baseline, render) {
+		t.Error("baseline was not replaced by the current render")
+	}
+}
+
+func TestStoreConvertsJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, solid(8, 8, white), nil); err != nil {
+		t.Fatal(err)
+	}
+	render := buf.Bytes()
+	s := newStore(t, &render)
+	s.Update = true
+	out, err := s.Check(context.Background(), Case{Name: "jpeg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out.Baseline)
+	if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Error("JPEG capture was not stored as PNG")
+	}
+
+	render = []byte("%PDF-1.7")
+	if _, err := s.Check(context.Background(), Case{Name: "pdf"}); err == nil || !strings.Contains(err.Error(), "PNG or JPEG") {
+		t.Errorf("PDF capture error = %v", err)
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	render := encodePNG(t, solid(1, 1, white))
+	s := newStore(t, &render)
+	for _, name := range []string{"", "../escape", "/abs", "a/../b", ".hidden", "home.actual", "a\\b"} {
+		if _, err := s.Check(context.Background(), Case{Name: name}); err == nil {
+			t.Errorf("Check(%q) error = nil", name)
+		}
+	}
+	if err := s.Approve("nothing"); err == nil || !strings.Contains(err.Error(), "no pending render") {
+		t.Errorf("Approve() error = %v", err)
+	}
+
+	s.Client = &rsmock.Client{TakeFunc: func(context.Context, *rs.TakeOptions) ([]byte, error) {
+		return nil, &rs.Error{Code: rs.CodeTimeout, Message: "timed out"}
+	}}
+	if _, err := s.Check(context.Background(), Case{Name: "home"}); err == nil {
+		t.Error("capture failure: error = nil")
+	}
+}
+
+func TestStoreApproveAll(t *testing.T) {
+	render := encodePNG(t, solid(4, 4, white))
+	s := newStore(t, &render)
+	if names, err := s.Pending(); err != nil || len(names) != 0 {
+		t.Errorf("Pending() on an empty store = %v, %v", names, err)
+	}
+	s.Dir = filepath.Join(s.Dir, "not-created-yet")
+	if names, err := s.Pending(); err != nil || len(names) != 0 {
+		t.Errorf("Pending() on a missing directory = %v, %v", names, err)
+	}
+
+	for _, name := range []string{"b", "a/mobile", "a/desktop"} {
+		if _, err := s.Check(context.Background(), Case{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := s.ApproveAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a/desktop", "a/mobile", "b"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ApproveAll() = %v, want %v", names, want)
+	}
+	if pending, _ := s.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() after ApproveAll = %v", pending)
+	}
+}
+
+var _ TB = (*testing.T)(nil)
+
+// recordingTB captures test failures reported by Assert.
+type recordingTB struct {
+	name   string
+	errors []string
+	logs   []string
+}
+
+func (r *recordingTB) Helper()      {}
+func (r *recordingTB) Name() string { return r.name }
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+func (r *recordingTB) Fatalf(format string, args ...interface{}) {
+	panic(fmt.Sprintf(format, args...))
+}
+func (r *recordingTB) Logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func TestStoreAssert(t *testing.T) {
+	render := encodePNG(t, solid(4, 4, white))
+	s := newStore(t, &render)
+	tb := &recordingTB{name: "TestHome/mobile #01"}
+
+	out := s.Assert(tb, Case{Options: rs.URL("https://example.com")})
+	if out.Name != "TestHome/mobile__01" {
+		t.Errorf("Name = %q, want it derived from the test name", out.Name)
+	}
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "-update") {
+		t.Errorf("missing baseline errors = %v", tb.errors)
+	}
+
+	s.Update = true
+	tb.errors = nil
+	s.Assert(tb, Case{})
+	if len(tb.errors) != 0 || len(tb.logs) != 1 {
+		t.Errorf("update: errors = %v, logs = %v", tb.errors, tb.logs)
+	}
+
+	s.Update = false
+	render = encodePNG(t, solid(4, 4, black))
+	s.Assert(tb, Case{})
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "100.000%") || !strings.Contains(tb.errors[0], ".diff.png") {
+		t.Errorf("mismatch errors = %v", tb.errors)
+	}
+}
@@ -0,0 +1,133 @@
+package visualdiff
+
+import (
+	"image"
+	"image/color"
+)
+
+// colorDelta returns the squared YIQ distance between two colours, negative
+// if the second is brighter. With yOnly it returns the brightness
+// difference alone. Translucent colours are blended with white first.
+func colorDelta(a, b color.NRGBA, yOnly bool) float64 {
+	if a == b {
+		return 0
+	}
+	r1, g1, b1 := blendWhite(a)
+	r2, g2, b2 := blendWhite(b)
+
+	y := rgb2y(r1, g1, b1) - rgb2y(r2, g2, b2)
+	if yOnly {
+		return y
+	}
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+	delta := 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+	if y > 0 {
+		return -delta
+	}
+	return delta
+}
+
+func blendWhite(c color.NRGBA) (r, g, b float64) {
+	a := float64(c.A) / 255
+	blend := func(v uint8) float64 { return 255 + (float64(v)-255)*a }
+	return blend(c.R), blend(c.G), blend(c.B)
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+// antialiased reports whether the pixel at x, y of img looks like
+// anti-aliasing: it lies on a brightness slope between a darkest and a
+// brightest neighbour, and one of those sits in a flat area of both images.
+func antialiased(img *image.NRGBA, x, y int, other *image.NRGBA) bool {
+	b := img.Rect
+	x0, y0 := maxInt(x-1, b.Min.X), maxInt(y-1, b.Min.Y)
+	x2, y2 := minInt(x+1, b.Max.X-1), minInt(y+1, b.Max.Y-1)
+	center := img.NRGBAAt(x, y)
+
+	zeroes := 0
+	if x == x0 || x == x2 || y == y0 || y == y2 {
+		zeroes = 1
+	}
+	var min, max float64
+	var minX, minY, maxX, maxY int
+	for nx := x0; nx <= x2; nx++ {
+		for ny := y0; ny <= y2; ny++ {
+			if nx == x && ny == y {
+				continue
+			}
+			delta := colorDelta(center, img.NRGBAAt(nx, ny), true)
+			switch {
+			case delta == 0:
+				zeroes++
+				// More than two equal neighbours means a flat area or a
+				// line, not a slope.
+				if zeroes > 2 {
+					return false
+				}
+			case delta < min:
+				min, minX, minY = delta, nx, ny
+			case delta > max:
+				max, maxX, maxY = delta, nx, ny
+			}
+		}
+	}
+	if min == 0 || max == 0 {
+		return false
+	}
+	return (hasManySiblings(img, minX, minY) && hasManySiblings(other, minX, minY)) ||
+		(hasManySiblings(img, maxX, maxY) && hasManySiblings(other, maxX, maxY))
+}
+
+// hasManySiblings reports whether the pixel at x, y has at least three
+// identical neighbours (counting the image edge as one).
+func hasManySiblings(img *image.NRGBA, x, y int) bool {
+	b := img.Rect
+	if !(image.Point{X: x, Y: y}).In(b) {
+		return false
+	}
+	x0, y0 := maxInt(x-1, b.Min.X), maxInt(y-1, b.Min.Y)
+	x2, y2 := minInt(x+1, b.Max.X-1), minInt(y+1, b.Max.Y-1)
+	center := img.NRGBAAt(x, y)
+
+	zeroes := 0
+	if x == x0 || x == x2 || y == y0 || y == y2 {
+		zeroes = 1
+	}
+	for nx := x0; nx <= x2; nx++ {
+		for ny := y0; ny <= y2; ny++ {
+			if nx == x && ny == y {
+				continue
+			}
+			if img.NRGBAAt(nx, ny) == center {
+				zeroes++
+			}
+			if zeroes > 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// faded returns c as a light grey, the background of the diff image.
+func faded(c color.NRGBA) color.NRGBA {
+	r, g, b := blendWhite(c)
+	v := uint8(255 + (rgb2y(r, g, b)-255)*0.1)
+	return color.NRGBA{R: v, G: v, B: v, A: 255}
+}
+
+// mix blends c towards over by amount (0 to 1).
+func mix(c, over color.NRGBA, amount float64) color.NRGBA {
+	m := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*amount) }
+	return color.NRGBA{R: m(c.R, over.R), G: m(c.G, over.G), B: m(c.B, over.B), A: 255}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,49 @@
+package visualdiff
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestColorDelta(t *testing.T) {
+	if d := colorDelta(white, white, false); d != 0 {
+		t.Errorf("white vs white = %v, want 0", d)
+	}
+	if d := colorDelta(black, white, false); d < 30000 || d > 35215 {
+		t.Errorf("black vs white = %v, want a large positive delta", d)
+	}
+	if d := colorDelta(white, black, false); d >= 0 {
+		t.Errorf("white vs black = %v, want negative (first is brighter)", d)
+	}
+	if y := colorDelta(black, white, true); y > -254 || y < -256 {
+		t.Errorf("brightness delta = %v, want about -255", y)
+	}
+
+	// Fully transparent pixels blend to white.
+	transparent := color.NRGBA{R: 12, G: 34, B: 56}
+	if d := colorDelta(transparent, white, false); d != 0 {
+		t.Errorf("transparent vs white = %v, want 0", d)
+	}
+}
+
+func TestHasManySiblings(t *testing.T) {
+	img := solid(3, 3, white)
+	if !hasManySiblings(img, 1, 1) {
+		t.Error("flat area: hasManySiblings = false")
+	}
+	dot := solid(3, 3, white)
+	dot.Set(1, 1, black)
+	dot.Set(0, 0, black)
+	if hasManySiblings(dot, 1, 1) {
+		t.Error("isolated dot: hasManySiblings = true")
+	}
+	if hasManySiblings(img, 5, 5) {
+		t.Error("outside the image: hasManySiblings = true")
+	}
+}
+
+func TestFaded(t *testing.T) {
+	if got := faded(black); got.R != got.B || got.R < 225 || got.A != 255 {
+		t.Errorf("faded(black) = %v, want opaque light grey", got)
+	}
+}
@@ -0,0 +1,261 @@
+// Package visualdiff compares two captures for visual regression testing.
+//
+//	res, err := visualdiff.CompareBytes(baseline, current, &visualdiff.Options{
+//		Tolerance:          0.1,
+//		DetectAntiAliasing: true,
+//		Ignore:             []visualdiff.Region{visualdiff.Rect(0, 0, 1280, 64)},
+//	})
+//	if res.Mismatch > 0.5 {
+//		res.WritePNG(diffFile)
+//	}
+//
+// Colour differences are measured in the YIQ colour space, so they follow
+// perceived brightness rather than raw RGB distance. Anti-aliasing detection
+// follows V. Vysniauskas, "Anti-aliased Pixel and Intensity Slope Detector"
+// (2009), as popularised by pixelmatch.
+package visualdiff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // register the JPEG decoder
+	"image/png"
+	"io"
+	"math"
+)
+
+// Default highlight colours of the diff image.
+var (
+	DefaultDiffColor      = color.NRGBA{R: 255, A: 255}
+	DefaultAntiAliasColor = color.NRGBA{R: 255, G: 200, A: 255}
+	DefaultIgnoreColor    = color.NRGBA{R: 80, G: 120, B: 255, A: 255}
+)
+
+// Options configures a comparison. The zero value requires an exact match.
+type Options struct {
+	// Tolerance is the per-pixel colour difference, from 0 to 1, below
+	// which pixels count as equal. 0.1 absorbs rendering noise while
+	// catching visible changes.
+	Tolerance float64
+	// DetectAntiAliasing excludes pixels that look like anti-aliasing
+	// (font and edge smoothing) from the mismatch. They are counted in
+	// Result.AntiAliasedPixels and highlighted in AntiAliasColor.
+	DetectAntiAliasing bool
+	// Ignore lists regions excluded from the comparison, such as clocks,
+	// ads or carousels.
+	Ignore []Region
+	// DiffColor, AntiAliasColor and IgnoreColor highlight pixels in the
+	// diff image. Colours with zero alpha use the defaults.
+	DiffColor      color.NRGBA
+	AntiAliasColor color.NRGBA
+	IgnoreColor    color.NRGBA
+}
+
+// Region is a rectangle excluded from a comparison, in image pixels.
+type Region struct {
+	Rect image.Rectangle
+	// Name labels the region, e.g. with the CSS selector it covers.
+	Name string
+}
+
+// Rect returns a region of width×height pixels at x, y.
+func Rect(x, y, width, height int) Region {
+	return Region{Rect: image.Rect(x, y, x+width, y+height)}
+}
+
+// BoundingBox is an element box in CSS pixels, as returned by the
+// browser's getBoundingClientRect (whose JSON form it decodes).
+type BoundingBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// SelectorBox returns the region covered by an element's bounding box.
+// scale is the capture's device scale factor (0 means 1); the box is
+// rounded outwards so partially covered pixels are ignored too.
+func SelectorBox(selector string, box BoundingBox, scale float64) Region {
+	if scale <= 0 {
+		scale = 1
+	}
+	return Region{
+		Rect: image.Rect(
+			int(math.Floor(box.X*scale)),
+			int(math.Floor(box.Y*scale)),
+			int(math.Ceil((box.X+box.Width)*scale)),
+			int(math.Ceil((box.Y+box.Height)*scale)),
+		),
+		Name: selector,
+	}
+}
+
+// Result is the outcome of a comparison.
+type Result struct {
+	// Width and Height are the compared area: the larger of the two
+	// images in each dimension. Pixels present in only one image differ.
+	Width, Height int
+	// SizeMismatch reports whether the images have different sizes.
+	SizeMismatch bool
+	// ComparedPixels excludes ignored pixels.
+	ComparedPixels    int
+	DiffPixels        int
+	AntiAliasedPixels int
+	IgnoredPixels     int
+	// Mismatch is DiffPixels as a percentage of ComparedPixels.
+	Mismatch float64
+	// DiffBounds is the smallest rectangle containing every differing
+	// pixel, empty if there are none.
+	DiffBounds image.Rectangle
+	// Diff shows the first image faded to grey with differing pixels,
+	// anti-aliased pixels and ignored regions highlighted.
+	Diff *image.NRGBA
+}
+
+// Equal reports whether no pixels differ.
+func (r *Result) Equal() bool {
+	return r.DiffPixels == 0
+}
+
+// WritePNG encodes the diff image as PNG.
+func (r *Result) WritePNG(w io.Writer) error {
+	return png.Encode(w, r.Diff)
+}
+
+// Decode decodes a PNG or JPEG capture.
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("visualdiff: %w", err)
+	}
+	return img, nil
+}
+
+// CompareBytes decodes two PNG or JPEG captures and compares them.
+func CompareBytes(a, b []byte, opts *Options) (*Result, error) {
+	imgA, err := Decode(a)
+	if err != nil {
+		return nil, err
+	}
+	imgB, err := Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(imgA, imgB, opts)
+}
+
+// Compare compares two images pixel by pixel. opts may be nil.
+func Compare(a, b image.Image, opts *Options) (*Result, error) {
+	if a == nil || b == nil {
+		return nil, errors.New("visualdiff: nil image")
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.Tolerance < 0 || opts.Tolerance > 1 {
+		return nil, fmt.Errorf("visualdiff: tolerance %v outside [0, 1]", opts.Tolerance)
+	}
+
+	pa, pb := toNRGBA(a), toNRGBA(b)
+	wa, ha := pa.Rect.Dx(), pa.Rect.Dy()
+	wb, hb := pb.Rect.Dx(), pb.Rect.Dy()
+	res := &Result{
+		Width:        maxInt(wa, wb),
+		Height:       maxInt(ha, hb),
+		SizeMismatch: wa != wb || ha != hb,
+	}
+	res.Diff = image.NewNRGBA(image.Rect(0, 0, res.Width, res.Height))
+
+	ignored := ignoreMask(opts.Ignore, res.Width, res.Height)
+	diffColor := colorOr(opts.DiffColor, DefaultDiffColor)
+	aaColor := colorOr(opts.AntiAliasColor, DefaultAntiAliasColor)
+	ignoreColor := colorOr(opts.IgnoreColor, DefaultIgnoreColor)
+	// maxDelta is the largest YIQ delta still considered equal; 35215 is
+	// the largest possible delta between two colours.
+	maxDelta := 35215 * opts.Tolerance * opts.Tolerance
+
+	for y := 0; y < res.Height; y++ {
+		for x := 0; x < res.Width; x++ {
+			inA, inB := x < wa && y < ha, x < wb && y < hb
+			var ca, cb color.NRGBA
+			if inA {
+				ca = pa.NRGBAAt(x, y)
+			}
+			if inB {
+				cb = pb.NRGBAAt(x, y)
+			}
+
+			if ignored[y*res.Width+x] {
+				res.IgnoredPixels++
+				res.Diff.SetNRGBA(x, y, mix(faded(ca), ignoreColor, 0.35))
+				continue
+			}
+			res.ComparedPixels++
+
+			switch {
+			case !inA || !inB:
+				res.markDiff(x, y, diffColor)
+			case math.Abs(colorDelta(ca, cb, false)) <= maxDelta:
+				res.Diff.SetNRGBA(x, y, faded(ca))
+			case opts.DetectAntiAliasing && (antialiased(pa, x, y, pb) || antialiased(pb, x, y, pa)):
+				res.AntiAliasedPixels++
+				res.Diff.SetNRGBA(x, y, aaColor)
+			default:
+				res.markDiff(x, y, diffColor)
+			}
+		}
+	}
+	if res.ComparedPixels > 0 {
+		res.Mismatch = 100 * float64(res.DiffPixels) / float64(res.ComparedPixels)
+	}
+	return res, nil
+}
+
+func (r *Result) markDiff(x, y int, c color.NRGBA) {
+	r.DiffPixels++
+	r.Diff.SetNRGBA(x, y, c)
+	r.DiffBounds = r.DiffBounds.Union(image.Rect(x, y, x+1, y+1))
+}
+
+// toNRGBA returns img as an NRGBA image with its origin at 0, 0.
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := img.Bounds()
+	n := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(n, n.Rect, img, b.Min, draw.Src)
+	return n
+}
+
+func ignoreMask(regions []Region, width, height int) []bool {
+	mask := make([]bool, width*height)
+	bounds := image.Rect(0, 0, width, height)
+	for _, r := range regions {
+		rect := r.Rect.Canon().Intersect(bounds)
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				mask[y*width+x] = true
+			}
+		}
+	}
+	return mask
+}
+
+func colorOr(c, def color.NRGBA) color.NRGBA {
+	if c.A == 0 {
+		return def
+	}
+	return c
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,235 @@
+package visualdiff
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func solid(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+var (
+	white = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	black = color.NRGBA{A: 255}
+)
+
+func TestCompareIdentical(t *testing.T) {
+	res, err := Compare(solid(20, 10, white), solid(20, 10, white), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal() || res.Mismatch != 0 || res.ComparedPixels != 200 {
+		t.Errorf("result = %+v, want equal", res)
+	}
+	if got := res.Diff.Bounds(); got != image.Rect(0, 0, 20, 10) {
+		t.Errorf("Diff.Bounds() = %v", got)
+	}
+	if !res.DiffBounds.Empty() {
+		t.Errorf("DiffBounds = %v, want empty", res.DiffBounds)
+	}
+}
+
+func TestCompareChangedPixels(t *testing.T) {
+	a := solid(10, 10, white)
+	b := solid(10, 10, white)
+	b.Set(2, 3, black)
+	b.Set(7, 5, black)
+
+	res, err := Compare(a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.DiffPixels != 2 || res.Mismatch != 2 {
+		t.Errorf("DiffPixels = %d, Mismatch = %v, want 2 and 2%%", res.DiffPixels, res.Mismatch)
+	}
+	if want := image.Rect(2, 3, 8, 6); res.DiffBounds != want {
+		t.Errorf("DiffBounds = %v, want %v", res.DiffBounds, want)
+	}
+	if got := res.Diff.NRGBAAt(2, 3); got != DefaultDiffColor {
+		t.Errorf("diff pixel = %v, want %v", got, DefaultDiffColor)
+	}
+	if got := res.Diff.NRGBAAt(0, 0); got.R != got.G || got.R < 200 {
+		t.Errorf("unchanged pixel = %v, want light grey", got)
+	}
+}
+
+func TestCompareTolerance(t *testing.T) {
+	a := solid(4, 4, white)
+	b := solid(4, 4, color.NRGBA{R: 250, G: 250, B: 250, A: 255})
+
+	res, _ := Compare(a, b, &Options{})
+	if res.DiffPixels != 16 {
+		t.Errorf("exact: DiffPixels = %d, want 16", res.DiffPixels)
+	}
+	res, _ = Compare(a, b, &Options{Tolerance: 0.1})
+	if !res.Equal() {
+		t.Errorf("tolerance 0.1: DiffPixels = %d, want 0", res.DiffPixels)
+	}
+	res, _ = Compare(a, solid(4, 4, black), &Options{Tolerance: 0.9})
+	if res.DiffPixels != 16 {
+		t.Errorf("black vs white at 0.9: DiffPixels = %d, want 16", res.DiffPixels)
+	}
+
+	for _, tol := range []float64{-0.1, 1.5} {
+		if _, err := Compare(a, b, &Options{Tolerance: tol}); err == nil {
+			t.Errorf("Tolerance %v: error = nil", tol)
+		}
+	}
+}
+
+func TestCompareIgnore(t *testing.T) {
+	a := solid(10, 10, white)
+	b := solid(10, 10, white)
+	for x := 0; x < 10; x++ {
+		b.Set(x, 0, black) // a changing header row
+	}
+	b.Set(5, 5, black)
+
+	res, err := Compare(a, b, &Options{Ignore: []Region{Rect(0, 0, 10, 1), Rect(8, 8, 20, 20)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IgnoredPixels != 14 || res.ComparedPixels != 86 || res.DiffPixels != 1 {
+		t.Errorf("ignored = %d, compared = %d, diff = %d, want 14, 86, 1", res.IgnoredPixels, res.ComparedPixels, res.DiffPixels)
+	}
+	if want := 100.0 / 86; math.Abs(res.Mismatch-want) > 1e-9 {
+		t.Errorf("Mismatch = %v, want %v", res.Mismatch, want)
+	}
+	if got := res.Diff.NRGBAAt(0, 0); got.B <= got.R {
+		t.Errorf("ignored pixel = %v, want tinted with the ignore colour", got)
+	}
+}
+
+func TestCompareSizeMismatch(t *testing.T) {
+	res, err := Compare(solid(10, 10, white), solid(10, 12, white), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.SizeMismatch || res.Width != 10 || res.Height != 12 || res.DiffPixels != 20 {
+		t.Errorf("result = %+v, want 20 differing pixels in a 10x12 area", res)
+	}
+	if want := image.Rect(0, 10, 10, 12); res.DiffBounds != want {
+		t.Errorf("DiffBounds = %v, want %v", res.DiffBounds, want)
+	}
+}
+
+// edge returns an image black left of column 5 and white from it, with
+// column 5 drawn in c to mimic anti-aliasing.
+func edge(c color.NRGBA) *image.NRGBA {
+	img := solid(10, 10, white)
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 5; x++ {
+			img.Set(x, y, black)
+		}
+		img.Set(5, y, c)
+	}
+	return img
+}
+
+func TestCompareAntiAliasing(t *testing.T) {
+	a := edge(white)
+	b := edge(color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+
+	res, _ := Compare(a, b, &Options{})
+	if res.DiffPixels != 10 {
+		t.Errorf("without detection: DiffPixels = %d, want 10", res.DiffPixels)
+	}
+	res, _ = Compare(a, b, &Options{DetectAntiAliasing: true})
+	if res.DiffPixels != 0 || res.AntiAliasedPixels != 10 {
+		t.Errorf("with detection: DiffPixels = %d, AntiAliasedPixels = %d, want 0 and 10", res.DiffPixels, res.AntiAliasedPixels)
+	}
+	if got := res.Diff.NRGBAAt(5, 5); got != DefaultAntiAliasColor {
+		t.Errorf("anti-aliased pixel = %v, want %v", got, DefaultAntiAliasColor)
+	}
+
+	// A real change is still reported.
+	c := edge(white)
+	for y := 2; y < 5; y++ {
+		for x := 6; x < 9; x++ {
+			c.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	res, _ = Compare(a, c, &Options{DetectAntiAliasing: true})
+	if res.DiffPixels != 9 {
+		t.Errorf("real change: DiffPixels = %d, want 9", res.DiffPixels)
+	}
+}
+
+func TestCompareCustomColors(t *testing.T) {
+	b := solid(2, 2, white)
+	b.Set(0, 0, black)
+	magenta := color.NRGBA{R: 255, B: 255, A: 255}
+	res, _ := Compare(solid(2, 2, white), b, &Options{DiffColor: magenta})
+	if got := res.Diff.NRGBAAt(0, 0); got != magenta {
+		t.Errorf("diff pixel = %v, want %v", got, magenta)
+	}
+}
+
+func TestCompareOffsetBounds(t *testing.T) {
+	big := solid(20, 20, white)
+	big.Set(12, 12, black)
+	sub := big.SubImage(image.Rect(10, 10, 20, 20))
+
+	res, err := Compare(solid(10, 10, white), sub, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.DiffPixels != 1 || res.DiffBounds != image.Rect(2, 2, 3, 3) {
+		t.Errorf("DiffPixels = %d, DiffBounds = %v, want the pixel at 2,2", res.DiffPixels, res.DiffBounds)
+	}
+}
+
+func TestSelectorBox(t *testing.T) {
+	r := SelectorBox("#clock", BoundingBox{X: 10.5, Y: 20, Width: 100.2, Height: 30}, 2)
+	if want := image.Rect(21, 40, 222, 100); r.Rect != want || r.Name != "#clock" {
+		t.Errorf("SelectorBox() = %+v, want %v", r, want)
+	}
+	if got := SelectorBox("x", BoundingBox{Width: 3, Height: 4}, 0).Rect; got != image.Rect(0, 0, 3, 4) {
+		t.Errorf("scale 0: Rect = %v", got)
+	}
+}
+
+func TestCompareBytes(t *testing.T) {
+	var pngData, jpegData bytes.Buffer
+	if err := png.Encode(&pngData, solid(8, 8, white)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, solid(8, 8, white), &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := CompareBytes(pngData.Bytes(), jpegData.Bytes(), &Options{Tolerance: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal() {
+		t.Errorf("PNG vs JPEG: DiffPixels = %d, want 0", res.DiffPixels)
+	}
+
+	var out bytes.Buffer
+	if err := res.WritePNG(&out); err != nil {
+		t.Fatal(err)
+	}
+	if img, err := Decode(out.Bytes()); err != nil || img.Bounds() != image.Rect(0, 0, 8, 8) {
+		t.Errorf("WritePNG output decoded = %v, %v", img, err)
+	}
+
+	if _, err := CompareBytes([]byte("not an image"), pngData.Bytes(), nil); err == nil {
+		t.Error("invalid image: error = nil")
+	}
+	if _, err := Compare(nil, solid(1, 1, white), nil); err == nil {
+		t.Error("nil image: error = nil")
+	}
+}
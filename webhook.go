@@ -3,9 +3,9 @@ package renderscreenshot
 import (
 	"crypto/hmac"
 	"crypto/sha256"
-	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -23,22 +23,81 @@ const (
 	DefaultTolerance = 300 * time.Second
 )
 
+// Webhook verification errors. Errors returned by VerifyWebhookSignature and
+// WebhookVerifier.Verify wrap one of these with a description.
+var (
+	ErrWebhookExpired    = errors.New("webhook timestamp outside tolerance")
+	ErrWebhookMalformed  = errors.New("malformed webhook signature")
+	ErrWebhookSignature  = errors.New("invalid webhook signature")
+	ErrWebhookMissingID  = errors.New("missing webhook delivery ID")
+	ErrWebhookIDMismatch = errors.New("webhook delivery ID does not match payload")
+	ErrWebhookReplayed   = errors.New("webhook delivery already processed")
+)
+
+// webhookSignatureSchemes lists the signature schemes accepted in the
+// signature header. All of them are HMAC-SHA256 over "timestamp.payload".
+var webhookSignatureSchemes = map[string]bool{
+	"sha256": true,
+	"v1":     true,
+}
+
+// WebhookVerification describes which signature verified a webhook.
+type WebhookVerification struct {
+	// SecretIndex is the index of the matching secret in the secrets list.
+	SecretIndex int
+	// Scheme is the scheme of the matching signature, e.g. "sha256".
+	Scheme string
+	// Timestamp is the verified delivery timestamp.
+	Timestamp time.Time
+	// DeliveryID is the ID recorded in the ReplayStore by
+	// WebhookVerifier.Verify, if one is configured.
+	DeliveryID string
+}
+
 // VerifyWebhook verifies a webhook signature using HMAC-SHA256.
 // It checks the timestamp is within the tolerance window and performs
 // a timing-safe comparison of the signature.
+//
+// Use VerifyWebhookSignature to accept several secrets or to find out
+// why verification failed.
 func VerifyWebhook(payload, signature, timestamp, secret string, tolerance time.Duration) bool {
-	if payload == "" || signature == "" || timestamp == "" || secret == "" {
-		return false
-	}
+	_, err := VerifyWebhookSignature(payload, signature, timestamp, []string{secret}, tolerance)
+	return err == nil
+}
 
+// VerifyWebhookSignature verifies a webhook against any of secrets, so that
+// old and new secrets can both be accepted during rotation.
+//
+// The signature header may hold several signatures separated by commas or
+// spaces, each as "scheme=hex" (for example "sha256=ab12.., v1=cd34.."); a
+// "t=<unix>" element is used as the timestamp when timestamp is empty.
+// Unsupported schemes are ignored.
+//
+// On failure the error wraps ErrWebhookExpired, ErrWebhookMalformed or
+// ErrWebhookSignature.
+func VerifyWebhookSignature(payload, signature, timestamp string, secrets []string, tolerance time.Duration) (*WebhookVerification, error) {
 	if tolerance == 0 {
 		tolerance = DefaultTolerance
 	}
+	if payload == "" {
+		return nil, fmt.Errorf("%w: empty payload", ErrWebhookMalformed)
+	}
+
+	sigs, headerTS := parseSignatureHeader(signature)
+	if timestamp == "" {
+		timestamp = headerTS
+	}
+	if timestamp == "" {
+		return nil, fmt.Errorf("%w: missing timestamp", ErrWebhookMalformed)
+	}
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("%w: no supported signature in %q", ErrWebhookMalformed, signature)
+	}
 
 	// Parse and validate timestamp
 	ts, err := strconv.ParseInt(timestamp, 10, 64)
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("%w: invalid timestamp %q", ErrWebhookMalformed, timestamp)
 	}
 
 	age := time.Now().Unix() - ts
@@ -46,18 +105,77 @@ func VerifyWebhook(payload, signature, timestamp, secret string, tolerance time.
 		age = -age
 	}
 	if age > int64(tolerance.Seconds()) {
-		return false
+		return nil, fmt.Errorf("%w: timestamp is %ds from now, tolerance is %ds",
+			ErrWebhookExpired, age, int64(tolerance.Seconds()))
 	}
 
-	// Compute expected signature: sha256=HMAC-SHA256("timestamp.payload", secret)
-	signedPayload := fmt.Sprintf("%s.%s", timestamp, payload)
+	checked := 0
+	for i, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		checked++
+		expected := webhookMAC(payload, secret, timestamp)
+
+		for _, sig := range sigs {
+			// Timing-safe comparison
+			if hmac.Equal(expected, sig.mac) {
+				return &WebhookVerification{
+					SecretIndex: i,
+					Scheme:      sig.scheme,
+					Timestamp:   time.Unix(ts, 0),
+				}, nil
+			}
+		}
+	}
+	if checked == 0 {
+		return nil, fmt.Errorf("%w: no secret configured", ErrWebhookSignature)
+	}
+	return nil, fmt.Errorf("%w: none of %d signature(s) matched %d secret(s)",
+		ErrWebhookSignature, len(sigs), checked)
+}
+
+// webhookMAC computes the HMAC-SHA256 of "timestamp.payload" keyed with
+// secret, which every supported signature scheme carries.
+func webhookMAC(payload, secret, timestamp string) []byte {
 	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(signedPayload))
-	expectedHash := hex.EncodeToString(mac.Sum(nil))
-	expected := "sha256=" + expectedHash
+	mac.Write([]byte(timestamp + "." + payload))
+	return mac.Sum(nil)
+}
 
-	// Timing-safe comparison
-	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+type webhookSignature struct {
+	scheme string
+	mac    []byte
+}
+
+// parseSignatureHeader splits a signature header into its supported
+// signatures and an optional "t=" timestamp.
+func parseSignatureHeader(header string) ([]webhookSignature, string) {
+	var sigs []webhookSignature
+	var timestamp string
+	fields := strings.FieldsFunc(header, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	for _, field := range fields {
+		scheme, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		scheme = strings.ToLower(scheme)
+		if scheme == "t" {
+			timestamp = value
+			continue
+		}
+		if !webhookSignatureSchemes[scheme] {
+			continue
+		}
+		mac, err := hex.DecodeString(value)
+		if err != nil || len(mac) != sha256.Size {
+			continue
+		}
+		sigs = append(sigs, webhookSignature{scheme: scheme, mac: mac})
+	}
+	return sigs, timestamp
 }
 
 // ParseWebhook parses a webhook payload into a WebhookEvent.
@@ -73,6 +191,7 @@ func ParseWebhook(payload string) (*WebhookEvent, error) {
 
 	event := &WebhookEvent{
 		Data: map[string]interface{}{},
+		Raw:  json.RawMessage(payload),
 	}
 
 	// Event type from "type" or "event" field
@@ -92,6 +211,7 @@ func ParseWebhook(payload string) (*WebhookEvent, error) {
 
 	if v, ok := data["data"].(map[string]interface{}); ok {
 		event.Data = v
+		event.Tags = parseTags(v["tags"])
 	}
 
 	return event, nil
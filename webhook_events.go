@@ -0,0 +1,104 @@
+package renderscreenshot
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Webhook event types.
+const (
+	EventScreenshotCompleted = "screenshot.completed"
+	EventScreenshotFailed    = "screenshot.failed"
+	EventBatchCompleted      = "batch.completed"
+	EventBatchFailed         = "batch.failed"
+)
+
+// UnknownEventData is returned by WebhookEvent.Decode for event types this
+// SDK version does not know about. The original payload is preserved.
+type UnknownEventData struct {
+	Event string
+	Data  map[string]interface{}
+	Raw   json.RawMessage
+}
+
+// Decode returns the event data as a concrete type:
+//
+//   - screenshot.completed: *ScreenshotResponse
+//   - batch.completed:      *BatchResponse
+//   - screenshot.failed:    *Error
+//   - batch.failed:         *Error
+//   - anything else:        *UnknownEventData
+//
+// Use a type switch on the result.
+func (e *WebhookEvent) Decode() (interface{}, error) {
+	switch e.Event {
+	case EventScreenshotCompleted:
+		return parseScreenshotResponse(e.Data), nil
+	case EventBatchCompleted:
+		return parseBatchResponse(e.Data), nil
+	case EventScreenshotFailed, EventBatchFailed:
+		return parseEventError(e.Data), nil
+	}
+	return &UnknownEventData{Event: e.Event, Data: e.Data, Raw: e.Raw}, nil
+}
+
+// ScreenshotCompleted returns the data of a screenshot.completed event.
+func (e *WebhookEvent) ScreenshotCompleted() (*ScreenshotResponse, error) {
+	if e.Event != EventScreenshotCompleted {
+		return nil, eventTypeMismatch(e.Event, EventScreenshotCompleted)
+	}
+	return parseScreenshotResponse(e.Data), nil
+}
+
+// BatchCompleted returns the data of a batch.completed event.
+func (e *WebhookEvent) BatchCompleted() (*BatchResponse, error) {
+	if e.Event != EventBatchCompleted {
+		return nil, eventTypeMismatch(e.Event, EventBatchCompleted)
+	}
+	return parseBatchResponse(e.Data), nil
+}
+
+// Failure returns the error carried by a screenshot.failed or batch.failed event.
+func (e *WebhookEvent) Failure() (*Error, error) {
+	if e.Event != EventScreenshotFailed && e.Event != EventBatchFailed {
+		return nil, eventTypeMismatch(e.Event, EventScreenshotFailed)
+	}
+	return parseEventError(e.Data), nil
+}
+
+// parseEventError builds an *Error from a failed event's data, which may carry
+// the error either as a string or as a {"message", "code"} object.
+func parseEventError(data map[string]interface{}) *Error {
+	apiErr := &Error{Message: "capture failed", Code: CodeRenderFailed}
+	switch v := data["error"].(type) {
+	case string:
+		apiErr.Message = v
+	case map[string]interface{}:
+		if msg, ok := v["message"].(string); ok {
+			apiErr.Message = msg
+		}
+		if c, ok := v["code"].(string); ok {
+			apiErr.Code = ErrorCode(c)
+		}
+		if rid, ok := v["request_id"].(string); ok {
+			apiErr.RequestID = rid
+		}
+		apiErr.Details = parseFieldErrors(v)
+	}
+	if c, ok := data["code"].(string); ok {
+		apiErr.Code = ErrorCode(c)
+	}
+	if apiErr.RequestID == "" {
+		if id, ok := data["id"].(string); ok {
+			apiErr.RequestID = id
+		}
+	}
+	return apiErr
+}
+
+func eventTypeMismatch(got, want string) *Error {
+	return &Error{
+		Message: fmt.Sprintf("webhook event is %q, not %q", got, want),
+		Code:    CodeInvalidRequest,
+	}
+}
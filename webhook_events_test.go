@@ -0,0 +1,117 @@
+package renderscreenshot
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWebhookEventDecodeScreenshotCompleted(t *testing.T) {
+	event, _ := ParseWebhook(`{"type":"screenshot.completed","id":"evt_1","data":{
+		"id":"req_1","status":"completed",
+		"image":{"url":"https://cdn.example.com/a.png","width":1200,"height":630},
+		"cache":{"hit":false,"key":"k1"},
+		"tags":{"tenant":"acme"}}}`)
+
+	decoded, err := event.Decode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, ok := decoded.(*ScreenshotResponse)
+	if !ok {
+		t.Fatalf("Decode() = %T, want *ScreenshotResponse", decoded)
+	}
+	if resp.ID != "req_1" || resp.Image.Width != 1200 || resp.Cache.Key != "k1" || resp.Tags["tenant"] != "acme" {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	typed, err := event.ScreenshotCompleted()
+	if err != nil || typed.Image.URL != "https://cdn.example.com/a.png" {
+		t.Errorf("ScreenshotCompleted() = %+v, %v", typed, err)
+	}
+}
+
+func TestWebhookEventDecodeBatchCompleted(t *testing.T) {
+	event, _ := ParseWebhook(`{"type":"batch.completed","data":{
+		"id":"batch_1","status":"completed","total":2,"completed":1,"failed":1,
+		"results":[{"url":"https://a.com","status":"completed","image_url":"https://cdn/a.png"},
+		           {"url":"https://b.com","status":"failed","error":"timeout"}]}}`)
+
+	decoded, _ := event.Decode()
+	resp, ok := decoded.(*BatchResponse)
+	if !ok {
+		t.Fatalf("Decode() = %T, want *BatchResponse", decoded)
+	}
+	if resp.ID != "batch_1" || resp.Total != 2 || len(resp.Results) != 2 || resp.Results[1].Error != "timeout" {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	if _, err := event.ScreenshotCompleted(); err == nil {
+		t.Error("expected type mismatch error")
+	}
+}
+
+func TestWebhookEventDecodeFailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		wantMsg  string
+		wantCode ErrorCode
+	}{
+		{
+			name:     "error object",
+			payload:  `{"type":"screenshot.failed","data":{"id":"req_9","error":{"message":"Navigation timeout","code":"timeout"}}}`,
+			wantMsg:  "Navigation timeout",
+			wantCode: CodeTimeout,
+		},
+		{
+			name:     "error string",
+			payload:  `{"type":"batch.failed","data":{"id":"batch_9","error":"Batch aborted"}}`,
+			wantMsg:  "Batch aborted",
+			wantCode: CodeRenderFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, _ := ParseWebhook(tt.payload)
+			decoded, _ := event.Decode()
+			apiErr, ok := decoded.(*Error)
+			if !ok {
+				t.Fatalf("Decode() = %T, want *Error", decoded)
+			}
+			if apiErr.Message != tt.wantMsg || apiErr.Code != tt.wantCode {
+				t.Errorf("error = %+v", apiErr)
+			}
+			if apiErr.RequestID == "" {
+				t.Error("expected RequestID from data id")
+			}
+
+			failure, err := event.Failure()
+			if err != nil || failure.Message != tt.wantMsg {
+				t.Errorf("Failure() = %+v, %v", failure, err)
+			}
+		})
+	}
+}
+
+func TestWebhookEventDecodeUnknown(t *testing.T) {
+	payload := `{"type":"screenshot.archived","data":{"archive_id":"arc_1"},"extra":true}`
+	event, _ := ParseWebhook(payload)
+
+	decoded, err := event.Decode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unknown, ok := decoded.(*UnknownEventData)
+	if !ok {
+		t.Fatalf("Decode() = %T, want *UnknownEventData", decoded)
+	}
+	if unknown.Event != "screenshot.archived" || unknown.Data["archive_id"] != "arc_1" {
+		t.Errorf("unknown = %+v", unknown)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(unknown.Raw, &raw); err != nil || raw["extra"] != true {
+		t.Errorf("Raw not preserved: %s", unknown.Raw)
+	}
+}
@@ -0,0 +1,250 @@
+package renderscreenshot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWebhookMaxBodyBytes is the default cap on webhook request bodies (1 MiB).
+const DefaultWebhookMaxBodyBytes = 1 << 20
+
+// ErrWebhookInProgress is reported when a delivery arrives while the same
+// delivery is still being handled.
+var ErrWebhookInProgress = errors.New("webhook delivery is being processed")
+
+// WebhookHandlerFunc handles a verified webhook event. Returning an error
+// responds with 500 so the sender retries the delivery.
+type WebhookHandlerFunc func(ctx context.Context, event *WebhookEvent) error
+
+// WebhookHandlerOptions configures a WebhookHandler.
+type WebhookHandlerOptions struct {
+	// MaxBodyBytes caps the request body size. Defaults to DefaultWebhookMaxBodyBytes.
+	MaxBodyBytes int64
+	// Tolerance is the maximum age of the delivery timestamp. Defaults to DefaultTolerance.
+	Tolerance time.Duration
+	// Secrets are further secrets accepted alongside the handler's secret,
+	// e.g. the previous secret while a rotation is in progress.
+	Secrets []string
+	// ReplayStore, if set, makes the handler skip deliveries that were
+	// already handled successfully. See WebhookVerifier for how deliveries
+	// are identified.
+	ReplayStore ReplayStore
+	// ReplayTTL is how long handled IDs are remembered. Defaults to DefaultReplayTTL.
+	ReplayTTL time.Duration
+	// OnError, if set, is called with every rejected, duplicate or failed delivery.
+	OnError func(r *http.Request, err error)
+}
+
+// WebhookHandler is an http.Handler that verifies, parses and dispatches
+// webhook deliveries to per-event-type handlers.
+//
+// It responds with:
+//   - 405 for non-POST requests
+//   - 413 if the body exceeds MaxBodyBytes
+//   - 401 if the signature or timestamp is invalid, or the delivery ID is
+//     missing or does not match the payload while a ReplayStore is configured
+//   - 400 if the payload is not a valid event
+//   - 500 if the event handler returns an error, so the sender retries
+//   - 503 for a duplicate of a delivery that is still being handled, so the
+//     sender retries it once the outcome is known
+//   - 200 otherwise, including for event types with no registered handler
+//     and for duplicate deliveries, which are not dispatched again
+//
+// A delivery ID is recorded in the ReplayStore only once the payload has
+// parsed, and removed again when the handler fails so the sender's retry is
+// processed. In-flight deliveries are tracked per WebhookHandler, so
+// replicas sharing a ReplayStore may still acknowledge a duplicate of a
+// delivery another replica is handling.
+type WebhookHandler struct {
+	verifier WebhookVerifier
+	opts     WebhookHandlerOptions
+	mu       sync.RWMutex
+	handlers map[string]WebhookHandlerFunc
+	failed   WebhookHandlerFunc
+	fallback WebhookHandlerFunc
+
+	flightMu sync.Mutex
+	inFlight map[string]bool
+}
+
+// NewWebhookHandler creates a WebhookHandler that verifies deliveries with secret.
+func NewWebhookHandler(secret string, opts WebhookHandlerOptions) *WebhookHandler {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultWebhookMaxBodyBytes
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+	return &WebhookHandler{
+		verifier: WebhookVerifier{
+			Secret:      secret,
+			Secrets:     opts.Secrets,
+			Tolerance:   opts.Tolerance,
+			ReplayStore: opts.ReplayStore,
+			ReplayTTL:   opts.ReplayTTL,
+		},
+		opts:     opts,
+		handlers: map[string]WebhookHandlerFunc{},
+		inFlight: map[string]bool{},
+	}
+}
+
+// On registers fn for the given event type, replacing any previous handler.
+func (h *WebhookHandler) On(eventType string, fn WebhookHandlerFunc) *WebhookHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[eventType] = fn
+	return h
+}
+
+// OnScreenshotCompleted registers fn for EventScreenshotCompleted events.
+func (h *WebhookHandler) OnScreenshotCompleted(fn WebhookHandlerFunc) *WebhookHandler {
+	return h.On(EventScreenshotCompleted, fn)
+}
+
+// OnBatchCompleted registers fn for EventBatchCompleted events.
+func (h *WebhookHandler) OnBatchCompleted(fn WebhookHandlerFunc) *WebhookHandler {
+	return h.On(EventBatchCompleted, fn)
+}
+
+// OnFailed registers fn for every "*.failed" event type that has no more
+// specific handler registered with On.
+func (h *WebhookHandler) OnFailed(fn WebhookHandlerFunc) *WebhookHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failed = fn
+	return h
+}
+
+// OnUnhandled registers fn for event types with no other handler.
+func (h *WebhookHandler) OnUnhandled(fn WebhookHandlerFunc) *WebhookHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fallback = fn
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.reject(w, r, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.opts.MaxBodyBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			h.reject(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		h.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+	payload := string(body)
+
+	headers := ExtractWebhookHTTPHeaders(r.Header)
+	verification, err := h.verifier.verify(payload, headers)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrWebhookSignature), errors.Is(err, ErrWebhookExpired),
+			errors.Is(err, ErrWebhookMalformed), errors.Is(err, ErrWebhookMissingID),
+			errors.Is(err, ErrWebhookIDMismatch):
+			h.reject(w, r, http.StatusUnauthorized, err)
+		default:
+			h.reject(w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	event, err := ParseWebhook(payload)
+	if err != nil {
+		h.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if event.ID == "" {
+		event.ID = headers.ID
+	}
+
+	if h.opts.ReplayStore != nil {
+		id := verification.DeliveryID
+		if !h.begin(id) {
+			w.Header().Set("Retry-After", "1")
+			h.reject(w, r, http.StatusServiceUnavailable, ErrWebhookInProgress)
+			return
+		}
+		defer h.end(id)
+		if err := h.verifier.claim(r.Context(), id); err != nil {
+			if errors.Is(err, ErrWebhookReplayed) {
+				h.reject(w, r, http.StatusOK, err)
+			} else {
+				h.reject(w, r, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+
+	if fn := h.handlerFor(event.Event); fn != nil {
+		if err := fn(r.Context(), event); err != nil {
+			if h.opts.ReplayStore != nil {
+				_ = h.opts.ReplayStore.Forget(r.Context(), verification.DeliveryID)
+			}
+			h.reject(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// begin marks a delivery as being handled, reporting false if it already is.
+func (h *WebhookHandler) begin(id string) bool {
+	h.flightMu.Lock()
+	defer h.flightMu.Unlock()
+	if h.inFlight[id] {
+		return false
+	}
+	h.inFlight[id] = true
+	return true
+}
+
+func (h *WebhookHandler) end(id string) {
+	h.flightMu.Lock()
+	defer h.flightMu.Unlock()
+	delete(h.inFlight, id)
+}
+
+func (h *WebhookHandler) handlerFor(eventType string) WebhookHandlerFunc {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if fn, ok := h.handlers[eventType]; ok {
+		return fn
+	}
+	if h.failed != nil && strings.HasSuffix(eventType, ".failed") {
+		return h.failed
+	}
+	return h.fallback
+}
+
+func (h *WebhookHandler) reject(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if h.opts.OnError != nil {
+		h.opts.OnError(r, err)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// ExtractWebhookHTTPHeaders extracts the signature, timestamp, and ID from
+// an http.Header. Repeated signature headers are joined with ", ".
+func ExtractWebhookHTTPHeaders(h http.Header) WebhookHeaders {
+	return WebhookHeaders{
+		Signature: strings.Join(h.Values(SignatureHeader), ", "),
+		Timestamp: h.Get(TimestampHeader),
+		ID:        h.Get(IDHeader),
+	}
+}
@@ -0,0 +1,172 @@
+package renderscreenshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "whsec_test_secret"
+
+func newSignedWebhookRequest(payload string) *http.Request {
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(payload))
+	req.Header.Set(SignatureHeader, createTestSignature(timestamp, payload, testWebhookSecret))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(IDHeader, "evt_header")
+	return req
+}
+
+func TestWebhookHandlerDispatch(t *testing.T) {
+	var got []string
+	h := NewWebhookHandler(testWebhookSecret, WebhookHandlerOptions{}).
+		OnScreenshotCompleted(func(_ context.Context, e *WebhookEvent) error {
+			got = append(got, "screenshot:"+e.ID)
+			return nil
+		}).
+		OnBatchCompleted(func(_ context.Context, e *WebhookEvent) error {
+			got = append(got, "batch:"+e.ID)
+			return nil
+		}).
+		OnFailed(func(_ context.Context, e *WebhookEvent) error {
+			got = append(got, "failed:"+e.Event)
+			return nil
+		})
+
+	payloads := []string{
+		`{"type":"screenshot.completed","id":"evt_1","data":{}}`,
+		`{"type":"batch.completed","id":"evt_2","data":{}}`,
+		`{"type":"screenshot.failed","id":"evt_3","data":{}}`,
+		`{"type":"batch.failed","id":"evt_4","data":{}}`,
+		`{"type":"something.new","id":"evt_5","data":{}}`,
+	}
+	for _, p := range payloads {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newSignedWebhookRequest(p))
+		if rec.Code != http.StatusOK {
+			t.Errorf("payload %s: status = %d, want 200", p, rec.Code)
+		}
+	}
+
+	want := []string{"screenshot:evt_1", "batch:evt_2", "failed:screenshot.failed", "failed:batch.failed"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("dispatched = %v, want %v", got, want)
+	}
+}
+
+func TestWebhookHandlerEventIDFromHeader(t *testing.T) {
+	var id string
+	h := NewWebhookHandler(testWebhookSecret, WebhookHandlerOptions{}).
+		OnUnhandled(func(_ context.Context, e *WebhookEvent) error {
+			id = e.ID
+			return nil
+		})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newSignedWebhookRequest(`{"type":"other"}`))
+	if id != "evt_header" {
+		t.Errorf("ID = %q, want evt_header", id)
+	}
+}
+
+func TestWebhookHandlerStatusCodes(t *testing.T) {
+	h := NewWebhookHandler(testWebhookSecret, WebhookHandlerOptions{MaxBodyBytes: 64}).
+		OnScreenshotCompleted(func(context.Context, *WebhookEvent) error {
+			return errors.New("database unavailable")
+		})
+
+	tests := []struct {
+		name string
+		req  func() *http.Request
+		want int
+	}{
+		{
+			name: "wrong method",
+			req:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/webhooks", nil) },
+			want: http.StatusMethodNotAllowed,
+		},
+		{
+			name: "body too large",
+			req: func() *http.Request {
+				return newSignedWebhookRequest(`{"type":"x","data":{"pad":"` + strings.Repeat("a", 100) + `"}}`)
+			},
+			want: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "bad signature",
+			req: func() *http.Request {
+				req := newSignedWebhookRequest(`{"type":"x"}`)
+				req.Header.Set(SignatureHeader, "sha256=deadbeef")
+				return req
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "malformed payload",
+			req:  func() *http.Request { return newSignedWebhookRequest(`not json`) },
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "handler error",
+			req:  func() *http.Request { return newSignedWebhookRequest(`{"type":"screenshot.completed"}`) },
+			want: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, tt.req())
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerOnError(t *testing.T) {
+	var reported error
+	h := NewWebhookHandler(testWebhookSecret, WebhookHandlerOptions{
+		OnError: func(_ *http.Request, err error) { reported = err },
+	})
+
+	req := newSignedWebhookRequest(`{"type":"x"}`)
+	req.Header.Del(SignatureHeader)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if reported == nil {
+		t.Error("expected OnError to be called")
+	}
+}
+
+func TestExtractWebhookHTTPHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("x-webhook-signature", "sha256=abc")
+	header.Set("X-Webhook-Timestamp", "12345")
+	header.Set("X-WEBHOOK-ID", "evt_1")
+
+	h := ExtractWebhookHTTPHeaders(header)
+	if h.Signature != "sha256=abc" || h.Timestamp != "12345" || h.ID != "evt_1" {
+		t.Errorf("ExtractWebhookHTTPHeaders() = %+v", h)
+	}
+}
+
+func TestWebhookHandlerSecretRotation(t *testing.T) {
+	payload := `{"type":"screenshot.completed","data":{}}`
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(payload))
+	req.Header.Add(SignatureHeader, createTestSignature(timestamp, payload, "whsec_unrelated"))
+	req.Header.Add(SignatureHeader, createTestSignature(timestamp, payload, "whsec_previous"))
+	req.Header.Set(TimestampHeader, timestamp)
+
+	h := NewWebhookHandler("whsec_current", WebhookHandlerOptions{Secrets: []string{"whsec_previous"}})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,319 @@
+package renderscreenshot
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReplayTTL is how long delivery IDs are remembered by default. It is
+// much longer than DefaultTolerance so that sender retries of a delivery that
+// was already processed are also recognised.
+const DefaultReplayTTL = 24 * time.Hour
+
+// ReplayStore records webhook delivery IDs that have already been accepted.
+// Implementations must be safe for concurrent use.
+type ReplayStore interface {
+	// CheckAndStore atomically records id for ttl and reports whether it
+	// was already recorded.
+	CheckAndStore(ctx context.Context, id string, ttl time.Duration) (seen bool, err error)
+	// Forget removes id so that a later delivery with the same ID is accepted.
+	Forget(ctx context.Context, id string) error
+}
+
+// WebhookVerifier verifies webhook signatures and, when a ReplayStore is
+// set, rejects deliveries whose ID has already been accepted.
+//
+// X-Webhook-ID is not covered by the signature, so the ID recorded is the
+// payload's signed "id", and a header ID that differs from it is rejected.
+// A payload without an "id" is recorded under a hash of its body.
+type WebhookVerifier struct {
+	// Secret is the webhook signing secret.
+	Secret string
+	// Secrets are further secrets accepted alongside Secret, e.g. the
+	// previous secret while a rotation is in progress.
+	Secrets []string
+	// Tolerance is the maximum age of the delivery timestamp. Defaults to DefaultTolerance.
+	Tolerance time.Duration
+	// ReplayStore, if set, enables duplicate delivery detection.
+	ReplayStore ReplayStore
+	// ReplayTTL is how long accepted IDs are remembered. Defaults to DefaultReplayTTL.
+	ReplayTTL time.Duration
+}
+
+// Verify checks the signature and timestamp of a delivery and records its ID
+// in the ReplayStore. The returned SecretIndex counts Secret as 0 and
+// Secrets from 1.
+//
+// Errors wrap ErrWebhookExpired, ErrWebhookMalformed, ErrWebhookSignature,
+// ErrWebhookMissingID, ErrWebhookIDMismatch or ErrWebhookReplayed, or are
+// replay store errors.
+func (v *WebhookVerifier) Verify(ctx context.Context, payload string, headers WebhookHeaders) (*WebhookVerification, error) {
+	result, err := v.verify(payload, headers)
+	if err != nil {
+		return nil, err
+	}
+	if v.ReplayStore != nil {
+		if err := v.claim(ctx, result.DeliveryID); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// verify checks the signature and, with a ReplayStore, works out the
+// delivery ID without recording it.
+func (v *WebhookVerifier) verify(payload string, headers WebhookHeaders) (*WebhookVerification, error) {
+	secrets := append([]string{v.Secret}, v.Secrets...)
+	result, err := VerifyWebhookSignature(payload, headers.Signature, headers.Timestamp, secrets, v.Tolerance)
+	if err != nil {
+		return nil, err
+	}
+	if v.ReplayStore != nil {
+		if result.DeliveryID, err = deliveryID(payload, headers.ID); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// claim records id in the ReplayStore, failing with ErrWebhookReplayed if
+// it was already recorded.
+func (v *WebhookVerifier) claim(ctx context.Context, id string) error {
+	ttl := v.ReplayTTL
+	if ttl <= 0 {
+		ttl = DefaultReplayTTL
+	}
+	seen, err := v.ReplayStore.CheckAndStore(ctx, id, ttl)
+	if err != nil {
+		return fmt.Errorf("replay store: %w", err)
+	}
+	if seen {
+		return ErrWebhookReplayed
+	}
+	return nil
+}
+
+// deliveryID returns the ID a verified delivery is recorded under: the
+// payload's "id", or a hash of the payload if it has none. A delivery must
+// carry an ID in the payload or the X-Webhook-ID header.
+func deliveryID(payload, headerID string) (string, error) {
+	var body struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal([]byte(payload), &body)
+	switch {
+	case body.ID != "" && headerID != "" && headerID != body.ID:
+		return "", fmt.Errorf("%w: header %q, payload %q", ErrWebhookIDMismatch, headerID, body.ID)
+	case body.ID != "":
+		return body.ID, nil
+	case headerID == "":
+		return "", ErrWebhookMissingID
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// MemoryReplayStore is an in-process ReplayStore whose entries expire after
+// their TTL. It does not survive restarts and is not shared between replicas.
+type MemoryReplayStore struct {
+	mu        sync.Mutex
+	entries   map[string]time.Time
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryReplayStore creates an empty MemoryReplayStore.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{
+		entries: map[string]time.Time{},
+		now:     time.Now,
+	}
+}
+
+// CheckAndStore implements ReplayStore.
+func (s *MemoryReplayStore) CheckAndStore(_ context.Context, id string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+	if expires, ok := s.entries[id]; ok && now.Before(expires) {
+		return true, nil
+	}
+	s.entries[id] = now.Add(ttl)
+	return false, nil
+}
+
+// Forget implements ReplayStore.
+func (s *MemoryReplayStore) Forget(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+	return nil
+}
+
+// Len returns the number of unexpired IDs in the store.
+func (s *MemoryReplayStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	n := 0
+	for _, expires := range s.entries {
+		if now.Before(expires) {
+			n++
+		}
+	}
+	return n
+}
+
+// sweep drops expired entries at most once a minute. Callers must hold s.mu.
+func (s *MemoryReplayStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for id, expires := range s.entries {
+		if !now.Before(expires) {
+			delete(s.entries, id)
+		}
+	}
+	s.nextSweep = now.Add(time.Minute)
+}
+
+// FileReplayStore is a ReplayStore that persists IDs to an append-only file
+// so that replay protection survives process restarts. Each line holds an
+// expiry Unix timestamp and an ID; an expiry of 0 marks a forgotten ID.
+// The file is compacted when the store is opened.
+//
+// A FileReplayStore must not be shared between processes.
+type FileReplayStore struct {
+	mem  *MemoryReplayStore
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileReplayStore opens or creates the store at path, loading the IDs
+// that have not yet expired.
+func NewFileReplayStore(path string) (*FileReplayStore, error) {
+	mem := NewMemoryReplayStore()
+	if err := loadReplayFile(path, mem); err != nil {
+		return nil, err
+	}
+	if err := writeReplayFile(path, mem); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileReplayStore{mem: mem, file: f}, nil
+}
+
+// CheckAndStore implements ReplayStore.
+func (s *FileReplayStore) CheckAndStore(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	if strings.ContainsAny(id, " \r\n") {
+		return false, fmt.Errorf("invalid webhook delivery ID %q", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen, _ := s.mem.CheckAndStore(ctx, id, ttl)
+	if seen {
+		return true, nil
+	}
+	expires := s.mem.now().Add(ttl).Unix()
+	if err := s.append(expires, id); err != nil {
+		_ = s.mem.Forget(ctx, id)
+		return false, err
+	}
+	return false, nil
+}
+
+// Forget implements ReplayStore.
+func (s *FileReplayStore) Forget(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.mem.Forget(ctx, id)
+	return s.append(0, id)
+}
+
+// Close closes the underlying file.
+func (s *FileReplayStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+func (s *FileReplayStore) append(expires int64, id string) error {
+	if _, err := fmt.Fprintf(s.file, "%d %s\n", expires, id); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func loadReplayFile(path string, mem *MemoryReplayStore) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		expiresStr, id, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || id == "" {
+			continue
+		}
+		expires, err := strconv.ParseInt(expiresStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if expires == 0 {
+			delete(mem.entries, id)
+			continue
+		}
+		mem.entries[id] = time.Unix(expires, 0)
+	}
+	return scanner.Err()
+}
+
+// writeReplayFile atomically rewrites path with the unexpired entries of mem.
+func writeReplayFile(path string, mem *MemoryReplayStore) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	now := mem.now()
+	for id, expires := range mem.entries {
+		if !now.Before(expires) {
+			delete(mem.entries, id)
+			continue
+		}
+		fmt.Fprintf(w, "%d %s\n", expires.Unix(), id)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
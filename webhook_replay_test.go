@@ -0,0 +1,269 @@
+package renderscreenshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedWebhookHeaders(payload, id string) WebhookHeaders {
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	return WebhookHeaders{
+		Signature: createTestSignature(timestamp, payload, testWebhookSecret),
+		Timestamp: timestamp,
+		ID:        id,
+	}
+}
+
+func TestWebhookVerifierRejectsReplay(t *testing.T) {
+	ctx := context.Background()
+	v := &WebhookVerifier{Secret: testWebhookSecret, ReplayStore: NewMemoryReplayStore()}
+	payload := `{"type":"screenshot.completed","id":"evt_1"}`
+	headers := signedWebhookHeaders(payload, "evt_1")
+
+	result, err := v.Verify(ctx, payload, headers)
+	if err != nil {
+		t.Fatalf("first delivery: unexpected error: %v", err)
+	}
+	if result.DeliveryID != "evt_1" {
+		t.Errorf("DeliveryID = %q, want evt_1", result.DeliveryID)
+	}
+	if _, err := v.Verify(ctx, payload, headers); !errors.Is(err, ErrWebhookReplayed) {
+		t.Errorf("second delivery: err = %v, want ErrWebhookReplayed", err)
+	}
+	if _, err := v.Verify(ctx, payload, signedWebhookHeaders(payload, "")); !errors.Is(err, ErrWebhookReplayed) {
+		t.Errorf("without header ID: err = %v, want ErrWebhookReplayed", err)
+	}
+	other := `{"type":"screenshot.completed","id":"evt_2"}`
+	if _, err := v.Verify(ctx, other, signedWebhookHeaders(other, "evt_2")); err != nil {
+		t.Errorf("other ID: unexpected error: %v", err)
+	}
+}
+
+func TestWebhookVerifierIgnoresUnsignedID(t *testing.T) {
+	ctx := context.Background()
+	v := &WebhookVerifier{Secret: testWebhookSecret, ReplayStore: NewMemoryReplayStore()}
+
+	// A captured delivery replayed with a fresh X-Webhook-ID is still a replay.
+	payload := `{"type":"screenshot.completed","id":"evt_1"}`
+	if _, err := v.Verify(ctx, payload, signedWebhookHeaders(payload, "evt_1")); err != nil {
+		t.Fatalf("first delivery: unexpected error: %v", err)
+	}
+	if _, err := v.Verify(ctx, payload, signedWebhookHeaders(payload, "evt_forged")); !errors.Is(err, ErrWebhookIDMismatch) {
+		t.Errorf("forged header ID: err = %v, want ErrWebhookIDMismatch", err)
+	}
+
+	// Without a payload ID, the delivery is identified by its signed body.
+	payload = `{"type":"screenshot.completed","data":{"id":"scr_1"}}`
+	if _, err := v.Verify(ctx, payload, signedWebhookHeaders(payload, "evt_a")); err != nil {
+		t.Fatalf("first delivery: unexpected error: %v", err)
+	}
+	if _, err := v.Verify(ctx, payload, signedWebhookHeaders(payload, "evt_b")); !errors.Is(err, ErrWebhookReplayed) {
+		t.Errorf("new header ID: err = %v, want ErrWebhookReplayed", err)
+	}
+}
+
+func TestWebhookVerifierErrors(t *testing.T) {
+	payload := `{"type":"x"}`
+	v := &WebhookVerifier{Secret: testWebhookSecret, ReplayStore: NewMemoryReplayStore()}
+
+	bad := signedWebhookHeaders(payload, "evt_1")
+	bad.Signature = "sha256=" + strings.Repeat("ab", 32)
+	if _, err := v.Verify(context.Background(), payload, bad); !errors.Is(err, ErrWebhookSignature) {
+		t.Errorf("bad signature: err = %v, want ErrWebhookSignature", err)
+	}
+	if _, err := v.Verify(context.Background(), payload, signedWebhookHeaders(payload, "")); !errors.Is(err, ErrWebhookMissingID) {
+		t.Errorf("missing ID: err = %v, want ErrWebhookMissingID", err)
+	}
+
+	noStore := &WebhookVerifier{Secret: testWebhookSecret}
+	headers := signedWebhookHeaders(payload, "")
+	for i := 0; i < 2; i++ {
+		if _, err := noStore.Verify(context.Background(), payload, headers); err != nil {
+			t.Errorf("without store: unexpected error: %v", err)
+		}
+	}
+}
+
+func TestMemoryReplayStoreExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewMemoryReplayStore()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if seen, _ := s.CheckAndStore(ctx, "evt_1", time.Minute); seen {
+		t.Error("first CheckAndStore: seen = true, want false")
+	}
+	if seen, _ := s.CheckAndStore(ctx, "evt_1", time.Minute); !seen {
+		t.Error("second CheckAndStore: seen = false, want true")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if s.Len() != 0 {
+		t.Errorf("Len() = %d after expiry, want 0", s.Len())
+	}
+	if seen, _ := s.CheckAndStore(ctx, "evt_1", time.Minute); seen {
+		t.Error("after expiry: seen = true, want false")
+	}
+
+	_ = s.Forget(ctx, "evt_1")
+	if seen, _ := s.CheckAndStore(ctx, "evt_1", time.Minute); seen {
+		t.Error("after Forget: seen = true, want false")
+	}
+}
+
+func TestFileReplayStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.log")
+	ctx := context.Background()
+
+	s, err := NewFileReplayStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _ = s.CheckAndStore(ctx, "evt_1", time.Hour)
+	_, _ = s.CheckAndStore(ctx, "evt_2", time.Hour)
+	_, _ = s.CheckAndStore(ctx, "evt_old", -time.Second)
+	_ = s.Forget(ctx, "evt_2")
+	_ = s.Close()
+
+	reopened, err := NewFileReplayStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reopened.Close()
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"evt_1", true},
+		{"evt_2", false},
+		{"evt_old", false},
+	}
+	for _, tt := range tests {
+		if seen, _ := reopened.CheckAndStore(ctx, tt.id, time.Hour); seen != tt.want {
+			t.Errorf("CheckAndStore(%q) = %v, want %v", tt.id, seen, tt.want)
+		}
+	}
+
+	if _, err := reopened.CheckAndStore(ctx, "bad id\n", time.Hour); err == nil {
+		t.Error("expected error for ID containing whitespace")
+	}
+
+	data, _ := os.ReadFile(path)
+	if len(data) == 0 {
+		t.Error("expected store file to be written")
+	}
+}
+
+func TestWebhookHandlerReplayProtection(t *testing.T) {
+	calls := 0
+	fail := true
+	var reported []error
+	h := NewWebhookHandler(testWebhookSecret, WebhookHandlerOptions{
+		ReplayStore: NewMemoryReplayStore(),
+		OnError:     func(_ *http.Request, err error) { reported = append(reported, err) },
+	}).OnScreenshotCompleted(func(context.Context, *WebhookEvent) error {
+		calls++
+		if fail {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	payload := `{"type":"screenshot.completed","data":{}}`
+	deliver := func() int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newSignedWebhookRequest(payload))
+		return rec.Code
+	}
+
+	if code := deliver(); code != http.StatusInternalServerError {
+		t.Fatalf("failed delivery: status = %d, want 500", code)
+	}
+	fail = false
+	if code := deliver(); code != http.StatusOK {
+		t.Fatalf("retried delivery: status = %d, want 200", code)
+	}
+	if code := deliver(); code != http.StatusOK {
+		t.Fatalf("duplicate delivery: status = %d, want 200", code)
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want 2", calls)
+	}
+	if len(reported) != 2 || !errors.Is(reported[1], ErrWebhookReplayed) {
+		t.Errorf("reported = %v, want handler error then ErrWebhookReplayed", reported)
+	}
+}
+
+func TestWebhookHandlerInFlightDuplicate(t *testing.T) {
+	store := NewMemoryReplayStore()
+	started := make(chan struct{})
+	release := make(chan error)
+	h := NewWebhookHandler(testWebhookSecret, WebhookHandlerOptions{ReplayStore: store}).
+		OnScreenshotCompleted(func(context.Context, *WebhookEvent) error {
+			started <- struct{}{}
+			return <-release
+		})
+
+	payload := `{"type":"screenshot.completed","id":"evt_1","data":{}}`
+	deliver := func() *httptest.ResponseRecorder {
+		req := newSignedWebhookRequest(payload)
+		req.Header.Set(IDHeader, "evt_1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := make(chan int)
+	go func() { first <- deliver().Code }()
+	<-started
+
+	// The duplicate must be retried: the first attempt may still fail.
+	rec := deliver()
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("in-flight duplicate: status = %d, Retry-After = %q, want 503 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	release <- errors.New("temporary failure")
+	if code := <-first; code != http.StatusInternalServerError {
+		t.Fatalf("first delivery: status = %d, want 500", code)
+	}
+
+	go func() { first <- deliver().Code }()
+	<-started
+	release <- nil
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("retried delivery: status = %d, want 200", code)
+	}
+	if code := deliver().Code; code != http.StatusOK || store.Len() != 1 {
+		t.Errorf("duplicate delivery: status = %d, stored IDs = %d, want 200 and 1", code, store.Len())
+	}
+}
+
+func TestWebhookHandlerReplayRejections(t *testing.T) {
+	store := NewMemoryReplayStore()
+	h := NewWebhookHandler(testWebhookSecret, WebhookHandlerOptions{ReplayStore: store})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newSignedWebhookRequest(`{"type":"screenshot.completed","id":"evt_1"}`))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("mismatched header ID: status = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newSignedWebhookRequest(`not json`))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid payload: status = %d, want 400", rec.Code)
+	}
+	if n := store.Len(); n != 0 {
+		t.Errorf("stored IDs = %d, want 0", n)
+	}
+}
@@ -0,0 +1,196 @@
+package renderscreenshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Default WebhookSender retry settings.
+const (
+	DefaultWebhookMaxAttempts    = 5
+	DefaultWebhookInitialBackoff = time.Second
+	DefaultWebhookMaxBackoff     = time.Minute
+)
+
+// SignWebhook returns the X-Webhook-Signature value for payload signed with
+// secret at the given Unix timestamp, in the same format the service uses.
+func SignWebhook(payload, secret string, timestamp int64) string {
+	return "sha256=" + hex.EncodeToString(webhookMAC(payload, secret, strconv.FormatInt(timestamp, 10)))
+}
+
+// WebhookSender posts signed webhook deliveries to a URL the way the service
+// does, for testing webhook endpoints end to end. Every attempt is signed with
+// a fresh timestamp and carries the same X-Webhook-ID. Network errors and
+// 408, 429 and 5xx responses are retried with exponential backoff; other
+// non-2xx responses fail immediately.
+type WebhookSender struct {
+	// Secret is the signing secret.
+	Secret string
+	// HTTPClient sends the requests. Defaults to a client with a 30s timeout.
+	HTTPClient *http.Client
+	// MaxAttempts is the total number of attempts. Defaults to DefaultWebhookMaxAttempts.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled for each
+	// further retry. Defaults to DefaultWebhookInitialBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. Defaults to DefaultWebhookMaxBackoff.
+	MaxBackoff time.Duration
+}
+
+// NewWebhookSender creates a WebhookSender with default retry settings.
+func NewWebhookSender(secret string) *WebhookSender {
+	return &WebhookSender{Secret: secret}
+}
+
+// WebhookAttempt describes one delivery attempt.
+type WebhookAttempt struct {
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// WebhookDelivery is the outcome of WebhookSender.Send.
+type WebhookDelivery struct {
+	// ID is the X-Webhook-ID sent with every attempt.
+	ID string
+	// Attempts lists every attempt in order.
+	Attempts []WebhookAttempt
+	// Delivered is true if the endpoint responded with 2xx.
+	Delivered bool
+}
+
+// Send delivers event to url. A missing event ID is generated and a zero
+// Timestamp is set to now for this delivery only; event is not modified, and
+// the ID sent is reported in WebhookDelivery.ID. If event.Raw is set it is
+// sent as the body unchanged; otherwise the body is built from the event
+// fields.
+//
+// The returned delivery is non-nil even when err is not.
+func (s *WebhookSender) Send(ctx context.Context, url string, event *WebhookEvent) (*WebhookDelivery, error) {
+	e := *event
+	event = &e
+	if event.ID == "" {
+		event.ID = "evt_" + newIdempotencyKey()
+	}
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+
+	payload := []byte(event.Raw)
+	if len(payload) == 0 {
+		data := event.Data
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		var err error
+		payload, err = json.Marshal(map[string]interface{}{
+			"id":        event.ID,
+			"type":      event.Event,
+			"timestamp": event.Timestamp,
+			"data":      data,
+		})
+		if err != nil {
+			return &WebhookDelivery{ID: event.ID}, err
+		}
+	}
+	return s.SendPayload(ctx, url, event.ID, payload)
+}
+
+// SendPayload delivers a raw JSON payload to url with the given delivery ID.
+func (s *WebhookSender) SendPayload(ctx context.Context, url, id string, payload []byte) (*WebhookDelivery, error) {
+	delivery := &WebhookDelivery{ID: id}
+	maxAttempts := s.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultWebhookMaxAttempts
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(s.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return delivery, ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		result := s.attempt(ctx, url, id, payload)
+		delivery.Attempts = append(delivery.Attempts, result)
+
+		if result.Err == nil && result.StatusCode >= 200 && result.StatusCode < 300 {
+			delivery.Delivered = true
+			return delivery, nil
+		}
+		if ctx.Err() != nil {
+			return delivery, ctx.Err()
+		}
+		if result.Err == nil && !retryableWebhookStatus(result.StatusCode) {
+			break
+		}
+	}
+
+	last := delivery.Attempts[len(delivery.Attempts)-1]
+	if last.Err != nil {
+		return delivery, fmt.Errorf("webhook %s not delivered after %d attempt(s): %w", id, len(delivery.Attempts), last.Err)
+	}
+	return delivery, fmt.Errorf("webhook %s not delivered after %d attempt(s): status %d", id, len(delivery.Attempts), last.StatusCode)
+}
+
+func (s *WebhookSender) attempt(ctx context.Context, url, id string, payload []byte) WebhookAttempt {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return WebhookAttempt{Err: err}
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("renderscreenshot-go/%s", Version))
+	req.Header.Set(SignatureHeader, SignWebhook(string(payload), s.Secret, timestamp))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(IDHeader, id)
+
+	client := s.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return WebhookAttempt{Err: err, Duration: time.Since(start)}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return WebhookAttempt{StatusCode: resp.StatusCode, Duration: time.Since(start)}
+}
+
+func (s *WebhookSender) backoff(attempt int) time.Duration {
+	initial := s.InitialBackoff
+	if initial <= 0 {
+		initial = DefaultWebhookInitialBackoff
+	}
+	maxBackoff := s.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultWebhookMaxBackoff
+	}
+
+	delay := initial
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+func retryableWebhookStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
@@ -0,0 +1,167 @@
+package renderscreenshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookMatchesVerify(t *testing.T) {
+	payload := `{"type":"screenshot.completed"}`
+	ts := time.Now().Unix()
+	sig := SignWebhook(payload, testWebhookSecret, ts)
+
+	if want := createTestSignature(fmt.Sprintf("%d", ts), payload, testWebhookSecret); sig != want {
+		t.Errorf("SignWebhook() = %q, want %q", sig, want)
+	}
+	if !VerifyWebhook(payload, sig, fmt.Sprintf("%d", ts), testWebhookSecret, DefaultTolerance) {
+		t.Error("expected SignWebhook output to verify")
+	}
+}
+
+func TestWebhookSenderDeliversToHandler(t *testing.T) {
+	var got *WebhookEvent
+	h := NewWebhookHandler(testWebhookSecret, WebhookHandlerOptions{ReplayStore: NewMemoryReplayStore()}).
+		OnScreenshotCompleted(func(_ context.Context, e *WebhookEvent) error {
+			got = e
+			return nil
+		})
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	sender := NewWebhookSender(testWebhookSecret)
+	delivery, err := sender.Send(context.Background(), server.URL, &WebhookEvent{
+		Event: EventScreenshotCompleted,
+		Data:  map[string]interface{}{"id": "req_1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !delivery.Delivered || len(delivery.Attempts) != 1 {
+		t.Errorf("delivery = %+v", delivery)
+	}
+	if got == nil || got.ID != delivery.ID || got.Data["id"] != "req_1" {
+		t.Errorf("handler received %+v", got)
+	}
+}
+
+func TestWebhookSenderSendDoesNotModifyEvent(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get(IDHeader))
+	}))
+	defer server.Close()
+
+	event := &WebhookEvent{Event: EventScreenshotCompleted}
+	sender := NewWebhookSender(testWebhookSecret)
+	var deliveries []*WebhookDelivery
+	for i := 0; i < 2; i++ {
+		delivery, err := sender.Send(context.Background(), server.URL, event)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if event.ID != "" || event.Timestamp != 0 {
+		t.Errorf("event modified: ID = %q, Timestamp = %d", event.ID, event.Timestamp)
+	}
+	if deliveries[0].ID == "" || deliveries[0].ID == deliveries[1].ID {
+		t.Errorf("delivery IDs = %q, %q, want two distinct IDs", deliveries[0].ID, deliveries[1].ID)
+	}
+	if len(ids) != 2 || ids[0] != deliveries[0].ID || ids[1] != deliveries[1].ID {
+		t.Errorf("sent IDs = %v", ids)
+	}
+}
+
+func TestWebhookSenderRetriesWithBackoff(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !VerifyWebhook(string(body), r.Header.Get(SignatureHeader), r.Header.Get(TimestampHeader), testWebhookSecret, 0) {
+			t.Error("attempt not correctly signed")
+		}
+		ids = append(ids, r.Header.Get(IDHeader))
+		if len(ids) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := &WebhookSender{Secret: testWebhookSecret, InitialBackoff: time.Millisecond}
+	delivery, err := sender.Send(context.Background(), server.URL, &WebhookEvent{ID: "evt_retry", Event: EventBatchCompleted})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(delivery.Attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(delivery.Attempts))
+	}
+	if delivery.Attempts[0].StatusCode != 503 || delivery.Attempts[2].StatusCode != 204 {
+		t.Errorf("attempts = %+v", delivery.Attempts)
+	}
+	for i, id := range ids {
+		if id != "evt_retry" {
+			t.Errorf("attempt %d ID = %q, want evt_retry", i, id)
+		}
+	}
+}
+
+func TestWebhookSenderStopsOnClientError(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	sender := &WebhookSender{Secret: "wrong", InitialBackoff: time.Millisecond}
+	delivery, err := sender.Send(context.Background(), server.URL, &WebhookEvent{Event: "x"})
+	if err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Errorf("err = %v, want status 401 error", err)
+	}
+	if calls != 1 || delivery.Delivered {
+		t.Errorf("calls = %d, delivered = %v; want 1, false", calls, delivery.Delivered)
+	}
+}
+
+func TestWebhookSenderGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	sender := &WebhookSender{Secret: testWebhookSecret, MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	delivery, err := sender.SendPayload(context.Background(), server.URL, "evt_1", []byte(`{}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(delivery.Attempts) != 2 {
+		t.Errorf("attempts = %d, want 2", len(delivery.Attempts))
+	}
+}
+
+func TestWebhookSenderBackoff(t *testing.T) {
+	s := &WebhookSender{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := s.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -119,8 +121,13 @@ func TestVerifyWebhookTimingSafe(t *testing.T) {
 		t.Error("expected valid signature to pass")
 	}
 
-	// Tampered signature (same length) should fail
-	tampered := validSig[:len(validSig)-1] + "0"
+	// Tampered signature (same length) should fail. The last digit is
+	// flipped so the tampered value never equals the valid one.
+	last := "0"
+	if strings.HasSuffix(validSig, "0") {
+		last = "1"
+	}
+	tampered := validSig[:len(validSig)-1] + last
 	if VerifyWebhook(payload, tampered, timestamp, secret, DefaultTolerance) {
 		t.Error("expected tampered signature to fail")
 	}
@@ -245,3 +252,86 @@ func TestExtractWebhookHeadersEmpty(t *testing.T) {
 		t.Error("expected all empty values for empty headers")
 	}
 }
+
+func TestParseWebhookTags(t *testing.T) {
+	payload := `{"type":"screenshot.completed","id":"evt_1","data":{"url":"https://example.com","tags":{"tenant":"acme"}}}`
+	event, err := ParseWebhook(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.Tags["tenant"] != "acme" {
+		t.Errorf("Tags = %v", event.Tags)
+	}
+}
+
+func TestVerifyWebhookSignatureRotation(t *testing.T) {
+	payload := `{"type":"screenshot.completed"}`
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	oldSig := createTestSignature(timestamp, payload, "whsec_old")
+	newSig := createTestSignature(timestamp, payload, "whsec_new")
+	newHex := strings.TrimPrefix(newSig, "sha256=")
+
+	tests := []struct {
+		name       string
+		signature  string
+		secrets    []string
+		wantIndex  int
+		wantScheme string
+	}{
+		{"single old", oldSig, []string{"whsec_new", "whsec_old"}, 1, "sha256"},
+		{"comma separated", oldSig + "," + newSig, []string{"whsec_new"}, 0, "sha256"},
+		{"space separated", "sha256=" + strings.Repeat("00", 32) + " " + newSig, []string{"whsec_new"}, 0, "sha256"},
+		{"versioned scheme", "v0=zz, v1=" + newHex, []string{"whsec_other", "whsec_new"}, 1, "v1"},
+		{"timestamp in header", "t=" + timestamp + ",v1=" + newHex, []string{"whsec_new"}, 0, "v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := timestamp
+			if strings.HasPrefix(tt.signature, "t=") {
+				ts = ""
+			}
+			result, err := VerifyWebhookSignature(payload, tt.signature, ts, tt.secrets, DefaultTolerance)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.SecretIndex != tt.wantIndex {
+				t.Errorf("SecretIndex = %d, want %d", result.SecretIndex, tt.wantIndex)
+			}
+			if result.Scheme != tt.wantScheme {
+				t.Errorf("Scheme = %q, want %q", result.Scheme, tt.wantScheme)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookSignatureErrors(t *testing.T) {
+	payload := `{"type":"screenshot.completed"}`
+	now := fmt.Sprintf("%d", time.Now().Unix())
+	old := fmt.Sprintf("%d", time.Now().Unix()-600)
+
+	tests := []struct {
+		name      string
+		signature string
+		timestamp string
+		secrets   []string
+		want      error
+	}{
+		{"expired", createTestSignature(old, payload, "s1"), old, []string{"s1"}, ErrWebhookExpired},
+		{"mismatch", createTestSignature(now, payload, "s1"), now, []string{"s2", "s3"}, ErrWebhookSignature},
+		{"no secrets", createTestSignature(now, payload, "s1"), now, nil, ErrWebhookSignature},
+		{"not hex", "sha256=xyz", now, []string{"s1"}, ErrWebhookMalformed},
+		{"unknown scheme", "md5=abc", now, []string{"s1"}, ErrWebhookMalformed},
+		{"bad timestamp", createTestSignature(now, payload, "s1"), "yesterday", []string{"s1"}, ErrWebhookMalformed},
+		{"missing timestamp", createTestSignature(now, payload, "s1"), "", []string{"s1"}, ErrWebhookMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyWebhookSignature(payload, tt.signature, tt.timestamp, tt.secrets, DefaultTolerance)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}